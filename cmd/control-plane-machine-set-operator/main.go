@@ -22,6 +22,9 @@ import (
 	"os"
 	"time"
 
+	// Embed the time zone database so that maintenance windows may be defined in any time zone.
+	_ "time/tzdata"
+
 	"github.com/go-logr/logr"
 	"github.com/spf13/pflag"
 
@@ -36,7 +39,9 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/klog/v2/klogr"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 
 	cpmscontroller "github.com/openshift/cluster-control-plane-machine-set-operator/pkg/controllers/controlplanemachineset"
@@ -127,6 +132,25 @@ func main() { //nolint:funlen,cyclop
 		os.Exit(1)
 	}
 
+	// The etcd membership of the control plane is read from the etcd namespace,
+	// which is outside of the namespace watched by the manager's cache.
+	// Define a separate cluster, whose cache is scoped to the etcd namespace, from which to read it.
+	// The manager starts and syncs its cache before starting the controllers.
+	etcdCluster, err := cluster.New(cfg, func(o *cluster.Options) {
+		o.Scheme = mgr.GetScheme()
+		o.SyncPeriod = &defaultSyncPeriod
+		o.Cache = cache.Options{Namespaces: []string{util.EtcdNamespace}}
+	})
+	if err != nil {
+		setupLog.Error(err, "unable to set up etcd cluster")
+		os.Exit(1)
+	}
+
+	if err := mgr.Add(etcdCluster); err != nil {
+		setupLog.Error(err, "unable to add etcd cluster to manager")
+		os.Exit(1)
+	}
+
 	// Define an uncached client.
 	// More resource intensive than the default client,
 	// is to be used only in situations where we want to avoid the cache.
@@ -141,6 +165,7 @@ func main() { //nolint:funlen,cyclop
 	if err := (&cpmscontroller.ControlPlaneMachineSetReconciler{
 		Client:         mgr.GetClient(),
 		UncachedClient: client.NewNamespacedClient(uncachedClient, managedNamespace),
+		EtcdClient:     etcdCluster.GetClient(),
 		Scheme:         mgr.GetScheme(),
 		Namespace:      managedNamespace,
 		OperatorName:   "control-plane-machine-set",
@@ -0,0 +1,529 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers/openshift/machine/v1beta1/providerconfig"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/util"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+const (
+	// maxSurgeAnnotation is the annotation used on the ControlPlaneMachineSet to configure the maximum number of
+	// Machines that may be created above the desired number of replicas during a RollingUpdate.
+	// The value must be a positive integer. The value is capped based on the etcd quorum for the number of replicas.
+	maxSurgeAnnotation = "controlplanemachineset.machine.openshift.io/max-surge"
+
+	// pausedAnnotation is the annotation used on the ControlPlaneMachineSet to pause updates to the control plane.
+	// While the annotation is present, no Machines will be created or deleted, though the status will continue to be
+	// reported.
+	pausedAnnotation = "controlplanemachineset.machine.openshift.io/paused"
+
+	// rollbackToRevisionAnnotation is the annotation used on the ControlPlaneMachineSet to request that the template
+	// is restored from a previous revision. The value must be the number of a stored revision.
+	// Once the template has been restored, the annotation is removed.
+	rollbackToRevisionAnnotation = "controlplanemachineset.machine.openshift.io/rollback-to-revision"
+
+	// partitionAnnotation is the annotation used on the ControlPlaneMachineSet to stage a RollingUpdate.
+	// Only Machines with an index greater than or equal to the partition are replaced when they are outdated.
+	// The value must be a non-negative integer.
+	partitionAnnotation = "controlplanemachineset.machine.openshift.io/partition"
+
+	// minReadySecondsAnnotation is the annotation used on the ControlPlaneMachineSet to configure the minimum number of
+	// seconds for which a replacement Machine must have been Ready before the Machine it replaces is deleted during a
+	// RollingUpdate. The value must be a non-negative integer.
+	minReadySecondsAnnotation = "controlplanemachineset.machine.openshift.io/min-ready-seconds"
+
+	// progressDeadlineSecondsAnnotation is the annotation used on the ControlPlaneMachineSet to configure the maximum
+	// number of seconds a replacement Machine may take to become Ready before the update of its index is considered to
+	// have failed. The value must be a positive integer.
+	progressDeadlineSecondsAnnotation = "controlplanemachineset.machine.openshift.io/progress-deadline-seconds"
+
+	// progressDeadlineRetriesAnnotation is the annotation used on the ControlPlaneMachineSet to configure how many
+	// times a replacement Machine, which has exceeded the progress deadline, is deleted and created again before the
+	// ControlPlaneMachineSet reports itself as degraded. The value must be a non-negative integer.
+	progressDeadlineRetriesAnnotation = "controlplanemachineset.machine.openshift.io/progress-deadline-retries"
+
+	// failedReplacementRetriesAnnotation is the annotation used on the ControlPlaneMachineSet to configure how many
+	// times a replacement Machine, which has reported an error, is deleted and created again before the
+	// ControlPlaneMachineSet reports itself as degraded. The value must be a non-negative integer.
+	failedReplacementRetriesAnnotation = "controlplanemachineset.machine.openshift.io/failed-replacement-retries"
+
+	// replacementAttemptsAnnotation is the annotation added to the ControlPlaneMachineSet to persist the failed
+	// replacement attempts of each index, so that the retries and backoff are kept when the controller restarts.
+	// The value is a JSON object keyed by the index.
+	replacementAttemptsAnnotation = "controlplanemachineset.machine.openshift.io/replacement-attempts"
+
+	// dryRunAnnotation is the annotation used on the ControlPlaneMachineSet to enable the dry-run plan mode.
+	// While the annotation is present, no Machines will be created or deleted. Instead, the Machines that would have
+	// been created or deleted are published as a plan within a ConfigMap.
+	dryRunAnnotation = "controlplanemachineset.machine.openshift.io/dry-run"
+
+	// planPublishedAnnotation is the annotation added to the ControlPlaneMachineSet while a dry-run plan is published,
+	// so that the plan ConfigMap is only removed when the dry-run plan mode is disabled, rather than looked up on
+	// every reconcile.
+	planPublishedAnnotation = "controlplanemachineset.machine.openshift.io/dry-run-plan-published"
+
+	// replaceIndexesAnnotation is the annotation used on the ControlPlaneMachineSet to request that the Machines in
+	// the listed indexes are replaced, even when they are up to date. The value must be a comma separated list of
+	// indexes. Once the replacement of the Machines in an index is Ready, the index is removed from the list.
+	replaceIndexesAnnotation = "controlplanemachineset.machine.openshift.io/replace-indexes"
+
+	// markedReplaceIndexesAnnotation is the annotation added to the ControlPlaneMachineSet to record the indexes of the
+	// replace indexes annotation whose Machines have been marked for replacement, so that the request is not lost when
+	// a marked Machine is removed before its replacement is Ready. The value is a comma separated list of indexes.
+	markedReplaceIndexesAnnotation = "controlplanemachineset.machine.openshift.io/marked-replace-indexes"
+
+	// maintenanceWindowsAnnotation is the annotation used on the ControlPlaneMachineSet to restrict the creation and
+	// deletion of Machines during a RollingUpdate to weekly maintenance windows. The value must be a JSON object
+	// configuring the windows and the time zone in which they are defined.
+	maintenanceWindowsAnnotation = "controlplanemachineset.machine.openshift.io/maintenance-windows"
+
+	// stabilityGateClusterOperatorsAnnotation is the annotation used on the ControlPlaneMachineSet to enable the
+	// stability gate. The value must be a comma separated list of ClusterOperator names, which may be empty.
+	// While any of these ClusterOperators, or the ClusterVersion, is not stable, no Machines will be created or deleted.
+	stabilityGateClusterOperatorsAnnotation = "controlplanemachineset.machine.openshift.io/stability-gate-cluster-operators"
+
+	// stabilityGateSettleSecondsAnnotation is the annotation used on the ControlPlaneMachineSet to configure the
+	// minimum number of seconds for which the components checked by the stability gate must have been stable.
+	// The value must be a non-negative integer.
+	stabilityGateSettleSecondsAnnotation = "controlplanemachineset.machine.openshift.io/stability-gate-settle-seconds"
+
+	// replacementOrderAnnotation is the annotation used on the ControlPlaneMachineSet to configure the order in which
+	// outdated indexes are replaced during a RollingUpdate. The value must be one of Index, OldestFirst,
+	// UnhealthyFirst or FailureDomain.
+	replacementOrderAnnotation = "controlplanemachineset.machine.openshift.io/replacement-order"
+
+	// remediationNodeNotReadySecondsAnnotation is the annotation used on the ControlPlaneMachineSet to enable the
+	// remediation of unhealthy Machines. Machines whose Node has not been Ready for longer than this number of seconds
+	// are replaced. The value must be a positive integer.
+	remediationNodeNotReadySecondsAnnotation = "controlplanemachineset.machine.openshift.io/remediation-node-not-ready-seconds"
+
+	// reconcileExcessIndexesAnnotation is the annotation used on the ControlPlaneMachineSet to reconcile indexes in
+	// excess of the replicas, rather than reporting the ControlPlaneMachineSet as degraded. While the annotation is
+	// present, the Machines of an excess index are migrated into a missing index.
+	reconcileExcessIndexesAnnotation = "controlplanemachineset.machine.openshift.io/reconcile-excess-indexes"
+
+	// migrateToIndexAnnotation is the annotation added to the Machines of an excess index to record the index into
+	// which they are migrated. It allows the excess index to be removed once the migrated index has a Ready Machine.
+	migrateToIndexAnnotation = "controlplanemachineset.machine.openshift.io/migrate-to-index"
+
+	// defaultMaxSurge is the maximum surge used when no maximum surge has been configured on the ControlPlaneMachineSet.
+	defaultMaxSurge = 1
+)
+
+var (
+	// errInvalidMaxSurge is used to inform users that the value of the maximum surge annotation is not valid.
+	errInvalidMaxSurge = errors.New("maximum surge must be a positive integer")
+
+	// errInvalidPartition is used to inform users that the value of the partition annotation is not valid.
+	errInvalidPartition = errors.New("partition must be a non-negative integer")
+
+	// errInvalidMinReadySeconds is used to inform users that the value of the minimum ready seconds annotation is not valid.
+	errInvalidMinReadySeconds = errors.New("minimum ready seconds must be a non-negative integer")
+
+	// errInvalidProgressDeadline is used to inform users that the value of the progress deadline annotation is not valid.
+	errInvalidProgressDeadline = errors.New("progress deadline seconds must be a positive integer")
+
+	// errInvalidProgressDeadlineRetries is used to inform users that the value of the progress deadline retries
+	// annotation is not valid.
+	errInvalidProgressDeadlineRetries = errors.New("progress deadline retries must be a non-negative integer")
+
+	// errInvalidFailedReplacementRetries is used to inform users that the value of the failed replacement retries
+	// annotation is not valid.
+	errInvalidFailedReplacementRetries = errors.New("failed replacement retries must be a non-negative integer")
+
+	// errInvalidReplaceIndexes is used to inform users that the value of the replace indexes annotation is not valid.
+	errInvalidReplaceIndexes = errors.New("replace indexes must be a comma separated list of non-negative integers")
+)
+
+// getMaxSurge returns the effective maximum surge for the ControlPlaneMachineSet.
+// This is the value configured by the maximum surge annotation, or the default when no value is configured,
+// capped by the number of etcd members that may be unavailable while maintaining quorum.
+func getMaxSurge(cpms *machinev1.ControlPlaneMachineSet) (int, error) {
+	requestedMaxSurge, err := getRequestedMaxSurge(cpms)
+	if err != nil {
+		return 0, err
+	}
+
+	quorumMaxSurge := etcdFaultTolerance(pointer.Int32Deref(cpms.Spec.Replicas, 0))
+
+	// A surge of 1 is always safe as the replacement Machine is added to the
+	// etcd cluster before the outdated Machine is removed.
+	if quorumMaxSurge < defaultMaxSurge {
+		quorumMaxSurge = defaultMaxSurge
+	}
+
+	if requestedMaxSurge > quorumMaxSurge {
+		return quorumMaxSurge, nil
+	}
+
+	return requestedMaxSurge, nil
+}
+
+// rollingUpdateParameters holds the configuration of a RollingUpdate of the ControlPlaneMachineSet.
+type rollingUpdateParameters struct {
+	// maxSurge is the maximum number of Machines that may be created above the desired number of replicas.
+	maxSurge int
+
+	// partition is the index below which outdated Machines are not replaced.
+	partition int32
+
+	// minReady is the minimum duration for which a replacement Machine must have been Ready before
+	// the Machine it replaces is deleted.
+	minReady time.Duration
+
+	// maintenanceWindows are the windows during which Machines may be created and deleted.
+	maintenanceWindows maintenanceWindows
+
+	// order is the order in which outdated indexes are replaced.
+	order replacementOrder
+}
+
+// getRollingUpdateParameters returns the configuration of a RollingUpdate of the ControlPlaneMachineSet.
+func getRollingUpdateParameters(cpms *machinev1.ControlPlaneMachineSet) (rollingUpdateParameters, error) {
+	maxSurge, err := getMaxSurge(cpms)
+	if err != nil {
+		return rollingUpdateParameters{}, err
+	}
+
+	partition, err := getPartition(cpms)
+	if err != nil {
+		return rollingUpdateParameters{}, err
+	}
+
+	minReady, err := getMinReady(cpms)
+	if err != nil {
+		return rollingUpdateParameters{}, err
+	}
+
+	maintenanceWindows, err := getMaintenanceWindows(cpms)
+	if err != nil {
+		return rollingUpdateParameters{}, err
+	}
+
+	order, err := getReplacementOrder(cpms)
+	if err != nil {
+		return rollingUpdateParameters{}, err
+	}
+
+	return rollingUpdateParameters{
+		maxSurge:           maxSurge,
+		partition:          partition,
+		minReady:           minReady,
+		maintenanceWindows: maintenanceWindows,
+		order:              order,
+	}, nil
+}
+
+// getRequestedMaxSurge returns the maximum surge configured by the maximum surge annotation.
+// When the annotation is not present, the default maximum surge is returned.
+func getRequestedMaxSurge(cpms *machinev1.ControlPlaneMachineSet) (int, error) {
+	value, ok := cpms.Annotations[maxSurgeAnnotation]
+	if !ok {
+		return defaultMaxSurge, nil
+	}
+
+	maxSurge, err := strconv.Atoi(value)
+	if err != nil || maxSurge < 1 {
+		return 0, fmt.Errorf("%w: %s: %q", errInvalidMaxSurge, maxSurgeAnnotation, value)
+	}
+
+	return maxSurge, nil
+}
+
+// hasMaxSurgeAnnotation determines whether the maximum surge has been configured on the ControlPlaneMachineSet.
+func hasMaxSurgeAnnotation(cpms *machinev1.ControlPlaneMachineSet) bool {
+	_, ok := cpms.Annotations[maxSurgeAnnotation]
+	return ok
+}
+
+// getPartition returns the partition configured by the partition annotation.
+// When the annotation is not present, the partition is 0 and no index is held back.
+func getPartition(cpms *machinev1.ControlPlaneMachineSet) (int32, error) {
+	value, ok := cpms.Annotations[partitionAnnotation]
+	if !ok {
+		return 0, nil
+	}
+
+	partition, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || partition < 0 {
+		return 0, fmt.Errorf("%w: %s: %q", errInvalidPartition, partitionAnnotation, value)
+	}
+
+	return int32(partition), nil
+}
+
+// hasPartitionAnnotation determines whether a partition has been configured on the ControlPlaneMachineSet.
+func hasPartitionAnnotation(cpms *machinev1.ControlPlaneMachineSet) bool {
+	_, ok := cpms.Annotations[partitionAnnotation]
+	return ok
+}
+
+// getMinReady returns the minimum ready duration configured by the minimum ready seconds annotation.
+// When the annotation is not present, replacement Machines are considered available as soon as they are Ready.
+func getMinReady(cpms *machinev1.ControlPlaneMachineSet) (time.Duration, error) {
+	value, ok := cpms.Annotations[minReadySecondsAnnotation]
+	if !ok {
+		return 0, nil
+	}
+
+	minReadySeconds, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || minReadySeconds < 0 {
+		return 0, fmt.Errorf("%w: %s: %q", errInvalidMinReadySeconds, minReadySecondsAnnotation, value)
+	}
+
+	return time.Duration(minReadySeconds) * time.Second, nil
+}
+
+// hasMinReadySecondsAnnotation determines whether the minimum ready seconds have been configured on the
+// ControlPlaneMachineSet.
+func hasMinReadySecondsAnnotation(cpms *machinev1.ControlPlaneMachineSet) bool {
+	_, ok := cpms.Annotations[minReadySecondsAnnotation]
+	return ok
+}
+
+// getProgressDeadline returns the progress deadline configured by the progress deadline annotation.
+// When the annotation is not present, no progress deadline is enforced and 0 is returned.
+func getProgressDeadline(cpms *machinev1.ControlPlaneMachineSet) (time.Duration, error) {
+	value, ok := cpms.Annotations[progressDeadlineSecondsAnnotation]
+	if !ok {
+		return 0, nil
+	}
+
+	progressDeadlineSeconds, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || progressDeadlineSeconds < 1 {
+		return 0, fmt.Errorf("%w: %s: %q", errInvalidProgressDeadline, progressDeadlineSecondsAnnotation, value)
+	}
+
+	return time.Duration(progressDeadlineSeconds) * time.Second, nil
+}
+
+// getProgressDeadlineRetries returns the number of times a replacement Machine, which has exceeded the progress
+// deadline, should be replaced. When the annotation is not present, replacement Machines are not retried.
+func getProgressDeadlineRetries(cpms *machinev1.ControlPlaneMachineSet) (int, error) {
+	value, ok := cpms.Annotations[progressDeadlineRetriesAnnotation]
+	if !ok {
+		return 0, nil
+	}
+
+	retries, err := strconv.Atoi(value)
+	if err != nil || retries < 0 {
+		return 0, fmt.Errorf("%w: %s: %q", errInvalidProgressDeadlineRetries, progressDeadlineRetriesAnnotation, value)
+	}
+
+	return retries, nil
+}
+
+// getFailedReplacementRetries returns the number of times a replacement Machine, which has reported an error, should
+// be replaced. When the annotation is not present, failed replacement Machines are not retried.
+func getFailedReplacementRetries(cpms *machinev1.ControlPlaneMachineSet) (int, error) {
+	value, ok := cpms.Annotations[failedReplacementRetriesAnnotation]
+	if !ok {
+		return 0, nil
+	}
+
+	retries, err := strconv.Atoi(value)
+	if err != nil || retries < 0 {
+		return 0, fmt.Errorf("%w: %s: %q", errInvalidFailedReplacementRetries, failedReplacementRetriesAnnotation, value)
+	}
+
+	return retries, nil
+}
+
+// getReplaceIndexes returns the sorted list of indexes configured by the replace indexes annotation.
+// When the annotation is not present, no indexes are returned.
+func getReplaceIndexes(cpms *machinev1.ControlPlaneMachineSet) ([]int32, error) {
+	return getIndexesAnnotation(cpms, replaceIndexesAnnotation)
+}
+
+// getMarkedReplaceIndexes returns the sorted list of indexes recorded by the marked replace indexes annotation.
+// When the annotation is not present, no indexes are returned.
+func getMarkedReplaceIndexes(cpms *machinev1.ControlPlaneMachineSet) ([]int32, error) {
+	return getIndexesAnnotation(cpms, markedReplaceIndexesAnnotation)
+}
+
+// getIndexesAnnotation parses the comma separated list of indexes in the given annotation and returns them sorted and
+// without duplicates.
+func getIndexesAnnotation(cpms *machinev1.ControlPlaneMachineSet, annotation string) ([]int32, error) {
+	value, ok := cpms.Annotations[annotation]
+	if !ok {
+		return nil, nil
+	}
+
+	seen := make(map[int32]struct{})
+	indexes := []int32{}
+
+	for _, field := range strings.Split(value, ",") {
+		idx, err := strconv.ParseInt(strings.TrimSpace(field), 10, 32)
+		if err != nil || idx < 0 {
+			return nil, fmt.Errorf("%w: %s: %q", errInvalidReplaceIndexes, annotation, value)
+		}
+
+		if _, ok := seen[int32(idx)]; ok {
+			continue
+		}
+
+		seen[int32(idx)] = struct{}{}
+		indexes = append(indexes, int32(idx))
+	}
+
+	sort.Slice(indexes, func(i, j int) bool {
+		return indexes[i] < indexes[j]
+	})
+
+	return indexes, nil
+}
+
+// validateIgnoredFields checks that the ignored fields annotation, used by the machine provider to ignore differences
+// in provider config fields, is valid.
+func validateIgnoredFields(cpms *machinev1.ControlPlaneMachineSet) error {
+	if _, err := providerconfig.NewIgnoreRulesFromAnnotations(cpms.Annotations); err != nil {
+		return fmt.Errorf("could not parse ignored fields: %w", err)
+	}
+
+	return nil
+}
+
+// validateMaxMachineAge checks that the maximum machine age annotation, used by the machine provider to replace old
+// Machines, is valid.
+func validateMaxMachineAge(cpms *machinev1.ControlPlaneMachineSet) error {
+	if _, err := util.GetMaxMachineAge(cpms.Annotations); err != nil {
+		return fmt.Errorf("could not parse maximum machine age: %w", err)
+	}
+
+	return nil
+}
+
+// validateFailureDomainWeights checks that the failure domain weights annotation, used by the machine provider to
+// spread indexes across the failure domains, is valid.
+func validateFailureDomainWeights(cpms *machinev1.ControlPlaneMachineSet) error {
+	if _, err := util.GetFailureDomainWeights(cpms.Annotations); err != nil {
+		return fmt.Errorf("could not parse failure domain weights: %w", err)
+	}
+
+	return nil
+}
+
+// validateMachineProviderAnnotations checks that the annotations consumed by the machine provider are valid.
+// The machine provider cannot be constructed while any of these annotations is invalid.
+func validateMachineProviderAnnotations(cpms *machinev1.ControlPlaneMachineSet) error {
+	if err := validateIgnoredFields(cpms); err != nil {
+		return err
+	}
+
+	if err := validateMaxMachineAge(cpms); err != nil {
+		return err
+	}
+
+	if err := validateFailureDomainWeights(cpms); err != nil {
+		return err
+	}
+
+	return validateCapacityFailover(cpms)
+}
+
+// validateAnnotations checks that every annotation configuring the ControlPlaneMachineSet is valid, so that no step of
+// the reconcile acts upon an invalid annotation.
+// Annotations which can only be validated against the Machines or the revision history are validated by the step
+// consuming them.
+func validateAnnotations(cpms *machinev1.ControlPlaneMachineSet) error {
+	if err := validateMachineProviderAnnotations(cpms); err != nil {
+		return err
+	}
+
+	if _, err := getRollingUpdateParameters(cpms); err != nil {
+		return err
+	}
+
+	if _, _, err := getProgressDeadlineParameters(cpms); err != nil {
+		return err
+	}
+
+	if _, err := getFailedReplacementRetries(cpms); err != nil {
+		return err
+	}
+
+	if _, err := getRemediationTimeout(cpms); err != nil {
+		return err
+	}
+
+	if _, err := getStabilityGate(cpms); err != nil {
+		return err
+	}
+
+	if _, err := getReplaceIndexes(cpms); err != nil {
+		return err
+	}
+
+	_, err := getMarkedReplaceIndexes(cpms)
+
+	return err
+}
+
+// setInvalidAnnotationDegraded reports the invalid annotation via the Degraded condition of the ControlPlaneMachineSet.
+func setInvalidAnnotationDegraded(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, err error) {
+	meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+		Type:    conditionDegraded,
+		Status:  metav1.ConditionTrue,
+		Reason:  reasonInvalidAnnotation,
+		Message: fmt.Sprintf("%s: %s", invalidAnnotationMessage, err),
+	})
+
+	logger.Error(err, invalidAnnotationMessage)
+}
+
+// isPaused determines whether updates to the control plane have been paused on the ControlPlaneMachineSet.
+func isPaused(cpms *machinev1.ControlPlaneMachineSet) bool {
+	_, ok := cpms.Annotations[pausedAnnotation]
+	return ok
+}
+
+// isDryRun determines whether the dry-run plan mode has been enabled on the ControlPlaneMachineSet.
+func isDryRun(cpms *machinev1.ControlPlaneMachineSet) bool {
+	_, ok := cpms.Annotations[dryRunAnnotation]
+	return ok
+}
+
+// isReconcilingExcessIndexes determines whether indexes in excess of the replicas should be reconciled, rather than
+// reported via the Degraded condition.
+func isReconcilingExcessIndexes(cpms *machinev1.ControlPlaneMachineSet) bool {
+	_, ok := cpms.Annotations[reconcileExcessIndexesAnnotation]
+	return ok
+}
+
+// etcdFaultTolerance returns the number of etcd members that may be unavailable, for a cluster of the given size,
+// without losing quorum.
+// For example, a 3 member cluster can tolerate the loss of 1 member and a 5 member cluster the loss of 2 members.
+func etcdFaultTolerance(members int32) int {
+	if members < 1 {
+		return 0
+	}
+
+	return int((members - 1) / 2)
+}
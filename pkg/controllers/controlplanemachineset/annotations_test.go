@@ -0,0 +1,290 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
+)
+
+var _ = Describe("Annotations", func() {
+	Context("getMaxSurge", func() {
+		type maxSurgeTableInput struct {
+			replicas         int32
+			annotations      map[string]string
+			expectedMaxSurge int
+			expectedError    error
+		}
+
+		DescribeTable("should return the effective maximum surge", func(in maxSurgeTableInput) {
+			cpms := machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(in.replicas).Build()
+			cpms.Annotations = in.annotations
+
+			maxSurge, err := getMaxSurge(cpms)
+			if in.expectedError != nil {
+				Expect(err).To(MatchError(in.expectedError))
+				return
+			}
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(maxSurge).To(Equal(in.expectedMaxSurge))
+		},
+			Entry("with no annotation", maxSurgeTableInput{
+				replicas:         3,
+				expectedMaxSurge: 1,
+			}),
+			Entry("with no annotation and 5 replicas", maxSurgeTableInput{
+				replicas:         5,
+				expectedMaxSurge: 1,
+			}),
+			Entry("with a maximum surge of 1 and 3 replicas", maxSurgeTableInput{
+				replicas:         3,
+				annotations:      map[string]string{maxSurgeAnnotation: "1"},
+				expectedMaxSurge: 1,
+			}),
+			Entry("with a maximum surge of 2 and 3 replicas", maxSurgeTableInput{
+				replicas:         3,
+				annotations:      map[string]string{maxSurgeAnnotation: "2"},
+				expectedMaxSurge: 1,
+			}),
+			Entry("with a maximum surge of 2 and 5 replicas", maxSurgeTableInput{
+				replicas:         5,
+				annotations:      map[string]string{maxSurgeAnnotation: "2"},
+				expectedMaxSurge: 2,
+			}),
+			Entry("with a maximum surge of 3 and 5 replicas", maxSurgeTableInput{
+				replicas:         5,
+				annotations:      map[string]string{maxSurgeAnnotation: "3"},
+				expectedMaxSurge: 2,
+			}),
+			Entry("with a maximum surge of 0", maxSurgeTableInput{
+				replicas:      3,
+				annotations:   map[string]string{maxSurgeAnnotation: "0"},
+				expectedError: fmt.Errorf("%w: %s: %q", errInvalidMaxSurge, maxSurgeAnnotation, "0"),
+			}),
+			Entry("with a negative maximum surge", maxSurgeTableInput{
+				replicas:      3,
+				annotations:   map[string]string{maxSurgeAnnotation: "-1"},
+				expectedError: fmt.Errorf("%w: %s: %q", errInvalidMaxSurge, maxSurgeAnnotation, "-1"),
+			}),
+			Entry("with a non-integer maximum surge", maxSurgeTableInput{
+				replicas:      3,
+				annotations:   map[string]string{maxSurgeAnnotation: "25%"},
+				expectedError: fmt.Errorf("%w: %s: %q", errInvalidMaxSurge, maxSurgeAnnotation, "25%"),
+			}),
+		)
+	})
+
+	Context("getPartition", func() {
+		type partitionTableInput struct {
+			annotations       map[string]string
+			expectedPartition int32
+			expectedError     error
+		}
+
+		DescribeTable("should return the configured partition", func(in partitionTableInput) {
+			cpms := machinev1resourcebuilder.ControlPlaneMachineSet().Build()
+			cpms.Annotations = in.annotations
+
+			partition, err := getPartition(cpms)
+			if in.expectedError != nil {
+				Expect(err).To(MatchError(in.expectedError))
+				return
+			}
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(partition).To(Equal(in.expectedPartition))
+		},
+			Entry("with no annotation", partitionTableInput{
+				expectedPartition: 0,
+			}),
+			Entry("with a partition of 0", partitionTableInput{
+				annotations:       map[string]string{partitionAnnotation: "0"},
+				expectedPartition: 0,
+			}),
+			Entry("with a partition of 2", partitionTableInput{
+				annotations:       map[string]string{partitionAnnotation: "2"},
+				expectedPartition: 2,
+			}),
+			Entry("with a negative partition", partitionTableInput{
+				annotations:   map[string]string{partitionAnnotation: "-1"},
+				expectedError: fmt.Errorf("%w: %s: %q", errInvalidPartition, partitionAnnotation, "-1"),
+			}),
+			Entry("with a non-integer partition", partitionTableInput{
+				annotations:   map[string]string{partitionAnnotation: "two"},
+				expectedError: fmt.Errorf("%w: %s: %q", errInvalidPartition, partitionAnnotation, "two"),
+			}),
+		)
+	})
+
+	Context("getMinReady", func() {
+		type minReadyTableInput struct {
+			annotations      map[string]string
+			expectedMinReady time.Duration
+			expectedError    error
+		}
+
+		DescribeTable("should return the configured minimum ready duration", func(in minReadyTableInput) {
+			cpms := machinev1resourcebuilder.ControlPlaneMachineSet().Build()
+			cpms.Annotations = in.annotations
+
+			minReady, err := getMinReady(cpms)
+			if in.expectedError != nil {
+				Expect(err).To(MatchError(in.expectedError))
+				return
+			}
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(minReady).To(Equal(in.expectedMinReady))
+		},
+			Entry("with no annotation", minReadyTableInput{
+				expectedMinReady: 0,
+			}),
+			Entry("with 300 seconds", minReadyTableInput{
+				annotations:      map[string]string{minReadySecondsAnnotation: "300"},
+				expectedMinReady: 5 * time.Minute,
+			}),
+			Entry("with a negative value", minReadyTableInput{
+				annotations:   map[string]string{minReadySecondsAnnotation: "-1"},
+				expectedError: fmt.Errorf("%w: %s: %q", errInvalidMinReadySeconds, minReadySecondsAnnotation, "-1"),
+			}),
+			Entry("with a duration", minReadyTableInput{
+				annotations:   map[string]string{minReadySecondsAnnotation: "5m"},
+				expectedError: fmt.Errorf("%w: %s: %q", errInvalidMinReadySeconds, minReadySecondsAnnotation, "5m"),
+			}),
+		)
+	})
+
+	Context("getFailedReplacementRetries", func() {
+		DescribeTable("should return the configured number of retries", func(annotations map[string]string, expectedRetries int, expectedError error) {
+			cpms := machinev1resourcebuilder.ControlPlaneMachineSet().Build()
+			cpms.Annotations = annotations
+
+			retries, err := getFailedReplacementRetries(cpms)
+			if expectedError != nil {
+				Expect(err).To(MatchError(expectedError))
+				return
+			}
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(retries).To(Equal(expectedRetries))
+		},
+			Entry("with no annotation", nil, 0, nil),
+			Entry("with 3 retries", map[string]string{failedReplacementRetriesAnnotation: "3"}, 3, nil),
+			Entry("with a negative value", map[string]string{failedReplacementRetriesAnnotation: "-1"}, 0,
+				fmt.Errorf("%w: %s: %q", errInvalidFailedReplacementRetries, failedReplacementRetriesAnnotation, "-1")),
+		)
+	})
+
+	Context("getReplaceIndexes", func() {
+		DescribeTable("should return the configured indexes", func(annotations map[string]string, expectedIndexes []int32, expectedError error) {
+			cpms := machinev1resourcebuilder.ControlPlaneMachineSet().Build()
+			cpms.Annotations = annotations
+
+			indexes, err := getReplaceIndexes(cpms)
+			if expectedError != nil {
+				Expect(err).To(MatchError(expectedError))
+				return
+			}
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(indexes).To(Equal(expectedIndexes))
+		},
+			Entry("with no annotation", nil, []int32(nil), nil),
+			Entry("with a single index", map[string]string{replaceIndexesAnnotation: "1"}, []int32{1}, nil),
+			Entry("with unsorted, repeated indexes", map[string]string{replaceIndexesAnnotation: "2, 0,2"}, []int32{0, 2}, nil),
+			Entry("with a negative index", map[string]string{replaceIndexesAnnotation: "0,-1"}, []int32(nil),
+				fmt.Errorf("%w: %s: %q", errInvalidReplaceIndexes, replaceIndexesAnnotation, "0,-1")),
+			Entry("with an empty value", map[string]string{replaceIndexesAnnotation: ""}, []int32(nil),
+				fmt.Errorf("%w: %s: %q", errInvalidReplaceIndexes, replaceIndexesAnnotation, "")),
+		)
+	})
+
+	Context("getMarkedReplaceIndexes", func() {
+		DescribeTable("should return the recorded indexes", func(annotations map[string]string, expectedIndexes []int32, expectedError error) {
+			cpms := machinev1resourcebuilder.ControlPlaneMachineSet().Build()
+			cpms.Annotations = annotations
+
+			indexes, err := getMarkedReplaceIndexes(cpms)
+			if expectedError != nil {
+				Expect(err).To(MatchError(expectedError))
+				return
+			}
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(indexes).To(Equal(expectedIndexes))
+		},
+			Entry("with no annotation", nil, []int32(nil), nil),
+			Entry("with only the replace indexes annotation", map[string]string{replaceIndexesAnnotation: "1"}, []int32(nil), nil),
+			Entry("with unsorted indexes", map[string]string{markedReplaceIndexesAnnotation: "2,0"}, []int32{0, 2}, nil),
+			Entry("with an invalid index", map[string]string{markedReplaceIndexesAnnotation: "a"}, []int32(nil),
+				fmt.Errorf("%w: %s: %q", errInvalidReplaceIndexes, markedReplaceIndexesAnnotation, "a")),
+		)
+	})
+
+	Context("validateAnnotations", func() {
+		DescribeTable("should validate every annotation", func(annotations map[string]string, expectedError error) {
+			cpms := machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).Build()
+			cpms.Annotations = annotations
+
+			err := validateAnnotations(cpms)
+			if expectedError != nil {
+				Expect(err).To(MatchError(ContainSubstring(expectedError.Error())))
+				return
+			}
+
+			Expect(err).ToNot(HaveOccurred())
+		},
+			Entry("with no annotations", nil, nil),
+			Entry("with valid annotations", map[string]string{
+				maxSurgeAnnotation:                       "1",
+				progressDeadlineSecondsAnnotation:        "600",
+				remediationNodeNotReadySecondsAnnotation: "300",
+				replaceIndexesAnnotation:                 "0,2",
+			}, nil),
+			Entry("with an invalid maximum surge", map[string]string{maxSurgeAnnotation: "0"}, errInvalidMaxSurge),
+			Entry("with an invalid maintenance window", map[string]string{maintenanceWindowsAnnotation: "{"}, errors.New(maintenanceWindowsAnnotation)),
+			Entry("with an invalid progress deadline", map[string]string{progressDeadlineSecondsAnnotation: "0"}, errInvalidProgressDeadline),
+			Entry("with invalid failed replacement retries", map[string]string{failedReplacementRetriesAnnotation: "-1"}, errInvalidFailedReplacementRetries),
+			Entry("with an invalid remediation timeout", map[string]string{remediationNodeNotReadySecondsAnnotation: "0"}, errInvalidRemediationNodeNotReadySeconds),
+			Entry("with an invalid stability gate settle period", map[string]string{
+				stabilityGateClusterOperatorsAnnotation: "",
+				stabilityGateSettleSecondsAnnotation:    "-1",
+			}, errInvalidStabilityGateSettleSeconds),
+			Entry("with invalid replace indexes", map[string]string{replaceIndexesAnnotation: "a"}, errInvalidReplaceIndexes),
+			Entry("with invalid marked replace indexes", map[string]string{markedReplaceIndexesAnnotation: "a"}, errInvalidReplaceIndexes),
+		)
+	})
+
+	Context("etcdFaultTolerance", func() {
+		DescribeTable("should return the number of members that may be lost without losing quorum", func(members int32, expected int) {
+			Expect(etcdFaultTolerance(members)).To(Equal(expected))
+		},
+			Entry("with 0 members", int32(0), 0),
+			Entry("with 1 member", int32(1), 0),
+			Entry("with 3 members", int32(3), 1),
+			Entry("with 4 members", int32(4), 1),
+			Entry("with 5 members", int32(5), 2),
+		)
+	})
+})
@@ -90,6 +90,18 @@ const (
 	// can continue.
 	reasonInvalidStrategy = "InvalidStrategy"
 
+	// reasonInvalidAnnotation denotes that the ControlPlaneMachineSet has identified an
+	// invalid value for one of the annotations used to configure the update strategy.
+	// This must be resolved by the user before operation of the ControlPlaneMachineSet
+	// can continue.
+	reasonInvalidAnnotation = "InvalidAnnotation"
+
+	// reasonProgressDeadlineExceeded denotes that the ControlPlaneMachineSet has identified
+	// a replacement Machine that has not become Ready within the configured progress deadline,
+	// and that no further attempts will be made to replace it automatically.
+	// This will require manual intervention to resolve.
+	reasonProgressDeadlineExceeded = "ProgressDeadlineExceeded"
+
 	// reasonMachinesAlreadyOwned denotes that the ControlPlaneMachineSet has identified
 	// some Control Plane Machines that are already owned by a different controller.
 	// In this scenario, the operator must cease operations to prevent possible conflicts
@@ -150,5 +162,25 @@ const (
 	// replicas under its management that are currently in need of an update.
 	reasonNeedsUpdateReplicas = "NeedsUpdateReplicas"
 
+	// reasonPaused denotes that the ControlPlaneMachineSet has identified replicas
+	// under its management that are in need of an update, but is not taking any action
+	// towards a rollout because updates have been paused by the user.
+	reasonPaused = "Paused"
+
+	// reasonPartitioned denotes that the ControlPlaneMachineSet has identified replicas
+	// under its management that are in need of an update, but is not taking any action
+	// towards a rollout because all of the outdated replicas are held back by the partition.
+	reasonPartitioned = "Partitioned"
+
+	// reasonMachineAgeExceeded denotes that the ControlPlaneMachineSet has identified replicas
+	// under its management that are in need of an update only because they have exceeded the
+	// maximum machine age.
+	reasonMachineAgeExceeded = "MachineAgeExceeded"
+
+	// reasonReconcilingExcessIndexes denotes that the ControlPlaneMachineSet has more indexes
+	// than the desired number of replicas, and that it is migrating the excess indexes into
+	// the missing indexes as reconciling them has been enabled.
+	reasonReconcilingExcessIndexes = "ReconcilingExcessIndexes"
+
 	// END: Progressing reasons.
 )
@@ -85,6 +85,10 @@ type ControlPlaneMachineSetReconciler struct {
 	RESTMapper     meta.RESTMapper
 	UncachedClient client.Client
 
+	// EtcdClient is used to read the state of the etcd members of the control plane from the etcd namespace,
+	// which is outside of the Namespace.
+	EtcdClient client.Reader
+
 	// Namespace is the namespace in which the ControlPlaneMachineSet controller should operate.
 	// Any ControlPlaneMachineSet not in this namespace should be ignored.
 	Namespace string
@@ -98,6 +102,15 @@ type ControlPlaneMachineSetReconciler struct {
 
 	// lastError allows us to track the last error that occurred during reconciliation.
 	lastError *lastErrorTracker
+
+	// replacementAttempts allows us to track, per index, the failed replacement Machines that have been
+	// deleted so that new replacements can be created with a backoff.
+	// These are loaded from, and persisted on, the ControlPlaneMachineSet on each reconcile.
+	replacementAttempts map[int32]replacementAttempts
+
+	// dryRun is set when computing the dry-run plan. Machines must not be modified directly through the client
+	// while it is set, only through the machine provider, which records the actions in the plan.
+	dryRun bool
 }
 
 // lastErrorTracker tracks the last error that occurred during reconciliation.
@@ -229,7 +242,16 @@ func (r *ControlPlaneMachineSetReconciler) reconcile(ctx context.Context, logger
 		return ctrl.Result{Requeue: true}, nil
 	}
 
-	machineProvider, err := providers.NewMachineProvider(ctx, logger, r.Client, cpms)
+	// Validate the annotations before any other step, as the machine provider cannot be constructed, and no Machine
+	// may be changed, while any of them is invalid.
+	if err := validateAnnotations(cpms); err != nil {
+		setInvalidAnnotationDegraded(logger, cpms, err)
+
+		// Do not return an error here as the annotation will need user intervention to resolve.
+		return ctrl.Result{}, nil
+	}
+
+	machineProvider, err := providers.NewMachineProvider(ctx, logger, r.Client, r.EtcdClient, cpms)
 	if err != nil {
 		return ctrl.Result{}, fmt.Errorf("error constructing machine provider: %w", err)
 	}
@@ -255,11 +277,15 @@ func (r *ControlPlaneMachineSetReconciler) reconcile(ctx context.Context, logger
 // reconcileMachines uses the gathered machine info to set the status of the ControlPlaneMachineSet and then,
 // after validating that the cluster state is as expected, uses the machine provider to take appropriate actions
 // to perform any requied roll outs.
+// When the dry-run plan mode is enabled, the actions are published as a plan rather than performed.
 func (r *ControlPlaneMachineSetReconciler) reconcileMachines(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, machineProvider machineproviders.MachineProvider, machineInfos map[int32][]machineproviders.MachineInfo) (ctrl.Result, error) {
 	if err := reconcileStatusWithMachineInfo(logger, cpms, machineInfos); err != nil {
 		return ctrl.Result{}, fmt.Errorf("error reconciling machine info with status: %w", err)
 	}
 
+	// The failed replacement attempts determine whether an errored replacement Machine degrades the cluster state.
+	r.loadReplacementAttempts(cpms)
+
 	if err := r.validateClusterState(ctx, logger, cpms, machineInfos); err != nil {
 		return ctrl.Result{}, fmt.Errorf("error validating cluster state: %w", err)
 	}
@@ -269,6 +295,15 @@ func (r *ControlPlaneMachineSetReconciler) reconcileMachines(ctx context.Context
 		return ctrl.Result{}, nil
 	}
 
+	if isDryRun(cpms) {
+		// In dry-run mode, the machines are never modified, so a plan can be computed even when inactive.
+		return r.reconcileDryRun(ctx, logger, cpms, machineProvider, machineInfos)
+	}
+
+	if err := r.removePlan(ctx, logger, cpms); err != nil {
+		return ctrl.Result{}, fmt.Errorf("error removing dry-run plan: %w", err)
+	}
+
 	if !isActive(cpms) {
 		// When inactive, we don't want to modify the machines at all so stop processing here.
 		return ctrl.Result{}, nil
@@ -278,12 +313,38 @@ func (r *ControlPlaneMachineSetReconciler) reconcileMachines(ctx context.Context
 		return ctrl.Result{}, fmt.Errorf("error ensuring owner references: %w", err)
 	}
 
+	if err := r.reconcileMigrationMarks(ctx, logger, cpms, machineInfos); err != nil {
+		return ctrl.Result{}, fmt.Errorf("error marking machines for migration: %w", err)
+	}
+
+	if done, result, err := r.reconcileReplaceIndexes(ctx, logger, cpms, machineInfos); err != nil {
+		return ctrl.Result{}, fmt.Errorf("error marking machines for replacement: %w", err)
+	} else if done {
+		return result, nil
+	}
+
+	if done, result, err := r.reconcileRollback(ctx, logger, cpms); err != nil {
+		return ctrl.Result{}, fmt.Errorf("error rolling back control plane machine set: %w", err)
+	} else if done {
+		return result, nil
+	}
+
+	if err := r.reconcileRevisionHistory(ctx, logger, cpms); err != nil {
+		return ctrl.Result{}, fmt.Errorf("error reconciling revision history: %w", err)
+	}
+
 	result, err := r.reconcileMachineUpdates(ctx, logger, cpms, machineProvider, machineInfos)
+
+	// Persist the failed replacement attempts even when the updates failed, as Machines may already have been deleted.
+	if persistErr := r.persistReplacementAttempts(ctx, cpms); persistErr != nil {
+		return ctrl.Result{}, fmt.Errorf("error persisting replacement attempts: %w", persistErr)
+	}
+
 	if err != nil {
 		return ctrl.Result{}, fmt.Errorf("error reconciling machine updates: %w", err)
 	}
 
-	return result, nil
+	return withRemediationRequeue(cpms, machineInfos, withMachineAgeRequeue(cpms, machineInfos, result)), nil
 }
 
 // reconcileDelete handles the removal logic for the ControlPlaneMachineSet resource.
@@ -506,7 +567,9 @@ func (r *ControlPlaneMachineSetReconciler) ensureOwnerReferences(ctx context.Con
 //   - We have the correct number of indexes:
 //     -- Right number of indexes, valid.
 //     -- Too few indexes, valid. We will later scale up without user intervention when we perform reconcileMachineUpdates.
-//     -- Too many indexes, invalid. We set the operator to degraded and ask the user for manual intervention.
+//     -- Too many indexes, when reconciling excess indexes has been enabled and an index is missing, valid. We will
+//     later migrate the excess index into the missing index when we perform reconcileMachineUpdates.
+//     -- Too many indexes otherwise, invalid. We set the operator to degraded and ask the user for manual intervention.
 //   - No replacement machines (one that doesn't need update but has an equivalent in the index that needs update) have an error.
 func (r *ControlPlaneMachineSetReconciler) validateClusterState(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, machineInfos map[int32][]machineproviders.MachineInfo) error {
 	sortedIndexedMs := sortMachineInfosByIndex(machineInfos)
@@ -718,6 +781,9 @@ func (r *ControlPlaneMachineSetReconciler) checkCorrectNumberOfIndexes(logger lo
 	case currentIndexesCount < *cpms.Spec.Replicas:
 		// Too few indexes. The cluster state is valid.
 		// We will later scale up without user intervention when we perform reconcileMachineUpdates.
+	case isMigratingExcessIndex(cpms, sortedIndexedMs):
+		// Too many indexes, which the user has asked us to reconcile, and which can be migrated into a missing index.
+		// The cluster state is valid. We will later migrate the excess index when we perform reconcileMachineUpdates.
 	case currentIndexesCount > *cpms.Spec.Replicas:
 		// Too many indexes. The cluster state is invalid.
 		// We set the operator to degraded and ask the user for manual intervention.
@@ -801,20 +867,18 @@ func (r *ControlPlaneMachineSetReconciler) checkValidNumerOfUpdatedMachinesPerIn
 }
 
 // checkNoErrorForReplacements checks that there is no errored replacement machine.
+// Errored replacement machines in indexes which will be retried are ignored.
 func (r *ControlPlaneMachineSetReconciler) checkNoErrorForReplacements(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, sortedIndexedMs []indexToMachineInfos) bool {
 	var erroredReplacementMachineNames []string
 
 	for _, indexToMachines := range sortedIndexedMs {
-		machines := indexToMachines.machineInfos
-		machinesPending := pendingMachines(machines)
-		machinesOutdated := needReplacementMachines(machines)
-
-		if hasAny(machinesOutdated) && hasAny(machinesPending) {
-			for _, m := range machinesPending {
-				if m.ErrorMessage != "" {
-					erroredReplacementMachineNames = append(erroredReplacementMachineNames, m.MachineRef.ObjectMeta.Name)
-				}
-			}
+		if r.canRetryFailedReplacement(cpms, indexToMachines.index) {
+			// The failed replacement will be removed and retried by the update strategy.
+			continue
+		}
+
+		for _, m := range erroredReplacementMachines(indexToMachines.machineInfos) {
+			erroredReplacementMachineNames = append(erroredReplacementMachineNames, m.MachineRef.ObjectMeta.Name)
 		}
 	}
 
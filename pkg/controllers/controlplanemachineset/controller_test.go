@@ -2387,6 +2387,41 @@ var _ = Describe("validateClusterState", func() {
 				},
 			},
 		}),
+		Entry("with a failed replacement machine, and failed replacement retries remaining", validateClusterTableInput{
+			cpmsBuilder: &machinev1resourcebuilder.ControlPlaneMachineSetFuncs{
+				BuildFunc: func() *machinev1.ControlPlaneMachineSet {
+					cpms := cpmsBuilder.WithConditions([]metav1.Condition{
+						degradedConditionBuilder.WithStatus(metav1.ConditionFalse).Build(),
+						progressingConditionBuilder.WithStatus(metav1.ConditionFalse).Build(),
+					}).Build()
+					cpms.Annotations = map[string]string{failedReplacementRetriesAnnotation: "1"}
+
+					return cpms
+				},
+			},
+			machineInfos: map[int32][]machineproviders.MachineInfo{
+				0: {
+					updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("master-0").WithNeedsUpdate(true).Build(),
+					updatedMachineBuilder.WithIndex(0).WithMachineName("machine-replacement-0").WithErrorMessage("Could not create new instance").WithReady(false).Build(),
+				},
+				1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("master-1").WithNeedsUpdate(true).Build()},
+				2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("master-2").WithNeedsUpdate(true).Build()},
+			},
+			nodes: []*corev1.Node{
+				masterNodeBuilder.WithName("master-0").Build(),
+				masterNodeBuilder.WithName("master-1").Build(),
+				masterNodeBuilder.WithName("master-2").Build(),
+				workerNodeBuilder.WithName("worker-0").Build(),
+				workerNodeBuilder.WithName("worker-1").Build(),
+				workerNodeBuilder.WithName("worker-2").Build(),
+			},
+			expectedError: nil,
+			expectedConditions: []metav1.Condition{
+				degradedConditionBuilder.WithStatus(metav1.ConditionFalse).Build(),
+				progressingConditionBuilder.WithStatus(metav1.ConditionFalse).Build(),
+			},
+			expectedLogs: []testutils.LogEntry{},
+		}),
 		Entry("with multiple updated machines in a single index and RollingUpdate strategy", validateClusterTableInput{
 			cpmsBuilder: cpmsBuilder.WithConditions([]metav1.Condition{
 				degradedConditionBuilder.WithStatus(metav1.ConditionFalse).Build(),
@@ -2537,6 +2572,84 @@ var _ = Describe("validateClusterState", func() {
 				},
 			},
 		}),
+		Entry("with an excess in number of control plane indexes, and reconciling excess indexes enabled, and the excess index marked for migration", validateClusterTableInput{
+			cpmsBuilder: &machinev1resourcebuilder.ControlPlaneMachineSetFuncs{
+				BuildFunc: func() *machinev1.ControlPlaneMachineSet {
+					cpms := cpmsBuilder.WithConditions([]metav1.Condition{
+						degradedConditionBuilder.WithStatus(metav1.ConditionFalse).Build(),
+						progressingConditionBuilder.WithStatus(metav1.ConditionFalse).Build(),
+					}).WithReplicas(3).Build()
+					cpms.Annotations = map[string]string{reconcileExcessIndexesAnnotation: ""}
+
+					return cpms
+				},
+			},
+			machineInfos: map[int32][]machineproviders.MachineInfo{
+				0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("master-0").Build()},
+				1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("master-1").Build()},
+				2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("master-2").Build()},
+				3: {updatedMachineBuilder.WithIndex(3).WithMachineName("machine-3").WithNodeName("master-3").WithMachineAnnotations(map[string]string{
+					migrateToIndexAnnotation: "2",
+				}).Build()},
+			},
+			nodes: []*corev1.Node{
+				masterNodeBuilder.WithName("master-0").Build(),
+				masterNodeBuilder.WithName("master-1").Build(),
+				masterNodeBuilder.WithName("master-2").Build(),
+				masterNodeBuilder.WithName("master-3").Build(),
+				workerNodeBuilder.WithName("worker-0").Build(),
+				workerNodeBuilder.WithName("worker-1").Build(),
+				workerNodeBuilder.WithName("worker-2").Build(),
+			},
+			expectedError: nil,
+			expectedConditions: []metav1.Condition{
+				degradedConditionBuilder.WithStatus(metav1.ConditionFalse).Build(),
+				progressingConditionBuilder.WithStatus(metav1.ConditionFalse).Build(),
+			},
+			expectedLogs: []testutils.LogEntry{},
+		}),
+		Entry("with an excess in number of control plane indexes, and reconciling excess indexes enabled, and no missing index", validateClusterTableInput{
+			cpmsBuilder: &machinev1resourcebuilder.ControlPlaneMachineSetFuncs{
+				BuildFunc: func() *machinev1.ControlPlaneMachineSet {
+					cpms := cpmsBuilder.WithConditions([]metav1.Condition{
+						degradedConditionBuilder.WithStatus(metav1.ConditionFalse).Build(),
+						progressingConditionBuilder.WithStatus(metav1.ConditionFalse).Build(),
+					}).WithReplicas(3).Build()
+					cpms.Annotations = map[string]string{reconcileExcessIndexesAnnotation: ""}
+
+					return cpms
+				},
+			},
+			machineInfos: map[int32][]machineproviders.MachineInfo{
+				0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("master-0").Build()},
+				1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("master-1").Build()},
+				2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("master-2").Build()},
+				3: {updatedMachineBuilder.WithIndex(3).WithMachineName("machine-3").WithNodeName("master-3").Build()},
+			},
+			nodes: []*corev1.Node{
+				masterNodeBuilder.WithName("master-0").Build(),
+				masterNodeBuilder.WithName("master-1").Build(),
+				masterNodeBuilder.WithName("master-2").Build(),
+				masterNodeBuilder.WithName("master-3").Build(),
+				workerNodeBuilder.WithName("worker-0").Build(),
+				workerNodeBuilder.WithName("worker-1").Build(),
+				workerNodeBuilder.WithName("worker-2").Build(),
+			},
+			expectedError: nil,
+			expectedConditions: []metav1.Condition{
+				degradedConditionBuilder.WithStatus(metav1.ConditionTrue).WithReason(reasonExcessIndexes).WithMessage("Observed 1 index(es) in excess").Build(),
+				progressingConditionBuilder.WithStatus(metav1.ConditionFalse).WithReason(reasonOperatorDegraded).Build(),
+			},
+			expectedLogs: []testutils.LogEntry{
+				{
+					Error: fmt.Errorf("%w: %s", errFoundExcessiveIndexes, "1 index(es) are in excess"),
+					KeysAndValues: []interface{}{
+						"excessIndexes", int32(1),
+					},
+					Message: "Observed an excessive number of control plane machine indexes",
+				},
+			},
+		}),
 	)
 })
 
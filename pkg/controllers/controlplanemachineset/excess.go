@@ -0,0 +1,242 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	// markedForMigration is a log message used to inform the user that a Machine of an excess index has been marked
+	// for migration into a missing index.
+	markedForMigration = "Marked excess machine for migration into a missing index"
+
+	// migratingExcessIndex is a log message used to inform the user that a Machine is being created within a missing
+	// index, so that the excess index can be removed once the new Machine is Ready.
+	migratingExcessIndex = "Migrating excess index into a missing index"
+
+	// waitingForMigratedIndex is a log message used to inform the user that the excess index is not yet removed, as
+	// the Machine of the index it is migrated into is not yet Ready.
+	waitingForMigratedIndex = "Waiting for the machine of the migrated index to become ready"
+
+	// removingMigratedExcessIndex is a log message used to inform the user that the excess index is being removed, as
+	// it has been migrated into another index.
+	removingMigratedExcessIndex = "Removing excess index, as it has been migrated into another index"
+)
+
+// excessIndexes returns the indexes which are not expected given the replicas, that is, the indexes at or beyond the
+// replicas.
+func excessIndexes(replicas int32, sortedIndexedMs []indexToMachineInfos) []indexToMachineInfos {
+	out := []indexToMachineInfos{}
+
+	for _, indexedMs := range sortedIndexedMs {
+		if indexedMs.index >= replicas {
+			out = append(out, indexedMs)
+		}
+	}
+
+	return out
+}
+
+// missingIndex returns the lowest index, below the replicas, which does not have any Machine.
+func missingIndex(replicas int32, sortedIndexedMs []indexToMachineInfos) (int32, bool) {
+	present := map[int32]bool{}
+
+	for _, indexedMs := range sortedIndexedMs {
+		if hasAny(indexedMs.machineInfos) {
+			present[indexedMs.index] = true
+		}
+	}
+
+	for idx := int32(0); idx < replicas; idx++ {
+		if !present[idx] {
+			return idx, true
+		}
+	}
+
+	return 0, false
+}
+
+// getMigrationIndex returns the index into which the Machines of an excess index are migrated, as recorded on these
+// Machines when the migration started.
+func getMigrationIndex(excess indexToMachineInfos) (int32, bool) {
+	for _, machineInfo := range excess.machineInfos {
+		if machineInfo.MachineRef == nil {
+			continue
+		}
+
+		value, ok := machineInfo.MachineRef.ObjectMeta.Annotations[migrateToIndexAnnotation]
+		if !ok {
+			continue
+		}
+
+		idx, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			continue
+		}
+
+		return int32(idx), true
+	}
+
+	return 0, false
+}
+
+// excessIndexMigration returns the highest excess index, and the index into which it is migrated.
+// This is the index recorded on the Machines of the excess index, or otherwise the lowest missing index.
+// When no index is missing, and no migration has started, the excess index cannot be migrated.
+func excessIndexMigration(replicas int32, sortedIndexedMs []indexToMachineInfos) (indexToMachineInfos, int32, bool) {
+	excess := excessIndexes(replicas, sortedIndexedMs)
+	if len(excess) == 0 {
+		return indexToMachineInfos{}, 0, false
+	}
+
+	highest := excess[len(excess)-1]
+
+	if idx, ok := getMigrationIndex(highest); ok {
+		return highest, idx, true
+	}
+
+	if idx, ok := missingIndex(replicas, sortedIndexedMs); ok {
+		return highest, idx, true
+	}
+
+	return indexToMachineInfos{}, 0, false
+}
+
+// isMigratingExcessIndex determines whether reconciling excess indexes has been enabled, and the highest excess index
+// can be migrated into another index.
+func isMigratingExcessIndex(cpms *machinev1.ControlPlaneMachineSet, sortedIndexedMs []indexToMachineInfos) bool {
+	_, _, ok := excessIndexMigration(pointer.Int32Deref(cpms.Spec.Replicas, 0), sortedIndexedMs)
+
+	return isReconcilingExcessIndexes(cpms) && ok
+}
+
+// reconcileMigrationMarks records, on the Machines of the highest excess index, the index into which they are
+// migrated, so that the excess index is still removed once the Machine of the missing index has been created.
+func (r *ControlPlaneMachineSetReconciler) reconcileMigrationMarks(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, machineInfos map[int32][]machineproviders.MachineInfo) error {
+	sortedIndexedMs := sortMachineInfosByIndex(machineInfos)
+
+	if !isMigratingExcessIndex(cpms, sortedIndexedMs) {
+		return nil
+	}
+
+	excess, idx, _ := excessIndexMigration(pointer.Int32Deref(cpms.Spec.Replicas, 0), sortedIndexedMs)
+
+	for _, machineInfo := range excess.machineInfos {
+		if machineInfo.MachineRef == nil {
+			continue
+		}
+
+		if _, ok := machineInfo.MachineRef.ObjectMeta.Annotations[migrateToIndexAnnotation]; ok {
+			continue
+		}
+
+		if err := r.annotateMachine(ctx, machineInfo, migrateToIndexAnnotation, strconv.Itoa(int(idx))); err != nil {
+			return err
+		}
+
+		logger.V(2).WithValues("index", machineInfo.Index, "namespace", r.Namespace, "name", machineInfo.MachineRef.ObjectMeta.Name, "migrationIndex", idx).Info(markedForMigration)
+	}
+
+	return nil
+}
+
+// reconcileExcessIndexes migrates the highest excess index into a missing index when reconciling excess indexes has
+// been enabled on the ControlPlaneMachineSet, rather than requiring manual intervention.
+// A Machine is created within the missing index, and, once it is Ready, the Machines of the excess index are removed.
+// Only a single index is migrated at a time. While an excess index is migrated, the update strategy is held back.
+func (r *ControlPlaneMachineSetReconciler) reconcileExcessIndexes(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, machineProvider machineproviders.MachineProvider, machineInfos map[int32][]machineproviders.MachineInfo) (bool, ctrl.Result, error) {
+	sortedIndexedMs := sortMachineInfosByIndex(machineInfos)
+
+	if !isMigratingExcessIndex(cpms, sortedIndexedMs) {
+		return false, ctrl.Result{}, nil
+	}
+
+	excess, idx, _ := excessIndexMigration(pointer.Int32Deref(cpms.Spec.Replicas, 0), sortedIndexedMs)
+
+	switch {
+	case isEmpty(machineInfos[idx]):
+		logger := logger.WithValues("index", idx, "excessIndex", excess.index)
+
+		now := time.Now()
+		if nextWindow, heldBack := heldBackByMaintenanceWindows(cpms, now); heldBack {
+			// A migration is only started while a maintenance window is open, a started migration is completed.
+			logger.V(2).WithValues("nextMaintenanceWindow", nextWindow).Info(changeHeldBackByMaintenanceWindow)
+
+			return true, ctrl.Result{RequeueAfter: nextWindow.Sub(now)}, nil
+		}
+
+		logger.V(2).Info(migratingExcessIndex)
+
+		_, result, err := r.createMachine(ctx, logger, machineProvider, idx)
+
+		return true, result, err
+	case isEmpty(readyMachines(machineInfos[idx])):
+		// Node readiness does not generate Machine events, so a manual requeue is needed.
+		logger.V(2).WithValues("index", idx, "excessIndex", excess.index).Info(waitingForMigratedIndex)
+
+		return true, ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+	}
+
+	logger = logger.WithValues("index", excess.index, "migrationIndex", idx)
+	logger.V(2).Info(removingMigratedExcessIndex)
+
+	for _, machineInfo := range excess.machineInfos {
+		if isDeletedMachine(machineInfo) {
+			continue
+		}
+
+		machineLogger := logger.WithValues("namespace", r.Namespace, "name", machineInfo.MachineRef.ObjectMeta.Name)
+
+		if result, err := deleteMachine(ctx, machineLogger, machineProvider, machineInfo, r.Namespace); err != nil {
+			return true, result, err
+		}
+	}
+
+	return true, ctrl.Result{}, nil
+}
+
+// getExcessIndexesCondition computes the Progressing condition when an index in excess of the replicas is being
+// migrated into a missing index.
+func getExcessIndexesCondition(cpms *machinev1.ControlPlaneMachineSet, machineInfosByIndex map[int32][]machineproviders.MachineInfo, progressingCondition metav1.Condition) metav1.Condition {
+	sortedIndexedMs := sortMachineInfosByIndex(machineInfosByIndex)
+
+	if !isMigratingExcessIndex(cpms, sortedIndexedMs) {
+		return progressingCondition
+	}
+
+	replicas := pointer.Int32Deref(cpms.Spec.Replicas, 0)
+	excess, idx, _ := excessIndexMigration(replicas, sortedIndexedMs)
+
+	return metav1.Condition{
+		Type:               conditionProgressing,
+		Status:             metav1.ConditionTrue,
+		Reason:             reasonReconcilingExcessIndexes,
+		Message:            fmt.Sprintf("Observed %d index(es) in excess, migrating excess index %d into index %d", len(excessIndexes(replicas, sortedIndexedMs)), excess.index, idx),
+		ObservedGeneration: cpms.Generation,
+	}
+}
@@ -0,0 +1,264 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	machinev1 "github.com/openshift/api/machine/v1"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/cluster-api-actuator-pkg/testutils"
+	corev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/core/v1"
+	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
+	machinev1beta1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1beta1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	machineprovidersresourcebuilder "github.com/openshift/cluster-control-plane-machine-set-operator/pkg/test/resourcebuilder/machineproviders"
+
+	"sigs.k8s.io/controller-runtime/pkg/envtest/komega"
+)
+
+var _ = Describe("Excess indexes", func() {
+	machineInfoBuilder := machineprovidersresourcebuilder.MachineInfo().WithReady(true)
+
+	Context("excessIndexes", func() {
+		type excessIndexesTableInput struct {
+			replicas        int32
+			machineInfos    map[int32][]machineproviders.MachineInfo
+			expectedIndexes []int32
+		}
+
+		DescribeTable("should return the indexes at or beyond the replicas", func(in excessIndexesTableInput) {
+			indexes := []int32{}
+			for _, indexedMs := range excessIndexes(in.replicas, sortMachineInfosByIndex(in.machineInfos)) {
+				indexes = append(indexes, indexedMs.index)
+			}
+
+			Expect(indexes).To(Equal(in.expectedIndexes))
+		},
+			Entry("with the expected indexes", excessIndexesTableInput{
+				replicas: 3,
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {machineInfoBuilder.WithIndex(0).WithMachineName("machine-0").Build()},
+					1: {machineInfoBuilder.WithIndex(1).WithMachineName("machine-1").Build()},
+					2: {machineInfoBuilder.WithIndex(2).WithMachineName("machine-2").Build()},
+				},
+				expectedIndexes: []int32{},
+			}),
+			Entry("with an extra index", excessIndexesTableInput{
+				replicas: 3,
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {machineInfoBuilder.WithIndex(0).WithMachineName("machine-0").Build()},
+					1: {machineInfoBuilder.WithIndex(1).WithMachineName("machine-1").Build()},
+					2: {machineInfoBuilder.WithIndex(2).WithMachineName("machine-2").Build()},
+					3: {machineInfoBuilder.WithIndex(3).WithMachineName("machine-3").Build()},
+				},
+				expectedIndexes: []int32{3},
+			}),
+			Entry("with an unexpected index in place of an expected index", excessIndexesTableInput{
+				replicas: 3,
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {machineInfoBuilder.WithIndex(0).WithMachineName("machine-0").Build()},
+					1: {machineInfoBuilder.WithIndex(1).WithMachineName("machine-1").Build()},
+					4: {machineInfoBuilder.WithIndex(4).WithMachineName("machine-4").Build()},
+				},
+				expectedIndexes: []int32{4},
+			}),
+			Entry("with 5 indexes and 3 replicas", excessIndexesTableInput{
+				replicas: 3,
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {machineInfoBuilder.WithIndex(0).WithMachineName("machine-0").Build()},
+					1: {machineInfoBuilder.WithIndex(1).WithMachineName("machine-1").Build()},
+					2: {machineInfoBuilder.WithIndex(2).WithMachineName("machine-2").Build()},
+					3: {machineInfoBuilder.WithIndex(3).WithMachineName("machine-3").Build()},
+					4: {machineInfoBuilder.WithIndex(4).WithMachineName("machine-4").Build()},
+				},
+				expectedIndexes: []int32{3, 4},
+			}),
+		)
+	})
+
+	Context("missingIndex", func() {
+		DescribeTable("should return the lowest index without a Machine", func(machineInfos map[int32][]machineproviders.MachineInfo, expectedIndex int32, expectedFound bool) {
+			idx, found := missingIndex(3, sortMachineInfosByIndex(machineInfos))
+			Expect(found).To(Equal(expectedFound))
+			Expect(idx).To(Equal(expectedIndex))
+		},
+			Entry("with no missing index", map[int32][]machineproviders.MachineInfo{
+				0: {machineInfoBuilder.WithIndex(0).WithMachineName("machine-0").Build()},
+				1: {machineInfoBuilder.WithIndex(1).WithMachineName("machine-1").Build()},
+				2: {machineInfoBuilder.WithIndex(2).WithMachineName("machine-2").Build()},
+				3: {machineInfoBuilder.WithIndex(3).WithMachineName("machine-3").Build()},
+			}, int32(0), false),
+			Entry("with an absent index", map[int32][]machineproviders.MachineInfo{
+				0: {machineInfoBuilder.WithIndex(0).WithMachineName("machine-0").Build()},
+				2: {machineInfoBuilder.WithIndex(2).WithMachineName("machine-2").Build()},
+				3: {machineInfoBuilder.WithIndex(3).WithMachineName("machine-3").Build()},
+			}, int32(1), true),
+			Entry("with an empty index", map[int32][]machineproviders.MachineInfo{
+				0: {machineInfoBuilder.WithIndex(0).WithMachineName("machine-0").Build()},
+				1: {machineInfoBuilder.WithIndex(1).WithMachineName("machine-1").Build()},
+				2: {},
+				3: {machineInfoBuilder.WithIndex(3).WithMachineName("machine-3").Build()},
+			}, int32(2), true),
+		)
+	})
+
+	Context("excessIndexMigration", func() {
+		markedAnnotations := map[string]string{migrateToIndexAnnotation: "1"}
+
+		type excessIndexMigrationTableInput struct {
+			machineInfos           map[int32][]machineproviders.MachineInfo
+			expectedExcessIndex    int32
+			expectedMigrationIndex int32
+			expectedFound          bool
+		}
+
+		DescribeTable("should return the excess index and the index it is migrated into", func(in excessIndexMigrationTableInput) {
+			excess, idx, ok := excessIndexMigration(3, sortMachineInfosByIndex(in.machineInfos))
+			Expect(ok).To(Equal(in.expectedFound))
+			Expect(excess.index).To(Equal(in.expectedExcessIndex))
+			Expect(idx).To(Equal(in.expectedMigrationIndex))
+		},
+			Entry("with no excess index", excessIndexMigrationTableInput{
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {machineInfoBuilder.WithIndex(0).WithMachineName("machine-0").Build()},
+					1: {machineInfoBuilder.WithIndex(1).WithMachineName("machine-1").Build()},
+					2: {machineInfoBuilder.WithIndex(2).WithMachineName("machine-2").Build()},
+				},
+			}),
+			Entry("with an excess index, and no missing index", excessIndexMigrationTableInput{
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {machineInfoBuilder.WithIndex(0).WithMachineName("machine-0").Build()},
+					1: {machineInfoBuilder.WithIndex(1).WithMachineName("machine-1").Build()},
+					2: {machineInfoBuilder.WithIndex(2).WithMachineName("machine-2").Build()},
+					3: {machineInfoBuilder.WithIndex(3).WithMachineName("machine-3").Build()},
+				},
+			}),
+			Entry("with an excess index, and a missing index", excessIndexMigrationTableInput{
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {machineInfoBuilder.WithIndex(0).WithMachineName("machine-0").Build()},
+					2: {machineInfoBuilder.WithIndex(2).WithMachineName("machine-2").Build()},
+					3: {machineInfoBuilder.WithIndex(3).WithMachineName("machine-3").Build()},
+				},
+				expectedExcessIndex:    3,
+				expectedMigrationIndex: 1,
+				expectedFound:          true,
+			}),
+			Entry("with an excess index marked for migration, once the migrated index has a Machine", excessIndexMigrationTableInput{
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {machineInfoBuilder.WithIndex(0).WithMachineName("machine-0").Build()},
+					1: {machineInfoBuilder.WithIndex(1).WithMachineName("machine-1").Build()},
+					2: {machineInfoBuilder.WithIndex(2).WithMachineName("machine-2").Build()},
+					3: {machineInfoBuilder.WithIndex(3).WithMachineName("machine-3").WithMachineAnnotations(markedAnnotations).Build()},
+				},
+				expectedExcessIndex:    3,
+				expectedMigrationIndex: 1,
+				expectedFound:          true,
+			}),
+			Entry("with two excess indexes, and a missing index, migrates the highest excess index", excessIndexMigrationTableInput{
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {machineInfoBuilder.WithIndex(0).WithMachineName("machine-0").Build()},
+					1: {machineInfoBuilder.WithIndex(1).WithMachineName("machine-1").Build()},
+					3: {machineInfoBuilder.WithIndex(3).WithMachineName("machine-3").Build()},
+					4: {machineInfoBuilder.WithIndex(4).WithMachineName("machine-4").Build()},
+				},
+				expectedExcessIndex:    4,
+				expectedMigrationIndex: 2,
+				expectedFound:          true,
+			}),
+		)
+	})
+
+	Context("reconcileMigrationMarks", func() {
+		var namespaceName string
+		var logger testutils.TestLogger
+		var reconciler *ControlPlaneMachineSetReconciler
+		var cpms *machinev1.ControlPlaneMachineSet
+
+		var machines map[int32]*machinev1beta1.Machine
+		var machineInfos map[int32][]machineproviders.MachineInfo
+
+		machineGVR := machinev1beta1.GroupVersion.WithResource("machines")
+
+		BeforeEach(func() {
+			By("Setting up a namespace for the test")
+			ns := corev1resourcebuilder.Namespace().WithGenerateName("control-plane-machine-set-excess-").Build()
+			Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+			namespaceName = ns.GetName()
+
+			logger = testutils.NewTestLogger()
+			reconciler = &ControlPlaneMachineSetReconciler{
+				Client:     k8sClient,
+				Scheme:     testScheme,
+				RESTMapper: testRESTMapper,
+				Namespace:  namespaceName,
+			}
+
+			cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithNamespace(namespaceName).WithReplicas(3).Build()
+
+			By("Creating machines with an excess index in place of index 2")
+			machines = map[int32]*machinev1beta1.Machine{}
+			machineInfos = map[int32][]machineproviders.MachineInfo{}
+			machineBuilder := machinev1beta1resourcebuilder.Machine().WithNamespace(namespaceName)
+
+			for _, i := range []int32{0, 1, 3} {
+				machine := machineBuilder.WithName(fmt.Sprintf("machine-%d", i)).Build()
+				Expect(k8sClient.Create(ctx, machine)).To(Succeed())
+
+				machines[i] = machine
+				machineInfos[i] = []machineproviders.MachineInfo{
+					machineInfoBuilder.WithIndex(i).WithMachineGVR(machineGVR).
+						WithMachineName(machine.GetName()).WithMachineNamespace(namespaceName).Build(),
+				}
+			}
+		})
+
+		AfterEach(func() {
+			testutils.CleanupResources(Default, ctx, cfg, k8sClient, namespaceName,
+				&machinev1beta1.Machine{},
+			)
+		})
+
+		Context("with reconciling excess indexes enabled", func() {
+			BeforeEach(func() {
+				cpms.Annotations = map[string]string{reconcileExcessIndexesAnnotation: ""}
+
+				Expect(reconciler.reconcileMigrationMarks(ctx, logger.Logger(), cpms, machineInfos)).To(Succeed())
+			})
+
+			It("marks the machine of the excess index with the missing index", func() {
+				Eventually(komega.Object(machines[3])).Should(HaveField("ObjectMeta.Annotations", HaveKeyWithValue(migrateToIndexAnnotation, "2")))
+			})
+
+			It("does not mark machines in other indexes", func() {
+				Consistently(komega.Object(machines[0])).ShouldNot(HaveField("ObjectMeta.Annotations", HaveKey(migrateToIndexAnnotation)))
+				Consistently(komega.Object(machines[1])).ShouldNot(HaveField("ObjectMeta.Annotations", HaveKey(migrateToIndexAnnotation)))
+			})
+		})
+
+		Context("with reconciling excess indexes disabled", func() {
+			It("does not mark any machines", func() {
+				Expect(reconciler.reconcileMigrationMarks(ctx, logger.Logger(), cpms, machineInfos)).To(Succeed())
+
+				Consistently(komega.Object(machines[3])).ShouldNot(HaveField("ObjectMeta.Annotations", HaveKey(migrateToIndexAnnotation)))
+			})
+		})
+	})
+})
@@ -0,0 +1,80 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"fmt"
+
+	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/util"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// validateCapacityFailover checks that the capacity failover annotation, used by the machine provider to fail over
+// indexes to another failure domain, is valid.
+func validateCapacityFailover(cpms *machinev1.ControlPlaneMachineSet) error {
+	if _, err := util.GetCapacityFailover(cpms.Annotations); err != nil {
+		return fmt.Errorf("could not parse capacity failover: %w", err)
+	}
+
+	return nil
+}
+
+// getFailureDomainFailoverCondition adds each index, which has failed over to another failure domain because its
+// failure domain did not have the capacity to create a replacement Machine, to the Progressing condition.
+func getFailureDomainFailoverCondition(machineInfosByIndex map[int32][]machineproviders.MachineInfo, progressingCondition metav1.Condition) metav1.Condition {
+	for _, indexToMachines := range sortMachineInfosByIndex(machineInfosByIndex) {
+		failedOver := failedOverMachines(indexToMachines.machineInfos)
+		if isEmpty(failedOver) {
+			continue
+		}
+
+		failover := failedOver[0].FailureDomainFailover
+		progressingCondition.Message = fmt.Sprintf("%s, index %d failed over from failure domain %s to %s for lack of capacity", progressingCondition.Message, indexToMachines.index, failover.From, failover.To)
+	}
+
+	return progressingCondition
+}
+
+// failedOverMachines returns the list of MachineInfo which have Machines that failed for lack of capacity, and whose
+// index has failed over to another failure domain.
+func failedOverMachines(machinesInfo []machineproviders.MachineInfo) []machineproviders.MachineInfo {
+	result := []machineproviders.MachineInfo{}
+
+	for _, m := range machinesInfo {
+		if m.FailureDomainFailover != nil {
+			result = append(result, m)
+		}
+	}
+
+	return result
+}
+
+// nonFailedOverMachines returns the list of MachineInfo which have Machines that have not failed over to another
+// failure domain.
+func nonFailedOverMachines(machinesInfo []machineproviders.MachineInfo) []machineproviders.MachineInfo {
+	result := []machineproviders.MachineInfo{}
+
+	for _, m := range machinesInfo {
+		if m.FailureDomainFailover == nil {
+			result = append(result, m)
+		}
+	}
+
+	return result
+}
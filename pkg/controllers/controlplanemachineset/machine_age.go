@@ -0,0 +1,139 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"fmt"
+	"time"
+
+	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/util"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// getMachineAgeExceededCondition computes the Progressing condition when updates are required and a maximum machine
+// age has been configured. When every outdated Machine only needs an update because it has exceeded the maximum
+// machine age, the reason is changed so that age driven rotations can be told apart from changes to the template.
+// Otherwise, the number of replicas which have exceeded the maximum machine age is added to the existing condition.
+func getMachineAgeExceededCondition(cpms *machinev1.ControlPlaneMachineSet, machineInfosByIndex map[int32][]machineproviders.MachineInfo, progressingCondition metav1.Condition) metav1.Condition {
+	maxAge, err := util.GetMaxMachineAge(cpms.Annotations)
+	if err != nil || maxAge == 0 {
+		// An invalid value is reported via the Degraded condition.
+		return progressingCondition
+	}
+
+	outdated := outdatedNonDeletedMachines(machineInfosMaptoSlice(machineInfosByIndex))
+	aged := ageExceededMachines(outdated)
+
+	if len(aged) == 0 {
+		return progressingCondition
+	}
+
+	progressingCondition.Message = fmt.Sprintf("%s, %d replica(s) exceeded the maximum machine age of %s", progressingCondition.Message, len(aged), maxAge)
+
+	if len(aged) == len(outdated) && allAgeExceededOnly(aged) {
+		progressingCondition.Reason = reasonMachineAgeExceeded
+	}
+
+	return progressingCondition
+}
+
+// withMachineAgeRequeue ensures that the ControlPlaneMachineSet is requeued once the next Machine exceeds the
+// maximum machine age, so that the Machine is replaced without waiting for an unrelated event.
+func withMachineAgeRequeue(cpms *machinev1.ControlPlaneMachineSet, machineInfos map[int32][]machineproviders.MachineInfo, result ctrl.Result) ctrl.Result {
+	maxAge, err := util.GetMaxMachineAge(cpms.Annotations)
+	if err != nil || maxAge == 0 {
+		return result
+	}
+
+	requeueAfter := nextMachineAgeExpiry(machineInfosMaptoSlice(machineInfos), maxAge, time.Now())
+	if requeueAfter > 0 && (result.RequeueAfter == 0 || requeueAfter < result.RequeueAfter) {
+		result.RequeueAfter = requeueAfter
+	}
+
+	return result
+}
+
+// nextMachineAgeExpiry returns the duration until the next Machine, which has not yet exceeded the maximum machine
+// age, exceeds it. When no Machine will exceed the maximum machine age, 0 is returned.
+func nextMachineAgeExpiry(machinesInfo []machineproviders.MachineInfo, maxAge time.Duration, now time.Time) time.Duration {
+	var next time.Duration
+
+	for _, m := range machinesInfo {
+		if m.MachineRef == nil || m.AgeExceeded || isDeletedMachine(m) {
+			continue
+		}
+
+		creationTimestamp := m.MachineRef.ObjectMeta.CreationTimestamp.Time
+		if creationTimestamp.IsZero() {
+			continue
+		}
+
+		// The maximum machine age must have been exceeded, not just reached, for the Machine to be replaced.
+		untilExpiry := creationTimestamp.Add(maxAge).Sub(now) + time.Second
+		if untilExpiry < time.Second {
+			untilExpiry = time.Second
+		}
+
+		if next == 0 || untilExpiry < next {
+			next = untilExpiry
+		}
+	}
+
+	return next
+}
+
+// outdatedNonDeletedMachines returns the list of MachineInfo which have Machines that need an update and are not
+// pending deletion.
+func outdatedNonDeletedMachines(machinesInfo []machineproviders.MachineInfo) []machineproviders.MachineInfo {
+	result := []machineproviders.MachineInfo{}
+
+	for _, m := range machinesInfo {
+		if m.NeedsUpdate && m.MachineRef != nil && !isDeletedMachine(m) {
+			result = append(result, m)
+		}
+	}
+
+	return result
+}
+
+// ageExceededMachines returns the list of MachineInfo which have Machines that have exceeded the maximum machine age.
+func ageExceededMachines(machinesInfo []machineproviders.MachineInfo) []machineproviders.MachineInfo {
+	result := []machineproviders.MachineInfo{}
+
+	for _, m := range machinesInfo {
+		if m.AgeExceeded {
+			result = append(result, m)
+		}
+	}
+
+	return result
+}
+
+// allAgeExceededOnly determines whether every Machine only needs an update because it has exceeded the maximum
+// machine age. The difference describing the exceeded age is the only difference reported for these Machines.
+func allAgeExceededOnly(machinesInfo []machineproviders.MachineInfo) bool {
+	for _, m := range machinesInfo {
+		if !m.AgeExceeded || len(m.Diff) != 1 {
+			return false
+		}
+	}
+
+	return true
+}
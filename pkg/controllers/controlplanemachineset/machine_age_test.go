@@ -0,0 +1,79 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	machineprovidersresourcebuilder "github.com/openshift/cluster-control-plane-machine-set-operator/pkg/test/resourcebuilder/machineproviders"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Machine age", func() {
+	Context("nextMachineAgeExpiry", func() {
+		now := time.Date(2023, time.March, 1, 0, 0, 0, 0, time.UTC)
+		maxAge := 24 * time.Hour
+
+		machineInfoBuilder := machineprovidersresourcebuilder.MachineInfo().WithNodeName("node")
+
+		type nextMachineAgeExpiryTableInput struct {
+			machineInfos []machineproviders.MachineInfo
+			expected     time.Duration
+		}
+
+		DescribeTable("should return the duration until the next Machine exceeds the maximum machine age", func(in nextMachineAgeExpiryTableInput) {
+			Expect(nextMachineAgeExpiry(in.machineInfos, maxAge, now)).To(Equal(in.expected))
+		},
+			Entry("with no Machines", nextMachineAgeExpiryTableInput{
+				machineInfos: []machineproviders.MachineInfo{},
+				expected:     0,
+			}),
+			Entry("with Machines of different ages", nextMachineAgeExpiryTableInput{
+				machineInfos: []machineproviders.MachineInfo{
+					machineInfoBuilder.WithMachineName("machine-0").WithMachineCreationTimestamp(metav1.NewTime(now.Add(-time.Hour))).Build(),
+					machineInfoBuilder.WithMachineName("machine-1").WithMachineCreationTimestamp(metav1.NewTime(now.Add(-20 * time.Hour))).Build(),
+				},
+				expected: 4*time.Hour + time.Second,
+			}),
+			Entry("with a Machine which has already exceeded the maximum machine age", nextMachineAgeExpiryTableInput{
+				machineInfos: []machineproviders.MachineInfo{
+					machineInfoBuilder.WithMachineName("machine-0").WithMachineCreationTimestamp(metav1.NewTime(now.Add(-time.Hour))).Build(),
+					machineInfoBuilder.WithMachineName("machine-1").WithMachineCreationTimestamp(metav1.NewTime(now.Add(-48 * time.Hour))).
+						WithAgeExceeded(true).WithDiff([]string{"Machine has exceeded the maximum machine age"}).Build(),
+				},
+				expected: 23*time.Hour + time.Second,
+			}),
+			Entry("with a Machine which has reached, but not exceeded, the maximum machine age", nextMachineAgeExpiryTableInput{
+				machineInfos: []machineproviders.MachineInfo{
+					machineInfoBuilder.WithMachineName("machine-0").WithMachineCreationTimestamp(metav1.NewTime(now.Add(-maxAge))).Build(),
+				},
+				expected: time.Second,
+			}),
+			Entry("with a deleted Machine", nextMachineAgeExpiryTableInput{
+				machineInfos: []machineproviders.MachineInfo{
+					machineInfoBuilder.WithMachineName("machine-0").WithMachineCreationTimestamp(metav1.NewTime(now.Add(-20 * time.Hour))).
+						WithMachineDeletionTimestamp(metav1.NewTime(now)).Build(),
+				},
+				expected: 0,
+			}),
+		)
+	})
+})
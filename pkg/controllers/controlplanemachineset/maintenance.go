@@ -0,0 +1,346 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// maxMaintenanceWindowDuration is the longest duration of a single maintenance window.
+	// Windows repeat weekly, so a longer window would always be open.
+	maxMaintenanceWindowDuration = 7 * 24 * time.Hour
+
+	// changeHeldBackByMaintenanceWindow is a log message used to inform the user that a retry, remediation or
+	// migration of an excess index will not be started until the next maintenance window opens.
+	changeHeldBackByMaintenanceWindow = "Machine change is held back until the next maintenance window"
+)
+
+var (
+	// errNoMaintenanceWindows is used to inform users that the maintenance windows annotation does not
+	// configure any window.
+	errNoMaintenanceWindows = errors.New("at least one maintenance window must be configured")
+
+	// errInvalidMaintenanceWindowDay is used to inform users that a day of a maintenance window is not valid.
+	errInvalidMaintenanceWindowDay = errors.New("invalid maintenance window day")
+
+	// errInvalidMaintenanceWindowStart is used to inform users that the start of a maintenance window is not valid.
+	errInvalidMaintenanceWindowStart = errors.New("maintenance window start must be formatted as HH:MM")
+
+	// errInvalidMaintenanceWindowDuration is used to inform users that the duration of a maintenance window is
+	// not valid.
+	errInvalidMaintenanceWindowDuration = errors.New("maintenance window duration must be positive and no longer than 168h")
+)
+
+// maintenanceWindowsConfig is the configuration of the maintenance windows annotation.
+type maintenanceWindowsConfig struct {
+	// TimeZone is the IANA time zone in which the windows are defined. Defaults to UTC.
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// Windows are the weekly windows during which Machines may be created and deleted.
+	Windows []maintenanceWindowConfig `json:"windows"`
+
+	// ExemptDeletedMachines allows replacement Machines to be created outside of the windows,
+	// for indexes in which the Machine has been deleted or is missing.
+	ExemptDeletedMachines bool `json:"exemptDeletedMachines,omitempty"`
+}
+
+// maintenanceWindowConfig is the configuration of a single weekly maintenance window.
+type maintenanceWindowConfig struct {
+	// Days are the days of the week on which the window opens. Defaults to every day.
+	Days []string `json:"days,omitempty"`
+
+	// Start is the time of day, formatted as HH:MM, at which the window opens.
+	Start string `json:"start"`
+
+	// Duration is how long the window stays open, for example 4h.
+	Duration string `json:"duration"`
+}
+
+// maintenanceWindows is the parsed form of the maintenance windows annotation.
+// When no windows have been configured, the maintenance windows are always open.
+type maintenanceWindows struct {
+	location              *time.Location
+	windows               []maintenanceWindow
+	exemptDeletedMachines bool
+}
+
+// maintenanceWindow is a single weekly maintenance window.
+type maintenanceWindow struct {
+	days     map[time.Weekday]struct{}
+	hour     int
+	minute   int
+	duration time.Duration
+}
+
+// getMaintenanceWindows returns the maintenance windows configured by the maintenance windows annotation.
+// When the annotation is not present, no windows are returned and updates may happen at any time.
+func getMaintenanceWindows(cpms *machinev1.ControlPlaneMachineSet) (maintenanceWindows, error) {
+	value, ok := cpms.Annotations[maintenanceWindowsAnnotation]
+	if !ok {
+		return maintenanceWindows{}, nil
+	}
+
+	windows, err := parseMaintenanceWindows(value)
+	if err != nil {
+		return maintenanceWindows{}, fmt.Errorf("%s: %w", maintenanceWindowsAnnotation, err)
+	}
+
+	return windows, nil
+}
+
+// hasMaintenanceWindowsAnnotation determines whether maintenance windows have been configured on the
+// ControlPlaneMachineSet.
+func hasMaintenanceWindowsAnnotation(cpms *machinev1.ControlPlaneMachineSet) bool {
+	_, ok := cpms.Annotations[maintenanceWindowsAnnotation]
+	return ok
+}
+
+// parseMaintenanceWindows parses the JSON value of the maintenance windows annotation.
+func parseMaintenanceWindows(value string) (maintenanceWindows, error) {
+	config := maintenanceWindowsConfig{}
+	if err := json.Unmarshal([]byte(value), &config); err != nil {
+		return maintenanceWindows{}, fmt.Errorf("could not unmarshal maintenance windows: %w", err)
+	}
+
+	if len(config.Windows) == 0 {
+		return maintenanceWindows{}, errNoMaintenanceWindows
+	}
+
+	location := time.UTC
+
+	if config.TimeZone != "" {
+		var err error
+
+		location, err = time.LoadLocation(config.TimeZone)
+		if err != nil {
+			return maintenanceWindows{}, fmt.Errorf("could not load time zone: %w", err)
+		}
+	}
+
+	windows := maintenanceWindows{
+		location:              location,
+		exemptDeletedMachines: config.ExemptDeletedMachines,
+	}
+
+	for _, windowConfig := range config.Windows {
+		window, err := parseMaintenanceWindow(windowConfig)
+		if err != nil {
+			return maintenanceWindows{}, err
+		}
+
+		windows.windows = append(windows.windows, window)
+	}
+
+	return windows, nil
+}
+
+// parseMaintenanceWindow parses the configuration of a single weekly maintenance window.
+func parseMaintenanceWindow(config maintenanceWindowConfig) (maintenanceWindow, error) {
+	days, err := parseWeekdays(config.Days)
+	if err != nil {
+		return maintenanceWindow{}, err
+	}
+
+	hour, minute, err := parseTimeOfDay(config.Start)
+	if err != nil {
+		return maintenanceWindow{}, err
+	}
+
+	duration, err := time.ParseDuration(config.Duration)
+	if err != nil || duration <= 0 || duration > maxMaintenanceWindowDuration {
+		return maintenanceWindow{}, fmt.Errorf("%w: %q", errInvalidMaintenanceWindowDuration, config.Duration)
+	}
+
+	return maintenanceWindow{
+		days:     days,
+		hour:     hour,
+		minute:   minute,
+		duration: duration,
+	}, nil
+}
+
+// parseWeekdays parses the days of the week, either as full names or as three letter abbreviations.
+// When no days are given, every day of the week is returned.
+func parseWeekdays(values []string) (map[time.Weekday]struct{}, error) {
+	days := make(map[time.Weekday]struct{})
+
+	if len(values) == 0 {
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			days[day] = struct{}{}
+		}
+
+		return days, nil
+	}
+
+	for _, value := range values {
+		found := false
+
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			if strings.EqualFold(value, day.String()) || strings.EqualFold(value, day.String()[:3]) {
+				days[day] = struct{}{}
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("%w: %q", errInvalidMaintenanceWindowDay, value)
+		}
+	}
+
+	return days, nil
+}
+
+// parseTimeOfDay parses a time of day formatted as HH:MM.
+func parseTimeOfDay(value string) (int, int, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("%w: %q", errInvalidMaintenanceWindowStart, value)
+	}
+
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, 0, fmt.Errorf("%w: %q", errInvalidMaintenanceWindowStart, value)
+	}
+
+	minute, err := strconv.Atoi(parts[1])
+	if err != nil || minute < 0 || minute > 59 {
+		return 0, 0, fmt.Errorf("%w: %q", errInvalidMaintenanceWindowStart, value)
+	}
+
+	return hour, minute, nil
+}
+
+// isOpen determines whether any maintenance window is open at the given time.
+// When no maintenance windows have been configured, the windows are always open.
+func (w maintenanceWindows) isOpen(now time.Time) bool {
+	if len(w.windows) == 0 {
+		return true
+	}
+
+	open, _ := w.evaluate(now)
+
+	return open
+}
+
+// nextStart returns the time at which the next maintenance window opens after the given time.
+// When no maintenance windows have been configured, the zero time is returned.
+func (w maintenanceWindows) nextStart(now time.Time) time.Time {
+	if len(w.windows) == 0 {
+		return time.Time{}
+	}
+
+	_, next := w.evaluate(now)
+
+	return next
+}
+
+// evaluate determines whether any maintenance window is open at the given time, and when the next
+// maintenance window opens after the given time.
+func (w maintenanceWindows) evaluate(now time.Time) (bool, time.Time) {
+	local := now.In(w.location)
+
+	var open bool
+
+	var next time.Time
+
+	for _, window := range w.windows {
+		// Windows last at most a week, so only the windows starting in the surrounding weeks may be relevant.
+		for offset := -7; offset <= 7; offset++ {
+			start := time.Date(local.Year(), local.Month(), local.Day()+offset, window.hour, window.minute, 0, 0, w.location)
+			if _, ok := window.days[start.Weekday()]; !ok {
+				continue
+			}
+
+			if !start.After(now) && now.Before(start.Add(window.duration)) {
+				open = true
+			}
+
+			if start.After(now) && (next.IsZero() || start.Before(next)) {
+				next = start
+			}
+		}
+	}
+
+	return open, next
+}
+
+// isExempt determines whether the Machines in an index may be replaced outside of the maintenance windows.
+// This is the case when deleted Machines are exempt and the index only needs a replacement because its
+// Machine has been deleted or is missing.
+func (w maintenanceWindows) isExempt(machinesInfo []machineproviders.MachineInfo) bool {
+	if !w.exemptDeletedMachines {
+		return false
+	}
+
+	if isEmpty(machinesInfo) {
+		return true
+	}
+
+	return hasAny(deletingMachines(machinesInfo)) && isEmpty(outdatedNonDeletedMachines(machinesInfo))
+}
+
+// heldBackByMaintenanceWindows determines whether new Machine changes are held back at the given time, as maintenance
+// windows have been configured for the RollingUpdate and none is open. When held back, the time at which the next
+// maintenance window opens is returned.
+// An invalid value is reported via the Degraded condition before any change is considered, so it is not held back here.
+func heldBackByMaintenanceWindows(cpms *machinev1.ControlPlaneMachineSet, now time.Time) (time.Time, bool) {
+	if cpms.Spec.Strategy.Type != machinev1.RollingUpdate {
+		return time.Time{}, false
+	}
+
+	windows, err := getMaintenanceWindows(cpms)
+	if err != nil || windows.isOpen(now) {
+		return time.Time{}, false
+	}
+
+	return windows.nextStart(now), true
+}
+
+// hasStartedReplacement determines whether a replacement has already been created for an outdated Machine in the
+// index. A started replacement is completed outside of the maintenance windows, so that the index is not left with
+// both Machines until the next window opens.
+func hasStartedReplacement(machinesInfo []machineproviders.MachineInfo) bool {
+	return hasAny(needReplacementMachines(machinesInfo)) &&
+		(hasAny(pendingMachines(machinesInfo)) || hasAny(updatedMachines(machinesInfo)))
+}
+
+// getMaintenanceWindowCondition adds to the Progressing condition when the next maintenance window opens,
+// when updates are required but no maintenance window is currently open.
+func getMaintenanceWindowCondition(cpms *machinev1.ControlPlaneMachineSet, progressingCondition metav1.Condition, now time.Time) metav1.Condition {
+	windows, err := getMaintenanceWindows(cpms)
+	if err != nil || windows.isOpen(now) {
+		// An invalid value is reported via the Degraded condition.
+		return progressingCondition
+	}
+
+	progressingCondition.Message = fmt.Sprintf("%s, outside of the maintenance windows, the next maintenance window opens at %s",
+		progressingCondition.Message, windows.nextStart(now).Format(time.RFC3339))
+
+	return progressingCondition
+}
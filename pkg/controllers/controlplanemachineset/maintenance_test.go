@@ -0,0 +1,273 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	machinev1 "github.com/openshift/api/machine/v1"
+	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	machineprovidersresourcebuilder "github.com/openshift/cluster-control-plane-machine-set-operator/pkg/test/resourcebuilder/machineproviders"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Maintenance windows", func() {
+	Context("getMaintenanceWindows", func() {
+		type getMaintenanceWindowsTableInput struct {
+			annotations   map[string]string
+			expectedError string
+		}
+
+		DescribeTable("should validate the maintenance windows annotation", func(in getMaintenanceWindowsTableInput) {
+			cpms := machinev1resourcebuilder.ControlPlaneMachineSet().Build()
+			cpms.Annotations = in.annotations
+
+			_, err := getMaintenanceWindows(cpms)
+			if in.expectedError != "" {
+				Expect(err).To(MatchError(ContainSubstring(in.expectedError)))
+				return
+			}
+
+			Expect(err).ToNot(HaveOccurred())
+		},
+			Entry("with no annotation", getMaintenanceWindowsTableInput{}),
+			Entry("with a valid window", getMaintenanceWindowsTableInput{
+				annotations: map[string]string{
+					maintenanceWindowsAnnotation: `{"timeZone": "Europe/London", "windows": [{"days": ["Saturday", "sun"], "start": "02:00", "duration": "4h"}]}`,
+				},
+			}),
+			Entry("with a window on every day", getMaintenanceWindowsTableInput{
+				annotations: map[string]string{
+					maintenanceWindowsAnnotation: `{"windows": [{"start": "23:30", "duration": "1h"}]}`,
+				},
+			}),
+			Entry("with invalid JSON", getMaintenanceWindowsTableInput{
+				annotations: map[string]string{
+					maintenanceWindowsAnnotation: `{"windows":`,
+				},
+				expectedError: "could not unmarshal maintenance windows",
+			}),
+			Entry("with no windows", getMaintenanceWindowsTableInput{
+				annotations: map[string]string{
+					maintenanceWindowsAnnotation: `{"windows": []}`,
+				},
+				expectedError: errNoMaintenanceWindows.Error(),
+			}),
+			Entry("with an unknown time zone", getMaintenanceWindowsTableInput{
+				annotations: map[string]string{
+					maintenanceWindowsAnnotation: `{"timeZone": "Mars/Olympus_Mons", "windows": [{"start": "02:00", "duration": "4h"}]}`,
+				},
+				expectedError: "could not load time zone",
+			}),
+			Entry("with an invalid day", getMaintenanceWindowsTableInput{
+				annotations: map[string]string{
+					maintenanceWindowsAnnotation: `{"windows": [{"days": ["Someday"], "start": "02:00", "duration": "4h"}]}`,
+				},
+				expectedError: errInvalidMaintenanceWindowDay.Error(),
+			}),
+			Entry("with an invalid start", getMaintenanceWindowsTableInput{
+				annotations: map[string]string{
+					maintenanceWindowsAnnotation: `{"windows": [{"start": "24:00", "duration": "4h"}]}`,
+				},
+				expectedError: errInvalidMaintenanceWindowStart.Error(),
+			}),
+			Entry("with a duration longer than a week", getMaintenanceWindowsTableInput{
+				annotations: map[string]string{
+					maintenanceWindowsAnnotation: `{"windows": [{"start": "02:00", "duration": "169h"}]}`,
+				},
+				expectedError: errInvalidMaintenanceWindowDuration.Error(),
+			}),
+		)
+	})
+
+	Context("evaluating the maintenance windows", func() {
+		// Saturday 02:00 to 06:00 and Wednesday 22:00 to Thursday 01:00, in New York.
+		annotation := `{"timeZone": "America/New_York", "windows": [{"days": ["Sat"], "start": "02:00", "duration": "4h"}, {"days": ["Wed"], "start": "22:00", "duration": "3h"}]}`
+
+		newYork, err := time.LoadLocation("America/New_York")
+		Expect(err).ToNot(HaveOccurred())
+
+		type evaluateTableInput struct {
+			now               time.Time
+			expectedOpen      bool
+			expectedNextStart time.Time
+		}
+
+		DescribeTable("should determine whether a window is open and when the next window opens", func(in evaluateTableInput) {
+			cpms := machinev1resourcebuilder.ControlPlaneMachineSet().Build()
+			cpms.Annotations = map[string]string{maintenanceWindowsAnnotation: annotation}
+
+			windows, err := getMaintenanceWindows(cpms)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(windows.isOpen(in.now)).To(Equal(in.expectedOpen))
+			Expect(windows.nextStart(in.now)).To(BeTemporally("==", in.expectedNextStart))
+		},
+			Entry("before the Saturday window", evaluateTableInput{
+				now:               time.Date(2023, time.March, 3, 12, 0, 0, 0, newYork),
+				expectedOpen:      false,
+				expectedNextStart: time.Date(2023, time.March, 4, 2, 0, 0, 0, newYork),
+			}),
+			Entry("during the Saturday window", evaluateTableInput{
+				now:               time.Date(2023, time.March, 4, 3, 0, 0, 0, newYork),
+				expectedOpen:      true,
+				expectedNextStart: time.Date(2023, time.March, 8, 22, 0, 0, 0, newYork),
+			}),
+			Entry("at the end of the Saturday window", evaluateTableInput{
+				now:               time.Date(2023, time.March, 4, 6, 0, 0, 0, newYork),
+				expectedOpen:      false,
+				expectedNextStart: time.Date(2023, time.March, 8, 22, 0, 0, 0, newYork),
+			}),
+			Entry("during the Wednesday window, after midnight", evaluateTableInput{
+				now:               time.Date(2023, time.March, 9, 0, 30, 0, 0, newYork),
+				expectedOpen:      true,
+				expectedNextStart: time.Date(2023, time.March, 11, 2, 0, 0, 0, newYork),
+			}),
+			Entry("in UTC, during the Saturday window", evaluateTableInput{
+				now:               time.Date(2023, time.March, 4, 8, 0, 0, 0, time.UTC),
+				expectedOpen:      true,
+				expectedNextStart: time.Date(2023, time.March, 8, 22, 0, 0, 0, newYork),
+			}),
+		)
+
+		It("should always be open when no windows are configured", func() {
+			Expect(maintenanceWindows{}.isOpen(time.Now())).To(BeTrue())
+		})
+	})
+
+	Context("isExempt", func() {
+		machineInfoBuilder := machineprovidersresourcebuilder.MachineInfo().WithIndex(0).WithNodeName("node-0")
+
+		type isExemptTableInput struct {
+			exemptDeletedMachines bool
+			machineInfos          []machineproviders.MachineInfo
+			expected              bool
+		}
+
+		DescribeTable("should determine whether an index may be replaced outside of the maintenance windows", func(in isExemptTableInput) {
+			windows := maintenanceWindows{exemptDeletedMachines: in.exemptDeletedMachines}
+
+			Expect(windows.isExempt(in.machineInfos)).To(Equal(in.expected))
+		},
+			Entry("with a deleted Machine, when deleted Machines are not exempt", isExemptTableInput{
+				machineInfos: []machineproviders.MachineInfo{
+					machineInfoBuilder.WithMachineName("machine-0").WithMachineDeletionTimestamp(metav1.Now()).Build(),
+				},
+				expected: false,
+			}),
+			Entry("with a deleted Machine", isExemptTableInput{
+				exemptDeletedMachines: true,
+				machineInfos: []machineproviders.MachineInfo{
+					machineInfoBuilder.WithMachineName("machine-0").WithMachineDeletionTimestamp(metav1.Now()).Build(),
+				},
+				expected: true,
+			}),
+			Entry("with no Machines", isExemptTableInput{
+				exemptDeletedMachines: true,
+				machineInfos:          []machineproviders.MachineInfo{},
+				expected:              true,
+			}),
+			Entry("with an outdated Machine", isExemptTableInput{
+				exemptDeletedMachines: true,
+				machineInfos: []machineproviders.MachineInfo{
+					machineInfoBuilder.WithMachineName("machine-0").WithNeedsUpdate(true).Build(),
+				},
+				expected: false,
+			}),
+		)
+	})
+
+	Context("heldBackByMaintenanceWindows", func() {
+		// Saturday 02:00 to 06:00, in UTC.
+		annotation := `{"windows": [{"days": ["Sat"], "start": "02:00", "duration": "4h"}]}`
+
+		type heldBackTableInput struct {
+			strategy         machinev1.ControlPlaneMachineSetStrategyType
+			annotations      map[string]string
+			now              time.Time
+			expectedHeldBack bool
+			expectedNext     time.Time
+		}
+
+		DescribeTable("should determine whether new Machine changes are held back", func(in heldBackTableInput) {
+			cpms := machinev1resourcebuilder.ControlPlaneMachineSet().WithStrategyType(in.strategy).Build()
+			cpms.Annotations = in.annotations
+
+			next, heldBack := heldBackByMaintenanceWindows(cpms, in.now)
+			Expect(heldBack).To(Equal(in.expectedHeldBack))
+			Expect(next).To(BeTemporally("==", in.expectedNext))
+		},
+			Entry("with no annotation", heldBackTableInput{
+				strategy:         machinev1.RollingUpdate,
+				now:              time.Date(2023, time.March, 3, 12, 0, 0, 0, time.UTC),
+				expectedHeldBack: false,
+			}),
+			Entry("outside of the windows", heldBackTableInput{
+				strategy:         machinev1.RollingUpdate,
+				annotations:      map[string]string{maintenanceWindowsAnnotation: annotation},
+				now:              time.Date(2023, time.March, 3, 12, 0, 0, 0, time.UTC),
+				expectedHeldBack: true,
+				expectedNext:     time.Date(2023, time.March, 4, 2, 0, 0, 0, time.UTC),
+			}),
+			Entry("during a window", heldBackTableInput{
+				strategy:         machinev1.RollingUpdate,
+				annotations:      map[string]string{maintenanceWindowsAnnotation: annotation},
+				now:              time.Date(2023, time.March, 4, 3, 0, 0, 0, time.UTC),
+				expectedHeldBack: false,
+			}),
+			Entry("outside of the windows, with the OnDelete strategy", heldBackTableInput{
+				strategy:         machinev1.OnDelete,
+				annotations:      map[string]string{maintenanceWindowsAnnotation: annotation},
+				now:              time.Date(2023, time.March, 3, 12, 0, 0, 0, time.UTC),
+				expectedHeldBack: false,
+			}),
+			Entry("with an invalid annotation", heldBackTableInput{
+				strategy:         machinev1.RollingUpdate,
+				annotations:      map[string]string{maintenanceWindowsAnnotation: "invalid"},
+				now:              time.Date(2023, time.March, 3, 12, 0, 0, 0, time.UTC),
+				expectedHeldBack: false,
+			}),
+		)
+	})
+
+	Context("hasStartedReplacement", func() {
+		machineInfoBuilder := machineprovidersresourcebuilder.MachineInfo().WithIndex(0)
+
+		DescribeTable("should determine whether a replacement has been created for an outdated Machine", func(machineInfos []machineproviders.MachineInfo, expected bool) {
+			Expect(hasStartedReplacement(machineInfos)).To(Equal(expected))
+		},
+			Entry("with only an outdated Machine", []machineproviders.MachineInfo{
+				machineInfoBuilder.WithMachineName("machine-0").WithReady(true).WithNeedsUpdate(true).Build(),
+			}, false),
+			Entry("with an outdated Machine and a pending replacement", []machineproviders.MachineInfo{
+				machineInfoBuilder.WithMachineName("machine-0").WithReady(true).WithNeedsUpdate(true).Build(),
+				machineInfoBuilder.WithMachineName("machine-replacement-0").WithReady(false).WithNeedsUpdate(false).Build(),
+			}, true),
+			Entry("with an outdated Machine and a ready replacement", []machineproviders.MachineInfo{
+				machineInfoBuilder.WithMachineName("machine-0").WithReady(true).WithNeedsUpdate(true).Build(),
+				machineInfoBuilder.WithMachineName("machine-replacement-0").WithReady(true).WithNeedsUpdate(false).Build(),
+			}, true),
+			Entry("with only an up to date Machine", []machineproviders.MachineInfo{
+				machineInfoBuilder.WithMachineName("machine-0").WithReady(true).WithNeedsUpdate(false).Build(),
+			}, false),
+		)
+	})
+})
@@ -0,0 +1,171 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// replacementOrder is the policy used to choose which outdated index is replaced next.
+type replacementOrder string
+
+const (
+	// replacementOrderIndex replaces outdated indexes in ascending index order.
+	replacementOrderIndex replacementOrder = "Index"
+
+	// replacementOrderOldestFirst replaces the index with the oldest outdated Machine first.
+	replacementOrderOldestFirst replacementOrder = "OldestFirst"
+
+	// replacementOrderUnhealthyFirst replaces indexes whose outdated Machine is not Ready, or whose etcd member is not
+	// healthy, before indexes whose outdated Machine is healthy.
+	replacementOrderUnhealthyFirst replacementOrder = "UnhealthyFirst"
+
+	// replacementOrderFailureDomain replaces outdated indexes one failure domain at a time, ordered by the name of
+	// the zone of the outdated Machine.
+	replacementOrderFailureDomain replacementOrder = "FailureDomain"
+
+	// machineZoneLabel is the label set by the Machine API on a Machine to record the zone in which its instance runs.
+	machineZoneLabel = "machine.openshift.io/zone"
+)
+
+// errInvalidReplacementOrder is used to inform users that the value of the replacement order annotation is not valid.
+var errInvalidReplacementOrder = errors.New("replacement order must be one of Index, OldestFirst, UnhealthyFirst or FailureDomain")
+
+// replacementOrderLessFuncs holds, for each replacement order other than the index order, the function used to
+// determine whether the outdated Machines of one index should be replaced before those of another.
+// Indexes for which neither is preferred are replaced in ascending index order.
+// There is no order which replaces the etcd leader last: etcd leadership is not published by the etcd operator
+// status, nor elsewhere through the Kubernetes API, and is only exposed by the etcd metrics.
+var replacementOrderLessFuncs = map[replacementOrder]func(a, b []machineproviders.MachineInfo) bool{
+	replacementOrderOldestFirst:    oldestFirst,
+	replacementOrderUnhealthyFirst: unhealthyFirst,
+	replacementOrderFailureDomain:  byFailureDomain,
+}
+
+// getReplacementOrder returns the replacement order configured by the replacement order annotation.
+// When the annotation is not present, outdated indexes are replaced in ascending index order.
+func getReplacementOrder(cpms *machinev1.ControlPlaneMachineSet) (replacementOrder, error) {
+	value, ok := cpms.Annotations[replacementOrderAnnotation]
+	if !ok {
+		return replacementOrderIndex, nil
+	}
+
+	order := replacementOrder(value)
+	if _, ok := replacementOrderLessFuncs[order]; !ok && order != replacementOrderIndex {
+		return "", fmt.Errorf("%w: %s: %q", errInvalidReplacementOrder, replacementOrderAnnotation, value)
+	}
+
+	return order, nil
+}
+
+// sortMachineInfosForReplacement returns a list of each index' MachineInfos, sorted in the order in which the
+// outdated indexes should be replaced.
+// Indexes without outdated Machines, for example those missing a Machine, are kept ahead of the outdated indexes
+// so that they are not starved of surge capacity.
+func sortMachineInfosForReplacement(indexedMachineInfos map[int32][]machineproviders.MachineInfo, order replacementOrder) []indexToMachineInfos {
+	sorted := sortMachineInfosByIndex(indexedMachineInfos)
+
+	less, ok := replacementOrderLessFuncs[order]
+	if !ok {
+		return sorted
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		outdatedI := needReplacementMachines(sorted[i].machineInfos)
+		outdatedJ := needReplacementMachines(sorted[j].machineInfos)
+
+		if isEmpty(outdatedI) || isEmpty(outdatedJ) {
+			return isEmpty(outdatedI) && hasAny(outdatedJ)
+		}
+
+		return less(outdatedI, outdatedJ)
+	})
+
+	return sorted
+}
+
+// oldestFirst prefers the index whose oldest outdated Machine was created first.
+func oldestFirst(a, b []machineproviders.MachineInfo) bool {
+	oldestA, oldestB := oldestCreationTimestamp(a), oldestCreationTimestamp(b)
+
+	return oldestA.Before(&oldestB)
+}
+
+// unhealthyFirst prefers the index with an unhealthy outdated Machine over an index whose outdated Machines are
+// all healthy.
+func unhealthyFirst(a, b []machineproviders.MachineInfo) bool {
+	return !isHealthy(a) && isHealthy(b)
+}
+
+// byFailureDomain prefers the index whose outdated Machine is in the zone whose name sorts first.
+// Machines without a zone are replaced last.
+func byFailureDomain(a, b []machineproviders.MachineInfo) bool {
+	zoneA, zoneB := machineZone(a[0]), machineZone(b[0])
+
+	switch {
+	case zoneA == zoneB, zoneA == "":
+		return false
+	case zoneB == "":
+		return true
+	default:
+		return zoneA < zoneB
+	}
+}
+
+// oldestCreationTimestamp returns the earliest creation timestamp of the given Machines.
+func oldestCreationTimestamp(machineInfos []machineproviders.MachineInfo) metav1.Time {
+	oldest := metav1.Time{}
+
+	for _, machineInfo := range machineInfos {
+		if machineInfo.MachineRef == nil {
+			continue
+		}
+
+		created := machineInfo.MachineRef.ObjectMeta.CreationTimestamp
+		if oldest.IsZero() || created.Before(&oldest) {
+			oldest = created
+		}
+	}
+
+	return oldest
+}
+
+// isHealthy determines whether all of the given Machines are Ready and, when known, have a healthy etcd member.
+func isHealthy(machineInfos []machineproviders.MachineInfo) bool {
+	for _, machineInfo := range machineInfos {
+		if !machineInfo.Ready || (machineInfo.EtcdMember != nil && !machineInfo.EtcdMember.Healthy) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// machineZone returns the zone recorded on the Machine, or an empty string when the zone is not known.
+func machineZone(machineInfo machineproviders.MachineInfo) string {
+	if machineInfo.MachineRef == nil {
+		return ""
+	}
+
+	return machineInfo.MachineRef.ObjectMeta.Labels[machineZoneLabel]
+}
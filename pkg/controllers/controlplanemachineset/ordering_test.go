@@ -0,0 +1,164 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	machineprovidersresourcebuilder "github.com/openshift/cluster-control-plane-machine-set-operator/pkg/test/resourcebuilder/machineproviders"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Replacement order", func() {
+	Context("getReplacementOrder", func() {
+		type getReplacementOrderTableInput struct {
+			annotations   map[string]string
+			expectedOrder replacementOrder
+			expectedError error
+		}
+
+		DescribeTable("should parse the replacement order annotation", func(in getReplacementOrderTableInput) {
+			cpms := machinev1resourcebuilder.ControlPlaneMachineSet().Build()
+			cpms.Annotations = in.annotations
+
+			order, err := getReplacementOrder(cpms)
+			if in.expectedError != nil {
+				Expect(err).To(MatchError(in.expectedError))
+				return
+			}
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(order).To(Equal(in.expectedOrder))
+		},
+			Entry("with no annotation", getReplacementOrderTableInput{
+				expectedOrder: replacementOrderIndex,
+			}),
+			Entry("with the index order", getReplacementOrderTableInput{
+				annotations:   map[string]string{replacementOrderAnnotation: "Index"},
+				expectedOrder: replacementOrderIndex,
+			}),
+			Entry("with the oldest first order", getReplacementOrderTableInput{
+				annotations:   map[string]string{replacementOrderAnnotation: "OldestFirst"},
+				expectedOrder: replacementOrderOldestFirst,
+			}),
+			Entry("with the unhealthy first order", getReplacementOrderTableInput{
+				annotations:   map[string]string{replacementOrderAnnotation: "UnhealthyFirst"},
+				expectedOrder: replacementOrderUnhealthyFirst,
+			}),
+			Entry("with the failure domain order", getReplacementOrderTableInput{
+				annotations:   map[string]string{replacementOrderAnnotation: "FailureDomain"},
+				expectedOrder: replacementOrderFailureDomain,
+			}),
+			Entry("with an unknown order", getReplacementOrderTableInput{
+				annotations:   map[string]string{replacementOrderAnnotation: "oldestfirst"},
+				expectedError: fmt.Errorf("%w: %s: %q", errInvalidReplacementOrder, replacementOrderAnnotation, "oldestfirst"),
+			}),
+		)
+	})
+
+	Context("sortMachineInfosForReplacement", func() {
+		now := time.Date(2023, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+		machineInfoBuilder := machineprovidersresourcebuilder.MachineInfo().WithReady(true)
+		outdatedMachineInfoBuilder := machineInfoBuilder.WithNeedsUpdate(true)
+
+		machineInfo := func(index int32, created time.Time, zone string) machineproviders.MachineInfo {
+			return outdatedMachineInfoBuilder.WithIndex(index).WithMachineName(fmt.Sprintf("machine-%d", index)).
+				WithMachineCreationTimestamp(metav1.NewTime(created)).
+				WithMachineLabels(map[string]string{machineZoneLabel: zone}).Build()
+		}
+
+		type sortMachineInfosForReplacementTableInput struct {
+			machineInfos    map[int32][]machineproviders.MachineInfo
+			order           replacementOrder
+			expectedIndexes []int32
+		}
+
+		DescribeTable("should sort the indexes in the order they should be replaced", func(in sortMachineInfosForReplacementTableInput) {
+			indexes := []int32{}
+			for _, indexToMachines := range sortMachineInfosForReplacement(in.machineInfos, in.order) {
+				indexes = append(indexes, indexToMachines.index)
+			}
+
+			Expect(indexes).To(Equal(in.expectedIndexes))
+		},
+			Entry("with the index order", sortMachineInfosForReplacementTableInput{
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					2: {machineInfo(2, now.Add(-3*time.Hour), "us-east-1a")},
+					0: {machineInfo(0, now.Add(-1*time.Hour), "us-east-1c")},
+					1: {machineInfo(1, now.Add(-2*time.Hour), "us-east-1b")},
+				},
+				order:           replacementOrderIndex,
+				expectedIndexes: []int32{0, 1, 2},
+			}),
+			Entry("with the oldest first order", sortMachineInfosForReplacementTableInput{
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {machineInfo(0, now.Add(-1*time.Hour), "us-east-1a")},
+					1: {machineInfo(1, now.Add(-3*time.Hour), "us-east-1b")},
+					2: {machineInfo(2, now.Add(-2*time.Hour), "us-east-1c")},
+				},
+				order:           replacementOrderOldestFirst,
+				expectedIndexes: []int32{1, 2, 0},
+			}),
+			Entry("with the oldest first order, and Machines of the same age", sortMachineInfosForReplacementTableInput{
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {machineInfo(0, now.Add(-1*time.Hour), "us-east-1a")},
+					1: {machineInfo(1, now.Add(-3*time.Hour), "us-east-1b")},
+					2: {machineInfo(2, now.Add(-1*time.Hour), "us-east-1c")},
+				},
+				order:           replacementOrderOldestFirst,
+				expectedIndexes: []int32{1, 0, 2},
+			}),
+			Entry("with the unhealthy first order", sortMachineInfosForReplacementTableInput{
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {machineInfo(0, now, "us-east-1a")},
+					1: {outdatedMachineInfoBuilder.WithIndex(1).WithMachineName("machine-1").WithEtcdMember(true, false).Build()},
+					2: {outdatedMachineInfoBuilder.WithIndex(2).WithMachineName("machine-2").WithReady(false).Build()},
+				},
+				order:           replacementOrderUnhealthyFirst,
+				expectedIndexes: []int32{1, 2, 0},
+			}),
+			Entry("with the failure domain order", sortMachineInfosForReplacementTableInput{
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {machineInfo(0, now, "us-east-1c")},
+					1: {machineInfo(1, now, "")},
+					2: {machineInfo(2, now, "us-east-1a")},
+					3: {machineInfo(3, now, "us-east-1b")},
+					4: {machineInfo(4, now, "us-east-1a")},
+				},
+				order:           replacementOrderFailureDomain,
+				expectedIndexes: []int32{2, 4, 3, 0, 1},
+			}),
+			Entry("with indexes without outdated Machines", sortMachineInfosForReplacementTableInput{
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {machineInfo(0, now.Add(-1*time.Hour), "us-east-1a")},
+					1: {machineInfoBuilder.WithIndex(1).WithMachineName("machine-1").Build()},
+					2: {machineInfo(2, now.Add(-2*time.Hour), "us-east-1c")},
+					3: {},
+				},
+				order:           replacementOrderOldestFirst,
+				expectedIndexes: []int32{1, 3, 2, 0},
+			}),
+		)
+	})
+})
@@ -0,0 +1,369 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// planConfigMapSuffix is appended to the name of the ControlPlaneMachineSet to name the ConfigMap
+	// in which the dry-run plan is published.
+	planConfigMapSuffix = "-plan"
+
+	// planDataKey is the key within the plan ConfigMap under which the plan is stored.
+	planDataKey = "plan.json"
+
+	// planActionCreate is the action recorded when the update strategy would have created a Machine.
+	planActionCreate = "Create"
+
+	// planActionDelete is the action recorded when the update strategy would have deleted a Machine.
+	planActionDelete = "Delete"
+
+	// publishedPlan is a log message used to inform the user that the dry-run plan has been
+	// published to the plan ConfigMap.
+	publishedPlan = "Published dry-run plan"
+
+	// removedPlan is a log message used to inform the user that the plan ConfigMap has been removed
+	// as the dry-run plan mode is no longer enabled.
+	removedPlan = "Removed dry-run plan"
+)
+
+// machinePlan is the plan published while the dry-run plan mode is enabled.
+// It describes the actions the update strategy would have taken based on the current state of the Machines.
+type machinePlan struct {
+	// Generation is the generation of the ControlPlaneMachineSet observed when computing the plan.
+	Generation int64 `json:"generation"`
+
+	// Strategy is the update strategy used to compute the plan.
+	Strategy machinev1.ControlPlaneMachineSetStrategyType `json:"strategy"`
+
+	// Actions are the Machine creations and deletions that would have been performed, in order.
+	Actions []plannedAction `json:"actions"`
+
+	// Indexes describes the Machines observed within each index.
+	Indexes []plannedIndex `json:"indexes"`
+}
+
+// plannedAction is a Machine creation or deletion that would have been performed.
+type plannedAction struct {
+	// Action is either Create or Delete.
+	Action string `json:"action"`
+
+	// Index is the index of the Machine being created or deleted.
+	Index int32 `json:"index"`
+
+	// MachineName is the name of the Machine that would have been deleted.
+	// The name of a Machine that would have been created is not known ahead of its creation.
+	MachineName string `json:"machineName,omitempty"`
+
+	// Diff is the difference between the existing spec and the desired spec of the outdated Machine
+	// which caused the action, if any.
+	Diff []string `json:"diff,omitempty"`
+}
+
+// plannedIndex describes the Machines observed within an index.
+type plannedIndex struct {
+	// Index is the index of the Machines.
+	Index int32 `json:"index"`
+
+	// Machines are the Machines observed within the index.
+	Machines []plannedMachine `json:"machines"`
+}
+
+// plannedMachine describes a Machine observed while computing the plan.
+type plannedMachine struct {
+	// Name is the name of the Machine.
+	Name string `json:"name"`
+
+	// Ready determines whether the Machine is ready.
+	Ready bool `json:"ready"`
+
+	// NeedsUpdate determines whether the Machine is outdated.
+	NeedsUpdate bool `json:"needsUpdate"`
+
+	// Deleting determines whether the Machine has been marked for deletion.
+	Deleting bool `json:"deleting,omitempty"`
+
+	// Diff is the difference between the existing spec and the desired spec of the Machine.
+	Diff []string `json:"diff,omitempty"`
+
+	// IgnoredDiff is the difference between the existing spec and the desired spec of the Machine,
+	// in the fields which are ignored when determining whether the Machine needs an update.
+	IgnoredDiff []string `json:"ignoredDiff,omitempty"`
+}
+
+// planRecorder is a MachineProvider which records the Machines that would have been created or deleted
+// instead of creating or deleting them. Information about the Machines is still gathered from the wrapped
+// MachineProvider.
+type planRecorder struct {
+	machineproviders.MachineProvider
+
+	// machineInfos are the Machines observed when computing the plan, used to describe the recorded actions.
+	machineInfos map[int32][]machineproviders.MachineInfo
+
+	// actions are the actions recorded so far. These are shared with any copy of the recorder.
+	actions *[]plannedAction
+}
+
+// newPlanRecorder wraps the MachineProvider so that any Machine creations or deletions are recorded.
+func newPlanRecorder(machineProvider machineproviders.MachineProvider, machineInfos map[int32][]machineproviders.MachineInfo) *planRecorder {
+	return &planRecorder{
+		MachineProvider: machineProvider,
+		machineInfos:    machineInfos,
+		actions:         &[]plannedAction{},
+	}
+}
+
+// WithClient returns a copy of the recorder wrapping a copy of the MachineProvider with the new client.
+// Actions recorded by the copy are recorded in the same plan.
+func (p *planRecorder) WithClient(ctx context.Context, logger logr.Logger, cl client.Client) (machineproviders.MachineProvider, error) {
+	machineProvider, err := p.MachineProvider.WithClient(ctx, logger, cl)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing machine provider with client: %w", err)
+	}
+
+	return &planRecorder{
+		MachineProvider: machineProvider,
+		machineInfos:    p.machineInfos,
+		actions:         p.actions,
+	}, nil
+}
+
+// CreateMachine records that a Machine would have been created in the index.
+func (p *planRecorder) CreateMachine(_ context.Context, _ logr.Logger, idx int32) error {
+	action := plannedAction{
+		Action: planActionCreate,
+		Index:  idx,
+	}
+
+	// Attribute the creation to the outdated Machine it replaces, if any.
+	if outdated := needReplacementMachines(p.machineInfos[idx]); hasAny(outdated) {
+		action.Diff = outdated[0].Diff
+	}
+
+	*p.actions = append(*p.actions, action)
+
+	return nil
+}
+
+// DeleteMachine records that the Machine would have been deleted.
+func (p *planRecorder) DeleteMachine(_ context.Context, _ logr.Logger, machineRef *machineproviders.ObjectRef) error {
+	action := plannedAction{
+		Action:      planActionDelete,
+		MachineName: machineRef.ObjectMeta.Name,
+	}
+
+	for idx, machines := range p.machineInfos {
+		for _, machine := range machines {
+			if machine.MachineRef != nil && machine.MachineRef.ObjectMeta.Name == machineRef.ObjectMeta.Name {
+				action.Index = idx
+				action.Diff = machine.Diff
+			}
+		}
+	}
+
+	*p.actions = append(*p.actions, action)
+
+	return nil
+}
+
+// Actions returns the actions recorded so far.
+func (p *planRecorder) Actions() []plannedAction {
+	return append([]plannedAction{}, *p.actions...)
+}
+
+// reconcileDryRun runs the update strategy of the ControlPlaneMachineSet against a plan recorder rather than the
+// machine provider, and publishes the actions the update strategy would have taken as a plan.
+// No Machines are created, deleted or otherwise modified, and the owner references, revision history and any rollback
+// of the ControlPlaneMachineSet are left untouched.
+func (r *ControlPlaneMachineSetReconciler) reconcileDryRun(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, machineProvider machineproviders.MachineProvider, machineInfos map[int32][]machineproviders.MachineInfo) (ctrl.Result, error) {
+	recorder := newPlanRecorder(machineProvider, machineInfos)
+
+	// Plan using a copy of the reconciler so that the replacement attempts
+	// tracked for the real update strategy are not modified by the plan.
+	planner := *r
+	planner.dryRun = true
+	planner.replacementAttempts = make(map[int32]replacementAttempts, len(r.replacementAttempts))
+
+	for idx, attempts := range r.replacementAttempts {
+		planner.replacementAttempts[idx] = attempts
+	}
+
+	result, err := planner.reconcileMachineUpdates(ctx, logger, cpms, recorder, machineInfos)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("error computing dry-run plan: %w", err)
+	}
+
+	plan := machinePlan{
+		Generation: cpms.Generation,
+		Strategy:   cpms.Spec.Strategy.Type,
+		Actions:    recorder.Actions(),
+		Indexes:    plannedIndexes(machineInfos),
+	}
+
+	if err := r.setPlanPublished(ctx, cpms, true); err != nil {
+		return ctrl.Result{}, fmt.Errorf("error recording dry-run plan: %w", err)
+	}
+
+	if err := r.publishPlan(ctx, logger, cpms, plan); err != nil {
+		return ctrl.Result{}, fmt.Errorf("error publishing dry-run plan: %w", err)
+	}
+
+	return result, nil
+}
+
+// publishPlan creates or updates the plan ConfigMap with the plan provided.
+func (r *ControlPlaneMachineSetReconciler) publishPlan(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, plan machinePlan) error {
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not marshal plan: %w", err)
+	}
+
+	configMap := &corev1.ConfigMap{}
+	configMapKey := client.ObjectKey{Namespace: cpms.Namespace, Name: planConfigMapName(cpms)}
+
+	if err := r.Get(ctx, configMapKey, configMap); apierrors.IsNotFound(err) {
+		configMap = &corev1.ConfigMap{}
+		configMap.SetNamespace(configMapKey.Namespace)
+		configMap.SetName(configMapKey.Name)
+		configMap.Data = map[string]string{planDataKey: string(data)}
+
+		if err := controllerutil.SetControllerReference(cpms, configMap, r.Scheme); err != nil {
+			return fmt.Errorf("could not set owner reference on plan: %w", err)
+		}
+
+		if err := r.Create(ctx, configMap); err != nil {
+			return fmt.Errorf("could not create plan ConfigMap %s: %w", configMapKey, err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("could not fetch plan ConfigMap %s: %w", configMapKey, err)
+	} else {
+		desiredData := map[string]string{planDataKey: string(data)}
+		if equality.Semantic.DeepEqual(configMap.Data, desiredData) {
+			// The plan has not changed.
+			return nil
+		}
+
+		configMap.Data = desiredData
+
+		if err := r.Update(ctx, configMap); err != nil {
+			return fmt.Errorf("could not update plan ConfigMap %s: %w", configMapKey, err)
+		}
+	}
+
+	logger.V(2).WithValues("configMap", configMapKey.Name, "actions", len(plan.Actions)).Info(publishedPlan)
+
+	return nil
+}
+
+// removePlan removes the plan ConfigMap once the dry-run plan mode is no longer enabled.
+// The ConfigMap is only removed when the ControlPlaneMachineSet records that a plan was published.
+func (r *ControlPlaneMachineSetReconciler) removePlan(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet) error {
+	if _, ok := cpms.Annotations[planPublishedAnnotation]; !ok {
+		return nil
+	}
+
+	configMap := &corev1.ConfigMap{}
+	configMap.SetNamespace(cpms.Namespace)
+	configMap.SetName(planConfigMapName(cpms))
+
+	if err := r.Delete(ctx, configMap); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("could not delete plan ConfigMap %s: %w", client.ObjectKeyFromObject(configMap), err)
+	}
+
+	logger.V(2).WithValues("configMap", configMap.GetName()).Info(removedPlan)
+
+	return r.setPlanPublished(ctx, cpms, false)
+}
+
+// setPlanPublished adds or removes the plan published annotation on the ControlPlaneMachineSet.
+func (r *ControlPlaneMachineSetReconciler) setPlanPublished(ctx context.Context, cpms *machinev1.ControlPlaneMachineSet, published bool) error {
+	if _, ok := cpms.Annotations[planPublishedAnnotation]; ok == published {
+		return nil
+	}
+
+	cpmsCopy := cpms.DeepCopy()
+	patchBase := client.MergeFrom(cpms.DeepCopy())
+
+	if published {
+		if cpmsCopy.Annotations == nil {
+			cpmsCopy.Annotations = map[string]string{}
+		}
+
+		cpmsCopy.Annotations[planPublishedAnnotation] = ""
+	} else {
+		delete(cpmsCopy.Annotations, planPublishedAnnotation)
+	}
+
+	if err := r.Patch(ctx, cpmsCopy, patchBase); err != nil {
+		return fmt.Errorf("error patching control plane machine set: %w", err)
+	}
+
+	cpms.Annotations = cpmsCopy.Annotations
+	cpms.ResourceVersion = cpmsCopy.ResourceVersion
+
+	return nil
+}
+
+// planConfigMapName returns the name of the ConfigMap in which the plan of the ControlPlaneMachineSet is published.
+func planConfigMapName(cpms *machinev1.ControlPlaneMachineSet) string {
+	return cpms.Name + planConfigMapSuffix
+}
+
+// plannedIndexes describes the Machines observed within each index, sorted by index.
+func plannedIndexes(machineInfos map[int32][]machineproviders.MachineInfo) []plannedIndex {
+	indexes := []plannedIndex{}
+
+	for _, indexToMachines := range sortMachineInfosByIndex(machineInfos) {
+		machines := []plannedMachine{}
+
+		for _, machine := range indexToMachines.machineInfos {
+			if machine.MachineRef == nil {
+				continue
+			}
+
+			machines = append(machines, plannedMachine{
+				Name:        machine.MachineRef.ObjectMeta.Name,
+				Ready:       machine.Ready,
+				NeedsUpdate: machine.NeedsUpdate,
+				Deleting:    isDeletedMachine(machine),
+				Diff:        machine.Diff,
+				IgnoredDiff: machine.IgnoredDiff,
+			})
+		}
+
+		indexes = append(indexes, plannedIndex{
+			Index:    indexToMachines.index,
+			Machines: machines,
+		})
+	}
+
+	return indexes
+}
@@ -0,0 +1,210 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"encoding/json"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	machinev1 "github.com/openshift/api/machine/v1"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/cluster-api-actuator-pkg/testutils"
+	corev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/core/v1"
+	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/mock"
+	machineprovidersresourcebuilder "github.com/openshift/cluster-control-plane-machine-set-operator/pkg/test/resourcebuilder/machineproviders"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest/komega"
+)
+
+var _ = Describe("Dry-run plan", func() {
+	var namespaceName string
+	var logger testutils.TestLogger
+	var reconciler *ControlPlaneMachineSetReconciler
+	var cpms *machinev1.ControlPlaneMachineSet
+
+	var mockCtrl *gomock.Controller
+	var mockMachineProvider *mock.MockMachineProvider
+
+	machineGVR := machinev1beta1.GroupVersion.WithResource("machines")
+
+	updatedMachineBuilder := machineprovidersresourcebuilder.MachineInfo().
+		WithMachineGVR(machineGVR).
+		WithReady(true).
+		WithNeedsUpdate(false)
+
+	outdatedMachineBuilder := updatedMachineBuilder.
+		WithNeedsUpdate(true).
+		WithDiff([]string{"InstanceType: m6i.xlarge != m6i.2xlarge"})
+
+	getPlan := func() (machinePlan, error) {
+		configMap := &corev1.ConfigMap{}
+		if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: namespaceName, Name: planConfigMapName(cpms)}, configMap); err != nil {
+			return machinePlan{}, err
+		}
+
+		plan := machinePlan{}
+		Expect(json.Unmarshal([]byte(configMap.Data[planDataKey]), &plan)).To(Succeed())
+
+		return plan, nil
+	}
+
+	BeforeEach(func() {
+		By("Setting up a namespace for the test")
+		ns := corev1resourcebuilder.Namespace().WithGenerateName("control-plane-machine-set-plan-").Build()
+		Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+		namespaceName = ns.GetName()
+
+		logger = testutils.NewTestLogger()
+		reconciler = &ControlPlaneMachineSetReconciler{
+			Client:         k8sClient,
+			UncachedClient: k8sClient,
+			Scheme:         testScheme,
+			Namespace:      namespaceName,
+		}
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockMachineProvider = mock.NewMockMachineProvider(mockCtrl)
+
+		By("Creating the control plane machine set")
+		cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithNamespace(namespaceName).WithName(clusterControlPlaneMachineSetName).
+			WithStrategyType(machinev1.RollingUpdate).Build()
+		Expect(k8sClient.Create(ctx, cpms)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		testutils.CleanupResources(Default, ctx, cfg, k8sClient, namespaceName,
+			&corev1.ConfigMap{},
+			&machinev1.ControlPlaneMachineSet{},
+		)
+	})
+
+	Context("reconcileDryRun", func() {
+		var machineInfos map[int32][]machineproviders.MachineInfo
+
+		Context("with an outdated machine", func() {
+			BeforeEach(func() {
+				machineInfos = map[int32][]machineproviders.MachineInfo{
+					0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").Build()},
+					1: {outdatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").Build()},
+					2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").Build()},
+				}
+
+				mockMachineProvider.EXPECT().WithClient(gomock.Any(), gomock.Any(), gomock.Any()).Return(mockMachineProvider, nil).AnyTimes()
+				mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(machineInfos), nil).AnyTimes()
+				mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+				_, err := reconciler.reconcileDryRun(ctx, logger.Logger(), cpms, mockMachineProvider, machineInfos)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("publishes the replacement machine that would be created", func() {
+				Expect(getPlan()).To(SatisfyAll(
+					HaveField("Strategy", machinev1.RollingUpdate),
+					HaveField("Actions", ConsistOf(plannedAction{
+						Action: planActionCreate,
+						Index:  1,
+						Diff:   []string{"InstanceType: m6i.xlarge != m6i.2xlarge"},
+					})),
+				))
+			})
+
+			It("publishes the machines within each index", func() {
+				Expect(getPlan()).To(HaveField("Indexes", ConsistOf(
+					plannedIndex{Index: 0, Machines: []plannedMachine{{Name: "machine-0", Ready: true}}},
+					plannedIndex{Index: 1, Machines: []plannedMachine{{Name: "machine-1", Ready: true, NeedsUpdate: true, Diff: []string{"InstanceType: m6i.xlarge != m6i.2xlarge"}}}},
+					plannedIndex{Index: 2, Machines: []plannedMachine{{Name: "machine-2", Ready: true}}},
+				)))
+			})
+
+			It("records that the plan was published on the control plane machine set", func() {
+				Eventually(komega.Object(cpms)).Should(HaveField("ObjectMeta.Annotations", HaveKey(planPublishedAnnotation)))
+			})
+
+			It("sets the control plane machine set as the owner of the plan", func() {
+				configMap := &corev1.ConfigMap{}
+				Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: namespaceName, Name: planConfigMapName(cpms)}, configMap)).To(Succeed())
+
+				Expect(configMap.OwnerReferences).To(ConsistOf(HaveField("UID", cpms.UID)))
+			})
+
+			Context("and the dry-run plan mode is disabled", func() {
+				BeforeEach(func() {
+					Expect(reconciler.removePlan(ctx, logger.Logger(), cpms)).To(Succeed())
+				})
+
+				It("removes the plan", func() {
+					_, err := getPlan()
+					Expect(apierrors.IsNotFound(err)).To(BeTrue())
+				})
+
+				It("removes the plan published annotation from the control plane machine set", func() {
+					Eventually(komega.Object(cpms)).ShouldNot(HaveField("ObjectMeta.Annotations", HaveKey(planPublishedAnnotation)))
+				})
+			})
+		})
+
+		Context("when no plan has been published", func() {
+			BeforeEach(func() {
+				Expect(k8sClient.Create(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: namespaceName, Name: planConfigMapName(cpms)}})).To(Succeed())
+
+				Expect(reconciler.removePlan(ctx, logger.Logger(), cpms)).To(Succeed())
+			})
+
+			It("does not remove the ConfigMap", func() {
+				Consistently(komega.Get(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: namespaceName, Name: planConfigMapName(cpms)}})).Should(Succeed())
+			})
+		})
+
+		Context("with an outdated machine that has been replaced", func() {
+			BeforeEach(func() {
+				machineInfos = map[int32][]machineproviders.MachineInfo{
+					0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").Build()},
+					1: {
+						outdatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").Build(),
+						updatedMachineBuilder.WithIndex(1).WithMachineName("machine-replacement-1").Build(),
+					},
+					2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").Build()},
+				}
+
+				mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+				_, err := reconciler.reconcileDryRun(ctx, logger.Logger(), cpms, mockMachineProvider, machineInfos)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("publishes the outdated machine that would be deleted", func() {
+				Expect(getPlan()).To(HaveField("Actions", ConsistOf(plannedAction{
+					Action:      planActionDelete,
+					Index:       1,
+					MachineName: "machine-1",
+					Diff:        []string{"InstanceType: m6i.xlarge != m6i.2xlarge"},
+				})))
+			})
+		})
+	})
+})
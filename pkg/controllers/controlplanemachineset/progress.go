@@ -0,0 +1,428 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// progressDeadlineExceeded is a log message used to inform the user that a replacement Machine has not become
+	// Ready within the progress deadline, and that no further attempts will be made to replace it.
+	progressDeadlineExceeded = "Replacement machine has exceeded the progress deadline"
+
+	// retryingStuckReplacement is a log message used to inform the user that a replacement Machine has not become
+	// Ready within the progress deadline, and that it is being deleted so that it can be created again.
+	retryingStuckReplacement = "Replacement machine has exceeded the progress deadline, deleting it to retry"
+
+	// retryingFailedReplacement is a log message used to inform the user that a replacement Machine has reported
+	// an error, and that it is being deleted so that it can be created again.
+	retryingFailedReplacement = "Replacement machine has failed, deleting it to retry"
+
+	// waitingForReplacementBackoff is a log message used to inform the user that a replacement Machine will not be
+	// created until the backoff following a previously failed replacement has elapsed.
+	waitingForReplacementBackoff = "Waiting for backoff before creating a new replacement machine"
+
+	// progressDeadlineExceededMessage is the message used in the Degraded condition when a replacement Machine has
+	// exceeded the progress deadline.
+	progressDeadlineExceededMessage = "Replacement machine %s in index %d has been pending for %s, exceeding the progress deadline of %s"
+
+	// baseReplacementBackoff is the backoff applied before the first retry of a failed replacement Machine.
+	// The backoff doubles with each subsequent attempt for the same index, whichever policy retried it.
+	baseReplacementBackoff = 30 * time.Second
+
+	// maxReplacementBackoff is the maximum backoff applied before retrying a failed replacement Machine.
+	maxReplacementBackoff = 10 * time.Minute
+)
+
+var (
+	// errProgressDeadlineExceeded is used to inform users that a replacement Machine has not become Ready within
+	// the progress deadline.
+	errProgressDeadlineExceeded = errors.New("progress deadline exceeded")
+)
+
+// replacementAttempts tracks the failed attempts to replace the Machine in an index.
+// The progress deadline retries and the failed replacement retries are counted separately, so that each is only
+// limited by its own number of retries.
+type replacementAttempts struct {
+	// stuck is the number of replacement Machines exceeding the progress deadline that have been deleted for the index.
+	stuck int
+
+	// failed is the number of replacement Machines reporting an error that have been deleted for the index.
+	failed int
+
+	// lastAttempt is the time at which the last failed replacement Machine was deleted.
+	lastAttempt time.Time
+}
+
+// count returns the number of failed replacement Machines that have been deleted for the index, by either policy.
+func (a replacementAttempts) count() int {
+	return a.stuck + a.failed
+}
+
+// persistedReplacementAttempts is the form in which the replacementAttempts of an index are persisted within the
+// replacement attempts annotation.
+type persistedReplacementAttempts struct {
+	// Stuck is the number of replacement Machines exceeding the progress deadline that have been deleted for the index.
+	Stuck int `json:"stuck,omitempty"`
+
+	// Failed is the number of replacement Machines reporting an error that have been deleted for the index.
+	Failed int `json:"failed,omitempty"`
+
+	// LastAttempt is the time at which the last failed replacement Machine was deleted.
+	LastAttempt time.Time `json:"lastAttempt"`
+}
+
+// reconcileProgressDeadline checks whether any pending replacement Machine has exceeded the progress deadline
+// configured on the ControlPlaneMachineSet.
+// When retries are configured, the stuck replacement Machine is deleted so that a new replacement may be created
+// once the backoff has elapsed. Once the retries are exhausted, the ControlPlaneMachineSet is marked as degraded and
+// no further updates are attempted.
+func (r *ControlPlaneMachineSetReconciler) reconcileProgressDeadline(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, machineProvider machineproviders.MachineProvider, machineInfos map[int32][]machineproviders.MachineInfo) (bool, ctrl.Result, error) {
+	r.resetReplacementAttempts(machineInfos)
+
+	progressDeadline, retries, err := getProgressDeadlineParameters(cpms)
+	if err != nil {
+		setInvalidAnnotationDegraded(logger, cpms, err)
+
+		// Do not return an error here as the annotation will need user intervention to resolve.
+		return true, ctrl.Result{}, nil
+	}
+
+	if progressDeadline == 0 {
+		return false, ctrl.Result{}, nil
+	}
+
+	now := time.Now()
+
+	for _, indexToMachines := range sortMachineInfosByIndex(machineInfos) {
+		stuckMachine, ok := stuckReplacementMachine(indexToMachines.machineInfos, progressDeadline, now)
+		if !ok {
+			continue
+		}
+
+		pendingFor := now.Sub(stuckMachine.MachineRef.ObjectMeta.CreationTimestamp.Time).Round(time.Second)
+		logger := logger.WithValues("index", indexToMachines.index, "namespace", r.Namespace, "name", stuckMachine.MachineRef.ObjectMeta.Name)
+
+		if attempts := r.replacementAttempts[indexToMachines.index]; attempts.stuck < retries {
+			if nextWindow, heldBack := heldBackByMaintenanceWindows(cpms, now); heldBack {
+				logger.V(2).WithValues("nextMaintenanceWindow", nextWindow).Info(changeHeldBackByMaintenanceWindow)
+
+				continue
+			}
+
+			logger.V(2).WithValues("pendingFor", pendingFor.String(), "attempt", attempts.stuck+1).Info(retryingStuckReplacement)
+
+			if result, err := deleteMachine(ctx, logger, machineProvider, stuckMachine, r.Namespace); err != nil {
+				return true, result, err
+			}
+
+			r.recordStuckReplacement(indexToMachines.index, now)
+
+			continue
+		}
+
+		message := fmt.Sprintf(progressDeadlineExceededMessage, stuckMachine.MachineRef.ObjectMeta.Name, indexToMachines.index, pendingFor, progressDeadline)
+
+		meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+			Type:    conditionDegraded,
+			Status:  metav1.ConditionTrue,
+			Reason:  reasonProgressDeadlineExceeded,
+			Message: message,
+		})
+
+		logger.Error(fmt.Errorf("%w: %s", errProgressDeadlineExceeded, message), progressDeadlineExceeded)
+
+		// Do not return an error here as the stuck Machine will need user intervention to resolve.
+		return true, ctrl.Result{}, nil
+	}
+
+	return false, ctrl.Result{}, nil
+}
+
+// reconcileFailedReplacements deletes any replacement Machine which has reported an error, when retries of failed
+// replacements have been configured on the ControlPlaneMachineSet and the retries for the index have not been
+// exhausted. A new replacement Machine is then created by the update strategy once the backoff has elapsed.
+// Once the retries are exhausted, the failed replacement is reported via the Degraded condition when validating the
+// cluster state.
+func (r *ControlPlaneMachineSetReconciler) reconcileFailedReplacements(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, machineProvider machineproviders.MachineProvider, machineInfos map[int32][]machineproviders.MachineInfo) (bool, ctrl.Result, error) {
+	retries, err := getFailedReplacementRetries(cpms)
+	if err != nil {
+		setInvalidAnnotationDegraded(logger, cpms, err)
+
+		// Do not return an error here as the annotation will need user intervention to resolve.
+		return true, ctrl.Result{}, nil
+	}
+
+	if retries == 0 {
+		return false, ctrl.Result{}, nil
+	}
+
+	now := time.Now()
+
+	for _, indexToMachines := range sortMachineInfosByIndex(machineInfos) {
+		failedMachines := erroredReplacementMachines(indexToMachines.machineInfos)
+		if isEmpty(failedMachines) || r.replacementAttempts[indexToMachines.index].failed >= retries {
+			continue
+		}
+
+		failedMachine := failedMachines[0]
+		logger := logger.WithValues("index", indexToMachines.index, "namespace", r.Namespace, "name", failedMachine.MachineRef.ObjectMeta.Name)
+
+		if nextWindow, heldBack := heldBackByMaintenanceWindows(cpms, now); heldBack {
+			logger.V(2).WithValues("nextMaintenanceWindow", nextWindow).Info(changeHeldBackByMaintenanceWindow)
+
+			continue
+		}
+		logger.V(2).WithValues("errorMessage", failedMachine.ErrorMessage, "attempt", r.replacementAttempts[indexToMachines.index].failed+1).Info(retryingFailedReplacement)
+
+		if result, err := deleteMachine(ctx, logger, machineProvider, failedMachine, r.Namespace); err != nil {
+			return true, result, err
+		}
+
+		r.recordFailedReplacement(indexToMachines.index, now)
+	}
+
+	return false, ctrl.Result{}, nil
+}
+
+// canRetryFailedReplacement determines whether a failed replacement Machine in the index will be retried.
+func (r *ControlPlaneMachineSetReconciler) canRetryFailedReplacement(cpms *machinev1.ControlPlaneMachineSet, idx int32) bool {
+	retries, err := getFailedReplacementRetries(cpms)
+	if err != nil {
+		// An invalid value is reported when reconciling the failed replacements, no retries are allowed.
+		return false
+	}
+
+	return r.replacementAttempts[idx].failed < retries
+}
+
+// erroredReplacementMachines returns the pending replacement Machines which have reported an error, in an index which
+// still has a Machine in need of replacement.
+func erroredReplacementMachines(machinesInfo []machineproviders.MachineInfo) []machineproviders.MachineInfo {
+	result := []machineproviders.MachineInfo{}
+
+	if isEmpty(needReplacementMachines(machinesInfo)) {
+		return result
+	}
+
+	for _, m := range pendingMachines(machinesInfo) {
+		if m.ErrorMessage != "" {
+			result = append(result, m)
+		}
+	}
+
+	return result
+}
+
+// getProgressDeadlineParameters returns the progress deadline and the number of retries configured on the
+// ControlPlaneMachineSet.
+func getProgressDeadlineParameters(cpms *machinev1.ControlPlaneMachineSet) (time.Duration, int, error) {
+	progressDeadline, err := getProgressDeadline(cpms)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	retries, err := getProgressDeadlineRetries(cpms)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return progressDeadline, retries, nil
+}
+
+// stuckReplacementMachine returns the first pending Machine in the index which has never become Ready and was created
+// longer ago than the progress deadline, in an index which still has a Machine in need of replacement.
+// A Machine with a Node has joined the cluster, so may have been Ready before and only briefly become NotReady.
+// Such a Machine is not considered stuck, as deleting it may remove a healthy etcd member.
+func stuckReplacementMachine(machinesInfo []machineproviders.MachineInfo, progressDeadline time.Duration, now time.Time) (machineproviders.MachineInfo, bool) {
+	if isEmpty(needReplacementMachines(machinesInfo)) {
+		return machineproviders.MachineInfo{}, false
+	}
+
+	for _, machine := range sortMachineInfoByCreationTimestamp(pendingMachines(machinesInfo)) {
+		if machine.NodeRef == nil && now.Sub(machine.MachineRef.ObjectMeta.CreationTimestamp.Time) > progressDeadline {
+			return machine, true
+		}
+	}
+
+	return machineproviders.MachineInfo{}, false
+}
+
+// resetReplacementAttempts stops tracking the failed replacement attempts of any index which now has
+// an Updated Machine, or which is no longer tracked by the machine provider.
+func (r *ControlPlaneMachineSetReconciler) resetReplacementAttempts(machineInfos map[int32][]machineproviders.MachineInfo) {
+	for idx := range r.replacementAttempts {
+		if machines, ok := machineInfos[idx]; !ok || hasAny(updatedMachines(machines)) {
+			delete(r.replacementAttempts, idx)
+		}
+	}
+}
+
+// recordStuckReplacement records that a replacement Machine exceeding the progress deadline has been deleted for
+// the index.
+func (r *ControlPlaneMachineSetReconciler) recordStuckReplacement(idx int32, now time.Time) {
+	attempts := r.replacementAttempts[idx]
+	attempts.stuck++
+	attempts.lastAttempt = now
+
+	r.setReplacementAttempts(idx, attempts)
+}
+
+// recordFailedReplacement records that a replacement Machine reporting an error has been deleted for the index.
+func (r *ControlPlaneMachineSetReconciler) recordFailedReplacement(idx int32, now time.Time) {
+	attempts := r.replacementAttempts[idx]
+	attempts.failed++
+	attempts.lastAttempt = now
+
+	r.setReplacementAttempts(idx, attempts)
+}
+
+// setReplacementAttempts sets the failed replacement attempts of the index.
+func (r *ControlPlaneMachineSetReconciler) setReplacementAttempts(idx int32, attempts replacementAttempts) {
+	if r.replacementAttempts == nil {
+		r.replacementAttempts = make(map[int32]replacementAttempts)
+	}
+
+	r.replacementAttempts[idx] = attempts
+}
+
+// replacementBackoff returns the remaining duration before a new replacement Machine may be created for the index.
+// The backoff doubles for each failed replacement Machine, up to the maximum replacement backoff.
+func (r *ControlPlaneMachineSetReconciler) replacementBackoff(idx int32, now time.Time) time.Duration {
+	attempts, ok := r.replacementAttempts[idx]
+	if !ok || attempts.count() == 0 {
+		return 0
+	}
+
+	backoff := baseReplacementBackoff
+	for i := 1; i < attempts.count() && backoff < maxReplacementBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > maxReplacementBackoff {
+		backoff = maxReplacementBackoff
+	}
+
+	if remaining := attempts.lastAttempt.Add(backoff).Sub(now); remaining > 0 {
+		return remaining
+	}
+
+	return 0
+}
+
+// nextReplacementBackoff returns the shortest remaining backoff across all indexes.
+// This is used to requeue the ControlPlaneMachineSet so that the replacement can be created once the backoff has elapsed.
+func (r *ControlPlaneMachineSetReconciler) nextReplacementBackoff(now time.Time) time.Duration {
+	var next time.Duration
+
+	for idx := range r.replacementAttempts {
+		if remaining := r.replacementBackoff(idx, now); remaining > 0 && (next == 0 || remaining < next) {
+			next = remaining
+		}
+	}
+
+	return next
+}
+
+// getReplacementAttempts returns the failed replacement attempts persisted on the ControlPlaneMachineSet.
+// The annotation is only written by the controller, so an invalid value is discarded rather than reported.
+func getReplacementAttempts(cpms *machinev1.ControlPlaneMachineSet) map[int32]replacementAttempts {
+	value, ok := cpms.Annotations[replacementAttemptsAnnotation]
+	if !ok {
+		return nil
+	}
+
+	persisted := map[int32]persistedReplacementAttempts{}
+	if err := json.Unmarshal([]byte(value), &persisted); err != nil {
+		return nil
+	}
+
+	attempts := make(map[int32]replacementAttempts, len(persisted))
+	for idx, p := range persisted {
+		attempts[idx] = replacementAttempts{stuck: p.Stuck, failed: p.Failed, lastAttempt: p.LastAttempt}
+	}
+
+	return attempts
+}
+
+// loadReplacementAttempts restores the failed replacement attempts persisted on the ControlPlaneMachineSet, so that
+// the retries and backoff of failed replacements are kept across restarts of the controller.
+func (r *ControlPlaneMachineSetReconciler) loadReplacementAttempts(cpms *machinev1.ControlPlaneMachineSet) {
+	r.replacementAttempts = getReplacementAttempts(cpms)
+}
+
+// persistReplacementAttempts records the failed replacement attempts on the ControlPlaneMachineSet, when these have
+// changed since they were loaded. The annotation is removed once no index has any failed replacement attempts.
+// The ControlPlaneMachineSet is patched through a copy so that the status computed earlier in the reconcile is kept.
+func (r *ControlPlaneMachineSetReconciler) persistReplacementAttempts(ctx context.Context, cpms *machinev1.ControlPlaneMachineSet) error {
+	current, hasCurrent := cpms.Annotations[replacementAttemptsAnnotation]
+
+	var desired string
+
+	if len(r.replacementAttempts) > 0 {
+		persisted := make(map[int32]persistedReplacementAttempts, len(r.replacementAttempts))
+		for idx, attempts := range r.replacementAttempts {
+			persisted[idx] = persistedReplacementAttempts{Stuck: attempts.stuck, Failed: attempts.failed, LastAttempt: attempts.lastAttempt}
+		}
+
+		data, err := json.Marshal(persisted)
+		if err != nil {
+			return fmt.Errorf("could not marshal replacement attempts: %w", err)
+		}
+
+		desired = string(data)
+	}
+
+	if (desired == "" && !hasCurrent) || (desired != "" && desired == current) {
+		return nil
+	}
+
+	cpmsCopy := cpms.DeepCopy()
+	patchBase := client.MergeFrom(cpms.DeepCopy())
+
+	if desired == "" {
+		delete(cpmsCopy.Annotations, replacementAttemptsAnnotation)
+	} else {
+		if cpmsCopy.Annotations == nil {
+			cpmsCopy.Annotations = map[string]string{}
+		}
+
+		cpmsCopy.Annotations[replacementAttemptsAnnotation] = desired
+	}
+
+	if err := r.Patch(ctx, cpmsCopy, patchBase); err != nil {
+		return fmt.Errorf("error patching control plane machine set: %w", err)
+	}
+
+	cpms.Annotations = cpmsCopy.Annotations
+	cpms.ResourceVersion = cpmsCopy.ResourceVersion
+
+	return nil
+}
@@ -0,0 +1,411 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	machinev1 "github.com/openshift/api/machine/v1"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/cluster-api-actuator-pkg/testutils"
+	corev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/core/v1"
+	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/mock"
+	machineprovidersresourcebuilder "github.com/openshift/cluster-control-plane-machine-set-operator/pkg/test/resourcebuilder/machineproviders"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("Progress deadline", func() {
+	const namespaceName = "openshift-machine-api"
+
+	var logger testutils.TestLogger
+	var reconciler *ControlPlaneMachineSetReconciler
+
+	var mockCtrl *gomock.Controller
+	var mockMachineProvider *mock.MockMachineProvider
+
+	machineGVR := machinev1beta1.GroupVersion.WithResource("machines")
+
+	updatedMachineBuilder := machineprovidersresourcebuilder.MachineInfo().
+		WithMachineGVR(machineGVR).
+		WithReady(true).
+		WithNeedsUpdate(false)
+
+	pendingMachineBuilder := machineprovidersresourcebuilder.MachineInfo().
+		WithMachineGVR(machineGVR).
+		WithReady(false).
+		WithNeedsUpdate(false)
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+		reconciler = &ControlPlaneMachineSetReconciler{
+			Namespace: namespaceName,
+		}
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockMachineProvider = mock.NewMockMachineProvider(mockCtrl)
+	})
+
+	Context("reconcileProgressDeadline", func() {
+		var cpms *machinev1.ControlPlaneMachineSet
+		var machineInfos map[int32][]machineproviders.MachineInfo
+		var stuckMachine machineproviders.MachineInfo
+
+		BeforeEach(func() {
+			cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithStrategyType(machinev1.RollingUpdate).Build()
+
+			stuckMachine = pendingMachineBuilder.WithIndex(1).WithMachineName("machine-replacement-1").
+				WithMachineCreationTimestamp(metav1.NewTime(time.Now().Add(-2 * time.Hour))).Build()
+
+			machineInfos = map[int32][]machineproviders.MachineInfo{
+				0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").Build()},
+				1: {
+					updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNeedsUpdate(true).Build(),
+					stuckMachine,
+				},
+				2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").Build()},
+			}
+		})
+
+		Context("with no progress deadline", func() {
+			It("does not act on the stuck replacement", func() {
+				mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+				done, result, err := reconciler.reconcileProgressDeadline(ctx, logger.Logger(), cpms, mockMachineProvider, machineInfos)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(done).To(BeFalse())
+				Expect(result).To(Equal(ctrl.Result{}))
+				Expect(cpms.Status.Conditions).To(BeEmpty())
+			})
+		})
+
+		Context("with a progress deadline that has not been exceeded", func() {
+			BeforeEach(func() {
+				cpms.Annotations = map[string]string{progressDeadlineSecondsAnnotation: "86400"}
+			})
+
+			It("does not act on the pending replacement", func() {
+				mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+				done, _, err := reconciler.reconcileProgressDeadline(ctx, logger.Logger(), cpms, mockMachineProvider, machineInfos)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(done).To(BeFalse())
+				Expect(cpms.Status.Conditions).To(BeEmpty())
+			})
+		})
+
+		Context("with a progress deadline that has been exceeded", func() {
+			BeforeEach(func() {
+				cpms.Annotations = map[string]string{progressDeadlineSecondsAnnotation: "3600"}
+			})
+
+			It("sets the degraded condition", func() {
+				mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+				done, result, err := reconciler.reconcileProgressDeadline(ctx, logger.Logger(), cpms, mockMachineProvider, machineInfos)
+				Expect(err).ToNot(HaveOccurred(), "This is a terminal error, returning an error would force a requeue which is not desired")
+				Expect(done).To(BeTrue())
+				Expect(result).To(Equal(ctrl.Result{}))
+
+				Expect(cpms.Status.Conditions).To(ConsistOf(testutils.MatchCondition(metav1.Condition{
+					Type:    conditionDegraded,
+					Status:  metav1.ConditionTrue,
+					Reason:  reasonProgressDeadlineExceeded,
+					Message: fmt.Sprintf(progressDeadlineExceededMessage, "machine-replacement-1", 1, 2*time.Hour, time.Hour),
+				})))
+			})
+
+			Context("and retries are configured", func() {
+				BeforeEach(func() {
+					cpms.Annotations[progressDeadlineRetriesAnnotation] = "1"
+				})
+
+				It("deletes the stuck replacement and records the attempt", func() {
+					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), stuckMachine.MachineRef).Return(nil).Times(1)
+
+					done, _, err := reconciler.reconcileProgressDeadline(ctx, logger.Logger(), cpms, mockMachineProvider, machineInfos)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(done).To(BeFalse())
+					Expect(cpms.Status.Conditions).To(BeEmpty())
+
+					Expect(reconciler.replacementAttempts[1].stuck).To(Equal(1))
+					Expect(reconciler.replacementBackoff(1, time.Now())).To(BeNumerically("~", baseReplacementBackoff, time.Second))
+				})
+
+				It("sets the degraded condition once the retries are exhausted", func() {
+					reconciler.recordStuckReplacement(1, time.Now().Add(-time.Hour))
+
+					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+					done, _, err := reconciler.reconcileProgressDeadline(ctx, logger.Logger(), cpms, mockMachineProvider, machineInfos)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(done).To(BeTrue())
+
+					Expect(cpms.Status.Conditions).To(ConsistOf(testutils.MatchCondition(metav1.Condition{
+						Type:    conditionDegraded,
+						Status:  metav1.ConditionTrue,
+						Reason:  reasonProgressDeadlineExceeded,
+						Message: fmt.Sprintf(progressDeadlineExceededMessage, "machine-replacement-1", 1, 2*time.Hour, time.Hour),
+					})))
+				})
+			})
+
+			Context("with an old, updated machine which is not ready", func() {
+				BeforeEach(func() {
+					cpms.Annotations[progressDeadlineRetriesAnnotation] = "1"
+
+					// The Node of the Machine has joined the cluster, and has since become NotReady.
+					machineInfos[1] = []machineproviders.MachineInfo{
+						pendingMachineBuilder.WithIndex(1).WithMachineName("machine-replacement-1").WithNodeName("node-replacement-1").
+							WithMachineCreationTimestamp(metav1.NewTime(time.Now().Add(-2 * time.Hour))).
+							WithNotReadySince(metav1.NewTime(time.Now().Add(-time.Minute))).Build(),
+					}
+				})
+
+				It("does not act on the machine", func() {
+					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+					done, _, err := reconciler.reconcileProgressDeadline(ctx, logger.Logger(), cpms, mockMachineProvider, machineInfos)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(done).To(BeFalse())
+					Expect(cpms.Status.Conditions).To(BeEmpty())
+					Expect(reconciler.replacementAttempts).To(BeEmpty())
+				})
+
+				Context("and an outdated machine in the index", func() {
+					BeforeEach(func() {
+						machineInfos[1] = append(machineInfos[1], updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNeedsUpdate(true).Build())
+					})
+
+					It("does not act on the machine", func() {
+						mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+						done, _, err := reconciler.reconcileProgressDeadline(ctx, logger.Logger(), cpms, mockMachineProvider, machineInfos)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(done).To(BeFalse())
+						Expect(cpms.Status.Conditions).To(BeEmpty())
+						Expect(reconciler.replacementAttempts).To(BeEmpty())
+					})
+				})
+			})
+		})
+
+		Context("with an invalid progress deadline", func() {
+			BeforeEach(func() {
+				cpms.Annotations = map[string]string{progressDeadlineSecondsAnnotation: "0"}
+			})
+
+			It("sets the degraded condition", func() {
+				done, _, err := reconciler.reconcileProgressDeadline(ctx, logger.Logger(), cpms, mockMachineProvider, machineInfos)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(done).To(BeTrue())
+
+				Expect(cpms.Status.Conditions).To(ConsistOf(testutils.MatchCondition(metav1.Condition{
+					Type:    conditionDegraded,
+					Status:  metav1.ConditionTrue,
+					Reason:  reasonInvalidAnnotation,
+					Message: fmt.Sprintf("%s: %s: %s: %q", invalidAnnotationMessage, errInvalidProgressDeadline, progressDeadlineSecondsAnnotation, "0"),
+				})))
+			})
+		})
+	})
+
+	Context("reconcileFailedReplacements", func() {
+		var cpms *machinev1.ControlPlaneMachineSet
+		var machineInfos map[int32][]machineproviders.MachineInfo
+		var failedMachine machineproviders.MachineInfo
+
+		BeforeEach(func() {
+			cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithStrategyType(machinev1.RollingUpdate).Build()
+
+			failedMachine = pendingMachineBuilder.WithIndex(1).WithMachineName("machine-replacement-1").
+				WithErrorMessage("InsufficientInstanceCapacity").Build()
+
+			machineInfos = map[int32][]machineproviders.MachineInfo{
+				0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").Build()},
+				1: {
+					updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNeedsUpdate(true).Build(),
+					failedMachine,
+				},
+				2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").Build()},
+			}
+		})
+
+		It("does not retry the failed replacement when no retries are configured", func() {
+			mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			done, _, err := reconciler.reconcileFailedReplacements(ctx, logger.Logger(), cpms, mockMachineProvider, machineInfos)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(done).To(BeFalse())
+			Expect(reconciler.canRetryFailedReplacement(cpms, 1)).To(BeFalse())
+		})
+
+		Context("with retries configured", func() {
+			BeforeEach(func() {
+				cpms.Annotations = map[string]string{failedReplacementRetriesAnnotation: "2"}
+			})
+
+			It("deletes the failed replacement and records the attempt", func() {
+				mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), failedMachine.MachineRef).Return(nil).Times(1)
+
+				done, _, err := reconciler.reconcileFailedReplacements(ctx, logger.Logger(), cpms, mockMachineProvider, machineInfos)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(done).To(BeFalse())
+
+				Expect(reconciler.replacementAttempts[1].failed).To(Equal(1))
+				Expect(reconciler.canRetryFailedReplacement(cpms, 1)).To(BeTrue())
+			})
+
+			It("does not count the progress deadline retries towards the failed replacement retries", func() {
+				reconciler.recordStuckReplacement(1, time.Now().Add(-time.Hour))
+				reconciler.recordStuckReplacement(1, time.Now().Add(-time.Hour))
+
+				mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), failedMachine.MachineRef).Return(nil).Times(1)
+
+				done, _, err := reconciler.reconcileFailedReplacements(ctx, logger.Logger(), cpms, mockMachineProvider, machineInfos)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(done).To(BeFalse())
+
+				Expect(reconciler.replacementAttempts[1].stuck).To(Equal(2))
+				Expect(reconciler.replacementAttempts[1].failed).To(Equal(1))
+			})
+
+			It("does not delete the failed replacement once the retries are exhausted", func() {
+				reconciler.recordFailedReplacement(1, time.Now().Add(-time.Hour))
+				reconciler.recordFailedReplacement(1, time.Now().Add(-time.Hour))
+
+				mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+				done, _, err := reconciler.reconcileFailedReplacements(ctx, logger.Logger(), cpms, mockMachineProvider, machineInfos)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(done).To(BeFalse())
+				Expect(reconciler.canRetryFailedReplacement(cpms, 1)).To(BeFalse())
+			})
+		})
+	})
+
+	Context("replacementBackoff", func() {
+		DescribeTable("should double the backoff for each attempt up to the maximum", func(attempts int, expected time.Duration) {
+			now := time.Now()
+
+			for i := 0; i < attempts; i++ {
+				reconciler.recordFailedReplacement(0, now)
+			}
+
+			Expect(reconciler.replacementBackoff(0, now)).To(Equal(expected))
+		},
+			Entry("with no attempts", 0, time.Duration(0)),
+			Entry("with 1 attempt", 1, 30*time.Second),
+			Entry("with 2 attempts", 2, time.Minute),
+			Entry("with 3 attempts", 3, 2*time.Minute),
+			Entry("with 6 attempts", 6, 10*time.Minute),
+			Entry("with 10 attempts", 10, 10*time.Minute),
+		)
+
+		It("should stop tracking attempts once the index has an updated machine", func() {
+			reconciler.recordFailedReplacement(0, time.Now())
+
+			reconciler.resetReplacementAttempts(map[int32][]machineproviders.MachineInfo{
+				0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").Build()},
+			})
+
+			Expect(reconciler.replacementAttempts).To(BeEmpty())
+		})
+	})
+
+	Context("getReplacementAttempts", func() {
+		It("should discard an invalid value", func() {
+			cpms := machinev1resourcebuilder.ControlPlaneMachineSet().Build()
+			cpms.Annotations = map[string]string{replacementAttemptsAnnotation: "invalid"}
+
+			Expect(getReplacementAttempts(cpms)).To(BeEmpty())
+		})
+	})
+
+	Context("persistReplacementAttempts", func() {
+		var testNamespaceName string
+		var cpms *machinev1.ControlPlaneMachineSet
+
+		BeforeEach(func() {
+			By("Setting up a namespace for the test")
+			ns := corev1resourcebuilder.Namespace().WithGenerateName("control-plane-machine-set-progress-").Build()
+			Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+			testNamespaceName = ns.GetName()
+
+			reconciler = &ControlPlaneMachineSetReconciler{
+				Client:    k8sClient,
+				Namespace: testNamespaceName,
+			}
+
+			By("Creating the control plane machine set")
+			cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithNamespace(testNamespaceName).WithName(clusterControlPlaneMachineSetName).Build()
+			Expect(k8sClient.Create(ctx, cpms)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			testutils.CleanupResources(Default, ctx, cfg, k8sClient, testNamespaceName,
+				&machinev1.ControlPlaneMachineSet{},
+			)
+		})
+
+		It("should keep the replacement attempts when the reconciler is rebuilt", func() {
+			lastAttempt := time.Now()
+
+			reconciler.loadReplacementAttempts(cpms)
+			reconciler.recordStuckReplacement(1, lastAttempt)
+			reconciler.recordFailedReplacement(1, lastAttempt)
+			Expect(reconciler.persistReplacementAttempts(ctx, cpms)).To(Succeed())
+			Expect(cpms.Annotations).To(HaveKey(replacementAttemptsAnnotation))
+
+			By("Rebuilding the reconciler")
+			reconciler = &ControlPlaneMachineSetReconciler{
+				Client:    k8sClient,
+				Namespace: testNamespaceName,
+			}
+
+			reloaded := &machinev1.ControlPlaneMachineSet{}
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(cpms), reloaded)).To(Succeed())
+
+			reconciler.loadReplacementAttempts(reloaded)
+			Expect(reconciler.replacementAttempts[1].stuck).To(Equal(1))
+			Expect(reconciler.replacementAttempts[1].failed).To(Equal(1))
+			Expect(reconciler.replacementAttempts[1].lastAttempt).To(BeTemporally("==", lastAttempt))
+			Expect(reconciler.replacementBackoff(1, lastAttempt)).To(Equal(2 * baseReplacementBackoff))
+		})
+
+		It("should remove the annotation once there are no replacement attempts", func() {
+			reconciler.recordFailedReplacement(1, time.Now())
+			Expect(reconciler.persistReplacementAttempts(ctx, cpms)).To(Succeed())
+
+			reconciler.resetReplacementAttempts(map[int32][]machineproviders.MachineInfo{
+				1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").Build()},
+			})
+			Expect(reconciler.persistReplacementAttempts(ctx, cpms)).To(Succeed())
+
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(cpms), cpms)).To(Succeed())
+			Expect(cpms.Annotations).ToNot(HaveKey(replacementAttemptsAnnotation))
+		})
+	})
+})
@@ -0,0 +1,343 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	machinev1 "github.com/openshift/api/machine/v1"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/util"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// etcdQuorumHookName is the name of the pre-drain lifecycle hook added to control plane Machines by the etcd
+	// operator. The etcd operator removes the hook once the etcd member of the Machine has been removed from the
+	// etcd cluster.
+	etcdQuorumHookName = "EtcdQuorumOperator"
+
+	// remediatingUnhealthyMachine is a log message used to inform the user that a Machine is being remediated as its
+	// Node has not been Ready for longer than the remediation timeout.
+	remediatingUnhealthyMachine = "Remediating machine whose node has not been ready for longer than the remediation timeout"
+
+	// waitingForRemediationReplacement is a log message used to inform the user that the unhealthy Machine will not be
+	// removed until its replacement is Ready and, when known, its etcd member is healthy and voting.
+	waitingForRemediationReplacement = "Waiting for the replacement of the unhealthy machine before removing it"
+
+	// cannotRemediateQuorumAtRisk is a log message used to inform the user that unhealthy Machines are not remediated
+	// as too many indexes are without a Ready Machine for the etcd cluster to safely maintain quorum.
+	cannotRemediateQuorumAtRisk = "Cannot remediate unhealthy machines, too many indexes are unavailable to maintain etcd quorum"
+
+	// removedEtcdQuorumHook is a log message used to inform the user that the etcd lifecycle hook has been removed from
+	// a deleted Machine, whose Node cannot be reached, so that the etcd operator removes its etcd member.
+	removedEtcdQuorumHook = "Removed etcd lifecycle hook from unreachable machine"
+
+	// remediationRequeueAfter is the interval at which the progress of a remediation is checked.
+	// Node readiness and etcd membership do not generate Machine events.
+	remediationRequeueAfter = 5 * time.Second
+)
+
+// errInvalidRemediationNodeNotReadySeconds is used to inform users that the value of the remediation node not ready
+// seconds annotation is not valid.
+var errInvalidRemediationNodeNotReadySeconds = errors.New("remediation node not ready seconds must be a positive integer")
+
+// getRemediationTimeout returns the duration for which the Node of a Machine must not have been Ready before the
+// Machine is remediated. When the annotation is not present, remediation is disabled and 0 is returned.
+func getRemediationTimeout(cpms *machinev1.ControlPlaneMachineSet) (time.Duration, error) {
+	value, ok := cpms.Annotations[remediationNodeNotReadySecondsAnnotation]
+	if !ok {
+		return 0, nil
+	}
+
+	seconds, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || seconds < 1 {
+		return 0, fmt.Errorf("%w: %s: %q", errInvalidRemediationNodeNotReadySeconds, remediationNodeNotReadySecondsAnnotation, value)
+	}
+
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// reconcileRemediation replaces control plane Machines whose Node has not been Ready for longer than the remediation
+// timeout. The unhealthy Machine is marked for replacement, a replacement Machine is created within its index and,
+// once the replacement is Ready and its etcd member, when known, is healthy and voting, the unhealthy Machine is
+// deleted.
+// Only a single index is remediated at a time, and only while the etcd cluster can tolerate the number of indexes
+// without a Ready Machine. While any index is unhealthy, the update strategy is held back.
+// When a deleted unhealthy Machine still holds the etcd lifecycle hook, the hook is removed once the replacement has
+// joined the etcd cluster, as the etcd operator cannot remove the member of a Machine which cannot be reached.
+func (r *ControlPlaneMachineSetReconciler) reconcileRemediation(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, machineProvider machineproviders.MachineProvider, machineInfos map[int32][]machineproviders.MachineInfo) (bool, ctrl.Result, error) {
+	timeout, err := getRemediationTimeout(cpms)
+	if err != nil {
+		setInvalidAnnotationDegraded(logger, cpms, err)
+
+		// Do not return an error here as the annotation will need user intervention to resolve.
+		return true, ctrl.Result{}, nil
+	}
+
+	if timeout == 0 {
+		return false, ctrl.Result{}, nil
+	}
+
+	now := time.Now()
+
+	// The etcd lifecycle hook is removed directly through the client, so must not be removed in dry-run mode.
+	if !r.dryRun {
+		if err := r.removeUnreachableEtcdQuorumHooks(ctx, logger, machineInfos, timeout, now); err != nil {
+			return false, ctrl.Result{}, err
+		}
+	}
+
+	unhealthy := unhealthyIndexes(machineInfos, timeout, now)
+	if len(unhealthy) == 0 {
+		return false, ctrl.Result{}, nil
+	}
+
+	replicas := pointer.Int32Deref(cpms.Spec.Replicas, 0)
+	if unavailable := unavailableIndexes(machineInfos); len(unavailable) > etcdFaultTolerance(replicas) {
+		// The etcd cluster may already have lost quorum, which requires manual recovery.
+		// Neither remediate, nor allow the update strategy to remove any further Machines.
+		logger.V(2).WithValues("unhealthyIndexes", unhealthy, "unavailableIndexes", unavailable).Info(cannotRemediateQuorumAtRisk)
+
+		return true, ctrl.Result{RequeueAfter: remediationRequeueAfter}, nil
+	}
+
+	idx := unhealthy[0]
+	machines := machineInfos[idx]
+	unhealthyMachine := unhealthyMachines(machines, timeout, now)[0]
+	replacements := remediationReplacementMachines(machines, timeout, now)
+
+	logger = logger.WithValues("index", idx, "namespace", r.Namespace, "name", unhealthyMachine.MachineRef.ObjectMeta.Name)
+
+	if nextWindow, heldBack := heldBackByMaintenanceWindows(cpms, now); heldBack && isEmpty(replacements) {
+		// A remediation is only started while a maintenance window is open, a started remediation is completed.
+		logger.V(2).WithValues("nextMaintenanceWindow", nextWindow).Info(changeHeldBackByMaintenanceWindow)
+
+		return true, ctrl.Result{RequeueAfter: nextWindow.Sub(now)}, nil
+	}
+
+	logger.V(2).WithValues("notReadySince", unhealthyMachine.NotReadySince.Time).Info(remediatingUnhealthyMachine)
+
+	if isEmpty(replacements) && !util.IsMarkedForReplacement(&unhealthyMachine.MachineRef.ObjectMeta) && !r.dryRun {
+		// Marking the Machine ensures that it is no longer considered up to date,
+		// so that it is not mistaken for its own replacement.
+		// In dry-run mode, the Machine is not marked and the replacement is planned straight away.
+		if err := r.markMachineForReplacement(ctx, logger, unhealthyMachine); err != nil {
+			return true, ctrl.Result{}, err
+		}
+
+		// Requeue so that the marked Machine is observed as in need of update.
+		return true, ctrl.Result{Requeue: true}, nil
+	}
+
+	if isEmpty(replacements) {
+		if _, result, err := r.createMachine(ctx, logger, machineProvider, idx); err != nil {
+			return true, result, err
+		}
+
+		return true, ctrl.Result{RequeueAfter: remediationRequeueAfter}, nil
+	}
+
+	if !isRemediationReplacementReady(replacements) {
+		logger.V(2).WithValues("replacementName", replacements[0].MachineRef.ObjectMeta.Name).Info(waitingForRemediationReplacement)
+
+		return true, ctrl.Result{RequeueAfter: remediationRequeueAfter}, nil
+	}
+
+	result, err := deleteMachine(ctx, logger, machineProvider, unhealthyMachine, r.Namespace)
+
+	return true, result, err
+}
+
+// removeUnreachableEtcdQuorumHooks removes the etcd lifecycle hook from deleted Machines whose Node has not been
+// Ready for longer than the remediation timeout, once a replacement within the same index is Ready and its etcd
+// member, when known, is healthy and voting.
+func (r *ControlPlaneMachineSetReconciler) removeUnreachableEtcdQuorumHooks(ctx context.Context, logger logr.Logger, machineInfos map[int32][]machineproviders.MachineInfo, timeout time.Duration, now time.Time) error {
+	for _, indexToMachines := range sortMachineInfosByIndex(machineInfos) {
+		machines := indexToMachines.machineInfos
+
+		if !isRemediationReplacementReady(remediationReplacementMachines(machines, timeout, now)) {
+			continue
+		}
+
+		for _, machineInfo := range deletingMachines(machines) {
+			if !isNotReadyBeyond(machineInfo, timeout, now) {
+				continue
+			}
+
+			if err := r.removeEtcdQuorumHook(ctx, logger, machineInfo); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// removeEtcdQuorumHook removes the etcd lifecycle hook from the pre-drain hooks of the Machine, if present.
+func (r *ControlPlaneMachineSetReconciler) removeEtcdQuorumHook(ctx context.Context, logger logr.Logger, machineInfo machineproviders.MachineInfo) error {
+	machine := &machinev1beta1.Machine{}
+	key := types.NamespacedName{Namespace: machineInfo.MachineRef.ObjectMeta.Namespace, Name: machineInfo.MachineRef.ObjectMeta.Name}
+
+	if err := r.Client.Get(ctx, key, machine); err != nil {
+		return fmt.Errorf("error getting machine %s: %w", key, err)
+	}
+
+	hooks := []machinev1beta1.LifecycleHook{}
+
+	for _, hook := range machine.Spec.LifecycleHooks.PreDrain {
+		if hook.Name != etcdQuorumHookName {
+			hooks = append(hooks, hook)
+		}
+	}
+
+	if len(hooks) == len(machine.Spec.LifecycleHooks.PreDrain) {
+		return nil
+	}
+
+	patchBase := client.MergeFrom(machine.DeepCopy())
+	machine.Spec.LifecycleHooks.PreDrain = hooks
+
+	if err := r.Client.Patch(ctx, machine, patchBase); err != nil {
+		return fmt.Errorf("error patching machine %s: %w", key, err)
+	}
+
+	logger.V(2).WithValues("index", machineInfo.Index, "namespace", r.Namespace, "name", machine.Name).Info(removedEtcdQuorumHook)
+
+	return nil
+}
+
+// withRemediationRequeue ensures that the ControlPlaneMachineSet is requeued once the Node of the next Machine, which
+// is not Ready, has not been Ready for longer than the remediation timeout.
+func withRemediationRequeue(cpms *machinev1.ControlPlaneMachineSet, machineInfos map[int32][]machineproviders.MachineInfo, result ctrl.Result) ctrl.Result {
+	timeout, err := getRemediationTimeout(cpms)
+	if err != nil || timeout == 0 {
+		return result
+	}
+
+	requeueAfter := nextRemediationTimeout(machineInfosMaptoSlice(machineInfos), timeout, time.Now())
+	if requeueAfter > 0 && (result.RequeueAfter == 0 || requeueAfter < result.RequeueAfter) {
+		result.RequeueAfter = requeueAfter
+	}
+
+	return result
+}
+
+// nextRemediationTimeout returns the duration until the Node of the next Machine, which is not Ready, has not been
+// Ready for longer than the remediation timeout. When no Machine will exceed the timeout, 0 is returned.
+func nextRemediationTimeout(machineInfos []machineproviders.MachineInfo, timeout time.Duration, now time.Time) time.Duration {
+	var next time.Duration
+
+	for _, m := range machineInfos {
+		if m.MachineRef == nil || m.NotReadySince == nil || isDeletedMachine(m) || isNotReadyBeyond(m, timeout, now) {
+			continue
+		}
+
+		// The timeout must have been exceeded, not just reached, for the Machine to be remediated.
+		untilTimeout := m.NotReadySince.Add(timeout).Sub(now) + time.Second
+		if next == 0 || untilTimeout < next {
+			next = untilTimeout
+		}
+	}
+
+	return next
+}
+
+// isNotReadyBeyond determines whether the Node of the Machine has not been Ready for longer than the timeout.
+func isNotReadyBeyond(machineInfo machineproviders.MachineInfo, timeout time.Duration, now time.Time) bool {
+	return machineInfo.MachineRef != nil && machineInfo.NotReadySince != nil && now.Sub(machineInfo.NotReadySince.Time) > timeout
+}
+
+// unhealthyMachines returns the non-deleted Machines whose Node has not been Ready for longer than the timeout.
+func unhealthyMachines(machineInfos []machineproviders.MachineInfo, timeout time.Duration, now time.Time) []machineproviders.MachineInfo {
+	result := []machineproviders.MachineInfo{}
+
+	for _, m := range machineInfos {
+		if isNotReadyBeyond(m, timeout, now) && !isDeletedMachine(m) {
+			result = append(result, m)
+		}
+	}
+
+	return result
+}
+
+// remediationReplacementMachines returns the non-deleted Machines which may replace the unhealthy Machines within
+// an index.
+func remediationReplacementMachines(machineInfos []machineproviders.MachineInfo, timeout time.Duration, now time.Time) []machineproviders.MachineInfo {
+	result := []machineproviders.MachineInfo{}
+
+	for _, m := range machineInfos {
+		if m.MachineRef != nil && !isDeletedMachine(m) && !isNotReadyBeyond(m, timeout, now) {
+			result = append(result, m)
+		}
+	}
+
+	return result
+}
+
+// isRemediationReplacementReady determines whether any of the replacement Machines is Ready and, when known, its
+// etcd member is healthy and voting.
+func isRemediationReplacementReady(replacements []machineproviders.MachineInfo) bool {
+	for _, m := range replacements {
+		if m.Ready && (m.EtcdMember == nil || (m.EtcdMember.Voting && m.EtcdMember.Healthy)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// unhealthyIndexes returns the sorted list of indexes containing a non-deleted Machine whose Node has not been Ready
+// for longer than the timeout.
+func unhealthyIndexes(machineInfos map[int32][]machineproviders.MachineInfo, timeout time.Duration, now time.Time) []int32 {
+	result := []int32{}
+
+	for idx, machines := range machineInfos {
+		if hasAny(unhealthyMachines(machines, timeout, now)) {
+			result = append(result, idx)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+
+	return result
+}
+
+// unavailableIndexes returns the sorted list of indexes without a Ready Machine.
+func unavailableIndexes(machineInfos map[int32][]machineproviders.MachineInfo) []int32 {
+	result := []int32{}
+
+	for idx, machines := range machineInfos {
+		if isEmpty(readyMachines(machines)) {
+			result = append(result, idx)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+
+	return result
+}
@@ -0,0 +1,551 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	machinev1 "github.com/openshift/api/machine/v1"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/cluster-api-actuator-pkg/testutils"
+	corev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/core/v1"
+	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
+	machinev1beta1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1beta1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/mock"
+	machineprovidersresourcebuilder "github.com/openshift/cluster-control-plane-machine-set-operator/pkg/test/resourcebuilder/machineproviders"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/util"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("Remediation", func() {
+	const timeoutSeconds = 300
+
+	machineGVR := machinev1beta1.GroupVersion.WithResource("machines")
+
+	healthyMachineBuilder := machineprovidersresourcebuilder.MachineInfo().
+		WithMachineGVR(machineGVR).
+		WithReady(true).
+		WithNeedsUpdate(false)
+
+	notReadyMachineBuilder := machineprovidersresourcebuilder.MachineInfo().
+		WithMachineGVR(machineGVR).
+		WithReady(false).
+		WithNeedsUpdate(false)
+
+	Context("getRemediationTimeout", func() {
+		type getRemediationTimeoutTableInput struct {
+			annotations     map[string]string
+			expectedTimeout time.Duration
+			expectedError   error
+		}
+
+		DescribeTable("should parse the remediation annotation", func(in getRemediationTimeoutTableInput) {
+			cpms := machinev1resourcebuilder.ControlPlaneMachineSet().Build()
+			cpms.Annotations = in.annotations
+
+			timeout, err := getRemediationTimeout(cpms)
+			if in.expectedError != nil {
+				Expect(err).To(MatchError(in.expectedError))
+				return
+			}
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(timeout).To(Equal(in.expectedTimeout))
+		},
+			Entry("with no annotation", getRemediationTimeoutTableInput{
+				expectedTimeout: 0,
+			}),
+			Entry("with a valid timeout", getRemediationTimeoutTableInput{
+				annotations:     map[string]string{remediationNodeNotReadySecondsAnnotation: "300"},
+				expectedTimeout: 5 * time.Minute,
+			}),
+			Entry("with a zero timeout", getRemediationTimeoutTableInput{
+				annotations:   map[string]string{remediationNodeNotReadySecondsAnnotation: "0"},
+				expectedError: fmt.Errorf("%w: %s: %q", errInvalidRemediationNodeNotReadySeconds, remediationNodeNotReadySecondsAnnotation, "0"),
+			}),
+			Entry("with a non-numeric timeout", getRemediationTimeoutTableInput{
+				annotations:   map[string]string{remediationNodeNotReadySecondsAnnotation: "5m"},
+				expectedError: fmt.Errorf("%w: %s: %q", errInvalidRemediationNodeNotReadySeconds, remediationNodeNotReadySecondsAnnotation, "5m"),
+			}),
+		)
+	})
+
+	Context("nextRemediationTimeout", func() {
+		now := time.Date(2023, time.March, 1, 0, 0, 0, 0, time.UTC)
+		timeout := 5 * time.Minute
+
+		type nextRemediationTimeoutTableInput struct {
+			machineInfos []machineproviders.MachineInfo
+			expected     time.Duration
+		}
+
+		DescribeTable("should return the duration until the next Machine exceeds the remediation timeout", func(in nextRemediationTimeoutTableInput) {
+			Expect(nextRemediationTimeout(in.machineInfos, timeout, now)).To(Equal(in.expected))
+		},
+			Entry("with only Ready Machines", nextRemediationTimeoutTableInput{
+				machineInfos: []machineproviders.MachineInfo{
+					healthyMachineBuilder.WithMachineName("machine-0").Build(),
+				},
+				expected: 0,
+			}),
+			Entry("with Machines which have not been Ready for different durations", nextRemediationTimeoutTableInput{
+				machineInfos: []machineproviders.MachineInfo{
+					notReadyMachineBuilder.WithMachineName("machine-0").WithNotReadySince(metav1.NewTime(now.Add(-time.Minute))).Build(),
+					notReadyMachineBuilder.WithMachineName("machine-1").WithNotReadySince(metav1.NewTime(now.Add(-3 * time.Minute))).Build(),
+				},
+				expected: 2*time.Minute + time.Second,
+			}),
+			Entry("with a Machine which has already exceeded the remediation timeout", nextRemediationTimeoutTableInput{
+				machineInfos: []machineproviders.MachineInfo{
+					notReadyMachineBuilder.WithMachineName("machine-0").WithNotReadySince(metav1.NewTime(now.Add(-10 * time.Minute))).Build(),
+				},
+				expected: 0,
+			}),
+			Entry("with a deleted Machine", nextRemediationTimeoutTableInput{
+				machineInfos: []machineproviders.MachineInfo{
+					notReadyMachineBuilder.WithMachineName("machine-0").WithNotReadySince(metav1.NewTime(now.Add(-time.Minute))).
+						WithMachineDeletionTimestamp(metav1.NewTime(now)).Build(),
+				},
+				expected: 0,
+			}),
+		)
+	})
+
+	Context("reconcileRemediation", func() {
+		var logger testutils.TestLogger
+		var reconciler *ControlPlaneMachineSetReconciler
+
+		var mockCtrl *gomock.Controller
+		var mockMachineProvider *mock.MockMachineProvider
+
+		unhealthySince := metav1.NewTime(time.Now().Add(-10 * time.Minute))
+		recentlyNotReadySince := metav1.NewTime(time.Now().Add(-time.Minute))
+
+		BeforeEach(func() {
+			logger = testutils.NewTestLogger()
+			reconciler = &ControlPlaneMachineSetReconciler{
+				Namespace: "remediation",
+			}
+
+			mockCtrl = gomock.NewController(GinkgoT())
+			mockMachineProvider = mock.NewMockMachineProvider(mockCtrl)
+		})
+
+		type reconcileRemediationTableInput struct {
+			annotations        map[string]string
+			machineInfos       map[int32][]machineproviders.MachineInfo
+			setupMock          func(machineInfos map[int32][]machineproviders.MachineInfo)
+			expectDone         bool
+			expectedResult     ctrl.Result
+			expectedConditions []metav1.Condition
+			expectedLogs       []testutils.LogEntry
+		}
+
+		remediationAnnotations := map[string]string{remediationNodeNotReadySecondsAnnotation: fmt.Sprintf("%d", timeoutSeconds)}
+
+		remediatingLog := testutils.LogEntry{
+			Level: 2,
+			KeysAndValues: []interface{}{
+				"index", int32(1),
+				"namespace", "remediation",
+				"name", "machine-1",
+				"notReadySince", unhealthySince.Time,
+			},
+			Message: remediatingUnhealthyMachine,
+		}
+
+		DescribeTable("should remediate Machines whose Node has not been Ready for longer than the timeout", func(in reconcileRemediationTableInput) {
+			cpms := machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).Build()
+			cpms.Annotations = in.annotations
+
+			if in.setupMock != nil {
+				in.setupMock(in.machineInfos)
+			}
+
+			done, result, err := reconciler.reconcileRemediation(ctx, logger.Logger(), cpms, mockMachineProvider, in.machineInfos)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(done).To(Equal(in.expectDone))
+			Expect(result).To(Equal(in.expectedResult))
+			Expect(logger.Entries()).To(ConsistOf(in.expectedLogs))
+
+			conditionMatchers := []interface{}{}
+			for _, c := range in.expectedConditions {
+				conditionMatchers = append(conditionMatchers, testutils.MatchCondition(c))
+			}
+
+			Expect(cpms.Status.Conditions).To(ConsistOf(conditionMatchers...))
+		},
+			Entry("when remediation is not configured", reconcileRemediationTableInput{
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {healthyMachineBuilder.WithIndex(0).WithMachineName("machine-0").Build()},
+					1: {notReadyMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNotReadySince(unhealthySince).Build()},
+					2: {healthyMachineBuilder.WithIndex(2).WithMachineName("machine-2").Build()},
+				},
+				expectDone:     false,
+				expectedResult: ctrl.Result{},
+			}),
+			Entry("when the remediation annotation is not valid", reconcileRemediationTableInput{
+				annotations: map[string]string{remediationNodeNotReadySecondsAnnotation: "-1"},
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {healthyMachineBuilder.WithIndex(0).WithMachineName("machine-0").Build()},
+				},
+				expectDone:     true,
+				expectedResult: ctrl.Result{},
+				expectedConditions: []metav1.Condition{
+					{
+						Type:    conditionDegraded,
+						Status:  metav1.ConditionTrue,
+						Reason:  reasonInvalidAnnotation,
+						Message: fmt.Sprintf("%s: %s: %s: %q", invalidAnnotationMessage, errInvalidRemediationNodeNotReadySeconds, remediationNodeNotReadySecondsAnnotation, "-1"),
+					},
+				},
+				expectedLogs: []testutils.LogEntry{
+					{
+						Error:   fmt.Errorf("%w: %s: %q", errInvalidRemediationNodeNotReadySeconds, remediationNodeNotReadySecondsAnnotation, "-1"),
+						Message: invalidAnnotationMessage,
+					},
+				},
+			}),
+			Entry("when a Node has not been Ready for less than the timeout", reconcileRemediationTableInput{
+				annotations: remediationAnnotations,
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {healthyMachineBuilder.WithIndex(0).WithMachineName("machine-0").Build()},
+					1: {notReadyMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNotReadySince(recentlyNotReadySince).Build()},
+					2: {healthyMachineBuilder.WithIndex(2).WithMachineName("machine-2").Build()},
+				},
+				expectDone:     false,
+				expectedResult: ctrl.Result{},
+			}),
+			Entry("when an unhealthy Machine, which has been marked for replacement, has no replacement", reconcileRemediationTableInput{
+				annotations: remediationAnnotations,
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {healthyMachineBuilder.WithIndex(0).WithMachineName("machine-0").Build()},
+					1: {notReadyMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNotReadySince(unhealthySince).
+						WithMachineAnnotations(map[string]string{util.ReplaceMachineAnnotation: ""}).WithDiff([]string{"Machine has been marked for replacement"}).Build()},
+					2: {healthyMachineBuilder.WithIndex(2).WithMachineName("machine-2").Build()},
+				},
+				setupMock: func(machineInfos map[int32][]machineproviders.MachineInfo) {
+					mockMachineProvider.EXPECT().WithClient(gomock.Any(), gomock.Any(), gomock.Any()).Return(mockMachineProvider, nil).AnyTimes()
+					mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(machineInfos), nil).AnyTimes()
+					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return(nil).Times(1)
+					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				},
+				expectDone:     true,
+				expectedResult: ctrl.Result{RequeueAfter: remediationRequeueAfter},
+				expectedLogs: []testutils.LogEntry{
+					remediatingLog,
+					{
+						Level: 2,
+						KeysAndValues: []interface{}{
+							"index", int32(1),
+							"namespace", "remediation",
+							"name", "machine-1",
+						},
+						Message: createdReplacement,
+					},
+				},
+			}),
+			Entry("when the replacement of an unhealthy Machine is not yet Ready", reconcileRemediationTableInput{
+				annotations: remediationAnnotations,
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {healthyMachineBuilder.WithIndex(0).WithMachineName("machine-0").Build()},
+					1: {
+						notReadyMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNotReadySince(unhealthySince).Build(),
+						notReadyMachineBuilder.WithIndex(1).WithMachineName("machine-replacement-1").Build(),
+					},
+					2: {healthyMachineBuilder.WithIndex(2).WithMachineName("machine-2").Build()},
+				},
+				setupMock: func(machineInfos map[int32][]machineproviders.MachineInfo) {
+					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				},
+				expectDone:     true,
+				expectedResult: ctrl.Result{RequeueAfter: remediationRequeueAfter},
+				expectedLogs: []testutils.LogEntry{
+					remediatingLog,
+					{
+						Level: 2,
+						KeysAndValues: []interface{}{
+							"index", int32(1),
+							"namespace", "remediation",
+							"name", "machine-1",
+							"replacementName", "machine-replacement-1",
+						},
+						Message: waitingForRemediationReplacement,
+					},
+				},
+			}),
+			Entry("when the etcd member of the replacement is not yet voting", reconcileRemediationTableInput{
+				annotations: remediationAnnotations,
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {healthyMachineBuilder.WithIndex(0).WithMachineName("machine-0").Build()},
+					1: {
+						notReadyMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNotReadySince(unhealthySince).Build(),
+						healthyMachineBuilder.WithIndex(1).WithMachineName("machine-replacement-1").WithEtcdMember(false, true).Build(),
+					},
+					2: {healthyMachineBuilder.WithIndex(2).WithMachineName("machine-2").Build()},
+				},
+				setupMock: func(machineInfos map[int32][]machineproviders.MachineInfo) {
+					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				},
+				expectDone:     true,
+				expectedResult: ctrl.Result{RequeueAfter: remediationRequeueAfter},
+				expectedLogs: []testutils.LogEntry{
+					remediatingLog,
+					{
+						Level: 2,
+						KeysAndValues: []interface{}{
+							"index", int32(1),
+							"namespace", "remediation",
+							"name", "machine-1",
+							"replacementName", "machine-replacement-1",
+						},
+						Message: waitingForRemediationReplacement,
+					},
+				},
+			}),
+			Entry("when the replacement of an unhealthy Machine is Ready", reconcileRemediationTableInput{
+				annotations: remediationAnnotations,
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {healthyMachineBuilder.WithIndex(0).WithMachineName("machine-0").Build()},
+					1: {
+						notReadyMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNotReadySince(unhealthySince).Build(),
+						healthyMachineBuilder.WithIndex(1).WithMachineName("machine-replacement-1").WithEtcdMember(true, true).Build(),
+					},
+					2: {healthyMachineBuilder.WithIndex(2).WithMachineName("machine-2").Build()},
+				},
+				setupMock: func(machineInfos map[int32][]machineproviders.MachineInfo) {
+					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), machineInfos[1][0].MachineRef).Return(nil).Times(1)
+				},
+				expectDone:     true,
+				expectedResult: ctrl.Result{},
+				expectedLogs: []testutils.LogEntry{
+					remediatingLog,
+					{
+						Level: 2,
+						KeysAndValues: []interface{}{
+							"index", int32(1),
+							"namespace", "remediation",
+							"name", "machine-1",
+						},
+						Message: removingOldMachine,
+					},
+				},
+			}),
+			Entry("when too many indexes are unavailable to maintain etcd quorum", reconcileRemediationTableInput{
+				annotations: remediationAnnotations,
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {healthyMachineBuilder.WithIndex(0).WithMachineName("machine-0").Build()},
+					1: {notReadyMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNotReadySince(unhealthySince).Build()},
+					2: {notReadyMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNotReadySince(recentlyNotReadySince).Build()},
+				},
+				setupMock: func(machineInfos map[int32][]machineproviders.MachineInfo) {
+					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				},
+				expectDone:     true,
+				expectedResult: ctrl.Result{RequeueAfter: remediationRequeueAfter},
+				expectedLogs: []testutils.LogEntry{
+					{
+						Level: 2,
+						KeysAndValues: []interface{}{
+							"unhealthyIndexes", []int32{1},
+							"unavailableIndexes", []int32{1, 2},
+						},
+						Message: cannotRemediateQuorumAtRisk,
+					},
+				},
+			}),
+		)
+	})
+
+	Context("with an unhealthy Machine", func() {
+		var namespaceName string
+		var logger testutils.TestLogger
+		var reconciler *ControlPlaneMachineSetReconciler
+		var machine *machinev1beta1.Machine
+
+		timeout := timeoutSeconds * time.Second
+		unhealthySince := metav1.NewTime(time.Now().Add(-10 * time.Minute))
+
+		otherHook := machinev1beta1.LifecycleHook{Name: "other", Owner: "other-controller"}
+
+		BeforeEach(func() {
+			By("Setting up a namespace for the test")
+			ns := corev1resourcebuilder.Namespace().WithGenerateName("control-plane-machine-set-remediation-").Build()
+			Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+			namespaceName = ns.GetName()
+
+			logger = testutils.NewTestLogger()
+			reconciler = &ControlPlaneMachineSetReconciler{
+				Client:         k8sClient,
+				UncachedClient: k8sClient,
+				Scheme:         testScheme,
+				RESTMapper:     testRESTMapper,
+				Namespace:      namespaceName,
+			}
+
+			By("Creating a Machine with the etcd lifecycle hook")
+			machine = machinev1beta1resourcebuilder.Machine().AsMaster().WithNamespace(namespaceName).WithName("machine-1").
+				WithProviderSpecBuilder(machinev1beta1resourcebuilder.AWSProviderSpec()).Build()
+			machine.Spec.LifecycleHooks.PreDrain = []machinev1beta1.LifecycleHook{
+				{Name: etcdQuorumHookName, Owner: "clusteroperator/etcd"},
+				otherHook,
+			}
+			Expect(k8sClient.Create(ctx, machine)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			testutils.CleanupResources(Default, ctx, cfg, k8sClient, namespaceName,
+				&corev1.ConfigMap{},
+				&machinev1beta1.Machine{},
+				&machinev1.ControlPlaneMachineSet{},
+			)
+		})
+
+		unreachableMachineInfo := func() machineproviders.MachineInfo {
+			return notReadyMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithMachineNamespace(namespaceName).
+				WithMachineDeletionTimestamp(metav1.Now()).WithNotReadySince(unhealthySince).Build()
+		}
+
+		It("should mark the unhealthy Machine for replacement", func() {
+			cpms := machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).Build()
+			cpms.Annotations = map[string]string{remediationNodeNotReadySecondsAnnotation: fmt.Sprintf("%d", timeoutSeconds)}
+
+			machineInfos := map[int32][]machineproviders.MachineInfo{
+				0: {healthyMachineBuilder.WithIndex(0).WithMachineName("machine-0").Build()},
+				1: {notReadyMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithMachineNamespace(namespaceName).WithNotReadySince(unhealthySince).Build()},
+				2: {healthyMachineBuilder.WithIndex(2).WithMachineName("machine-2").Build()},
+			}
+
+			done, result, err := reconciler.reconcileRemediation(ctx, logger.Logger(), cpms, nil, machineInfos)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(done).To(BeTrue())
+			Expect(result).To(Equal(ctrl.Result{Requeue: true}))
+
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(machine), machine)).To(Succeed())
+			Expect(machine.Annotations).To(HaveKey(util.ReplaceMachineAnnotation))
+		})
+
+		It("should remove the etcd lifecycle hook once the replacement has joined the etcd cluster", func() {
+			machineInfos := map[int32][]machineproviders.MachineInfo{
+				1: {
+					unreachableMachineInfo(),
+					healthyMachineBuilder.WithIndex(1).WithMachineName("machine-replacement-1").WithEtcdMember(true, true).Build(),
+				},
+			}
+
+			Expect(reconciler.removeUnreachableEtcdQuorumHooks(ctx, logger.Logger(), machineInfos, timeout, time.Now())).To(Succeed())
+
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(machine), machine)).To(Succeed())
+			Expect(machine.Spec.LifecycleHooks.PreDrain).To(ConsistOf(otherHook))
+
+			Expect(logger.Entries()).To(ConsistOf(testutils.LogEntry{
+				Level: 2,
+				KeysAndValues: []interface{}{
+					"index", int32(1),
+					"namespace", namespaceName,
+					"name", "machine-1",
+				},
+				Message: removedEtcdQuorumHook,
+			}))
+		})
+
+		It("should not remove the etcd lifecycle hook while the replacement has not joined the etcd cluster", func() {
+			machineInfos := map[int32][]machineproviders.MachineInfo{
+				1: {
+					unreachableMachineInfo(),
+					healthyMachineBuilder.WithIndex(1).WithMachineName("machine-replacement-1").WithEtcdMember(false, false).Build(),
+				},
+			}
+
+			Expect(reconciler.removeUnreachableEtcdQuorumHooks(ctx, logger.Logger(), machineInfos, timeout, time.Now())).To(Succeed())
+
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(machine), machine)).To(Succeed())
+			Expect(machine.Spec.LifecycleHooks.PreDrain).To(HaveLen(2))
+			Expect(logger.Entries()).To(BeEmpty())
+		})
+
+		Context("in dry-run mode", func() {
+			var cpms *machinev1.ControlPlaneMachineSet
+			var mockMachineProvider *mock.MockMachineProvider
+
+			BeforeEach(func() {
+				By("Creating the control plane machine set")
+				cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithNamespace(namespaceName).WithName(clusterControlPlaneMachineSetName).
+					WithReplicas(3).WithStrategyType(machinev1.RollingUpdate).Build()
+				cpms.Annotations = map[string]string{
+					dryRunAnnotation:                         "",
+					remediationNodeNotReadySecondsAnnotation: fmt.Sprintf("%d", timeoutSeconds),
+				}
+				Expect(k8sClient.Create(ctx, cpms)).To(Succeed())
+
+				mockMachineProvider = mock.NewMockMachineProvider(gomock.NewController(GinkgoT()))
+				mockMachineProvider.EXPECT().WithClient(gomock.Any(), gomock.Any(), gomock.Any()).Return(mockMachineProvider, nil).AnyTimes()
+				mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			})
+
+			It("should not mark the unhealthy Machine for replacement", func() {
+				machineInfos := map[int32][]machineproviders.MachineInfo{
+					0: {healthyMachineBuilder.WithIndex(0).WithMachineName("machine-0").Build()},
+					1: {notReadyMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithMachineNamespace(namespaceName).WithNotReadySince(unhealthySince).Build()},
+					2: {healthyMachineBuilder.WithIndex(2).WithMachineName("machine-2").Build()},
+				}
+				mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(machineInfos), nil).AnyTimes()
+
+				_, err := reconciler.reconcileDryRun(ctx, logger.Logger(), cpms, mockMachineProvider, machineInfos)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(machine), machine)).To(Succeed())
+				Expect(machine.Annotations).ToNot(HaveKey(util.ReplaceMachineAnnotation))
+				Expect(machine.Spec.LifecycleHooks.PreDrain).To(HaveLen(2))
+			})
+
+			It("should not remove the etcd lifecycle hook once the replacement has joined the etcd cluster", func() {
+				machineInfos := map[int32][]machineproviders.MachineInfo{
+					0: {healthyMachineBuilder.WithIndex(0).WithMachineName("machine-0").Build()},
+					1: {
+						unreachableMachineInfo(),
+						healthyMachineBuilder.WithIndex(1).WithMachineName("machine-replacement-1").WithEtcdMember(true, true).Build(),
+					},
+					2: {healthyMachineBuilder.WithIndex(2).WithMachineName("machine-2").Build()},
+				}
+				mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(machineInfos), nil).AnyTimes()
+
+				_, err := reconciler.reconcileDryRun(ctx, logger.Logger(), cpms, mockMachineProvider, machineInfos)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(machine), machine)).To(Succeed())
+				Expect(machine.Annotations).ToNot(HaveKey(util.ReplaceMachineAnnotation))
+				Expect(machine.Spec.LifecycleHooks.PreDrain).To(HaveLen(2))
+			})
+		})
+	})
+})
@@ -0,0 +1,251 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/util"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// markedForReplacement is a log message used to inform the user that a Machine has been marked for replacement
+	// as its index was listed in the replace indexes annotation.
+	markedForReplacement = "Marked machine for replacement"
+
+	// waitingToMarkForReplacement is a log message used to inform the user that the Machines in an index listed in the
+	// replace indexes annotation will not be marked for replacement until the index has no pending or deleting Machines.
+	waitingToMarkForReplacement = "Waiting for index to settle before marking its machines for replacement"
+
+	// waitingForIndexReplacement is a log message used to inform the user that an index listed in the replace indexes
+	// annotation has been marked for replacement, but its replacement Machine is not yet Ready.
+	waitingForIndexReplacement = "Waiting for the replacement of the marked index to be ready"
+
+	// replacedIndex is a log message used to inform the user that the replacement of an index listed in the replace
+	// indexes annotation is Ready, and that the index has been removed from the annotation.
+	replacedIndex = "Replacement of the marked index is ready"
+)
+
+var (
+	// errUnknownReplaceIndex is used to inform users that an index listed in the replace indexes annotation
+	// is not an index of the ControlPlaneMachineSet.
+	errUnknownReplaceIndex = errors.New("unknown index")
+)
+
+// reconcileReplaceIndexes marks the Machines in the indexes listed by the replace indexes annotation for replacement.
+// Marked Machines are reported as in need of update by the machine provider, so that the update strategy replaces them.
+// Indexes with pending or deleting Machines are only marked once the index has settled, so that a replacement
+// Machine is never marked for replacement itself. Marked indexes are recorded on the ControlPlaneMachineSet and an
+// index is only removed from the annotation once it has no Machines in need of replacement and its replacement is Ready.
+func (r *ControlPlaneMachineSetReconciler) reconcileReplaceIndexes(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, machineInfos map[int32][]machineproviders.MachineInfo) (bool, ctrl.Result, error) {
+	indexes, err := getReplaceIndexes(cpms)
+	if err == nil {
+		err = validateReplaceIndexes(indexes, machineInfos)
+	}
+
+	var markedIndexes []int32
+	if err == nil {
+		markedIndexes, err = getMarkedReplaceIndexes(cpms)
+	}
+
+	if err != nil {
+		setInvalidAnnotationDegraded(logger, cpms, err)
+
+		// Do not return an error here as the annotation will need user intervention to resolve.
+		return true, ctrl.Result{}, nil
+	}
+
+	if len(indexes) == 0 && len(markedIndexes) == 0 {
+		return false, ctrl.Result{}, nil
+	}
+
+	marked := make(map[int32]struct{}, len(markedIndexes))
+	for _, idx := range markedIndexes {
+		marked[idx] = struct{}{}
+	}
+
+	remaining := []int32{}
+	remainingMarked := []int32{}
+	newlyMarked := false
+
+	for _, idx := range indexes {
+		machines := machineInfos[idx]
+
+		if _, ok := marked[idx]; ok {
+			if !hasAny(needReplacementMachines(machines)) && hasAny(updatedMachines(machines)) {
+				logger.V(2).WithValues("index", idx).Info(replacedIndex)
+
+				continue
+			}
+
+			logger.V(4).WithValues("index", idx).Info(waitingForIndexReplacement)
+
+			remaining = append(remaining, idx)
+			remainingMarked = append(remainingMarked, idx)
+
+			continue
+		}
+
+		remaining = append(remaining, idx)
+
+		if hasAny(pendingMachines(machines)) || hasAny(deletingMachines(machines)) {
+			logger.V(2).WithValues("index", idx).Info(waitingToMarkForReplacement)
+
+			continue
+		}
+
+		for _, machine := range machines {
+			if err := r.markMachineForReplacement(ctx, logger, machine); err != nil {
+				return false, ctrl.Result{}, err
+			}
+		}
+
+		remainingMarked = append(remainingMarked, idx)
+		newlyMarked = true
+	}
+
+	if err := r.patchReplaceIndexes(ctx, cpms, remaining, remainingMarked); err != nil {
+		return false, ctrl.Result{}, err
+	}
+
+	if newlyMarked {
+		// Requeue so that the marked Machines are observed as in need of update.
+		return true, ctrl.Result{Requeue: true}, nil
+	}
+
+	return false, ctrl.Result{}, nil
+}
+
+// patchReplaceIndexes updates the replace indexes and marked replace indexes annotations on the
+// ControlPlaneMachineSet to the given indexes, removing each annotation when it has no indexes left.
+func (r *ControlPlaneMachineSetReconciler) patchReplaceIndexes(ctx context.Context, cpms *machinev1.ControlPlaneMachineSet, indexes, markedIndexes []int32) error {
+	desired := map[string]string{
+		replaceIndexesAnnotation:       joinIndexes(indexes),
+		markedReplaceIndexesAnnotation: joinIndexes(markedIndexes),
+	}
+
+	cpmsCopy := cpms.DeepCopy()
+	patchBase := client.MergeFrom(cpms.DeepCopy())
+	changed := false
+
+	for annotation, value := range desired {
+		current, hasCurrent := cpmsCopy.Annotations[annotation]
+
+		switch {
+		case value == "" && hasCurrent:
+			delete(cpmsCopy.Annotations, annotation)
+		case value != "" && value != current:
+			if cpmsCopy.Annotations == nil {
+				cpmsCopy.Annotations = map[string]string{}
+			}
+
+			cpmsCopy.Annotations[annotation] = value
+		default:
+			continue
+		}
+
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+
+	if err := r.Patch(ctx, cpmsCopy, patchBase); err != nil {
+		return fmt.Errorf("error patching control plane machine set: %w", err)
+	}
+
+	cpms.Annotations = cpmsCopy.Annotations
+	cpms.ResourceVersion = cpmsCopy.ResourceVersion
+
+	return nil
+}
+
+// joinIndexes formats the indexes as a comma separated list.
+func joinIndexes(indexes []int32) string {
+	fields := make([]string, 0, len(indexes))
+	for _, idx := range indexes {
+		fields = append(fields, strconv.Itoa(int(idx)))
+	}
+
+	return strings.Join(fields, ",")
+}
+
+// markMachineForReplacement adds the replace machine annotation to the Machine, unless it is already present.
+func (r *ControlPlaneMachineSetReconciler) markMachineForReplacement(ctx context.Context, logger logr.Logger, machineInfo machineproviders.MachineInfo) error {
+	if machineInfo.MachineRef == nil || util.IsMarkedForReplacement(&machineInfo.MachineRef.ObjectMeta) {
+		return nil
+	}
+
+	if err := r.annotateMachine(ctx, machineInfo, util.ReplaceMachineAnnotation, ""); err != nil {
+		return err
+	}
+
+	logger.V(2).WithValues("index", machineInfo.Index, "namespace", r.Namespace, "name", machineInfo.MachineRef.ObjectMeta.Name).Info(markedForReplacement)
+
+	return nil
+}
+
+// annotateMachine adds the given annotation, with the given value, to the Machine.
+func (r *ControlPlaneMachineSetReconciler) annotateMachine(ctx context.Context, machineInfo machineproviders.MachineInfo, annotation, value string) error {
+	machineGVK, err := r.RESTMapper.KindFor(machineInfo.MachineRef.GroupVersionResource)
+	if err != nil {
+		return fmt.Errorf("error getting GVK for machine: %w", err)
+	}
+
+	machine := &metav1.PartialObjectMetadata{}
+	machine.SetGroupVersionKind(machineGVK)
+	machine.ObjectMeta = machineInfo.MachineRef.ObjectMeta
+
+	patchBase := client.MergeFrom(machine.DeepCopy())
+
+	annotations := machine.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	annotations[annotation] = value
+	machine.SetAnnotations(annotations)
+
+	if err := r.Client.Patch(ctx, machine, patchBase); err != nil {
+		return fmt.Errorf("error patching machine %s/%s: %w", machine.GetNamespace(), machine.GetName(), err)
+	}
+
+	return nil
+}
+
+// validateReplaceIndexes checks that each index listed in the replace indexes annotation is an index of the
+// ControlPlaneMachineSet.
+func validateReplaceIndexes(indexes []int32, machineInfos map[int32][]machineproviders.MachineInfo) error {
+	for _, idx := range indexes {
+		if _, ok := machineInfos[idx]; !ok {
+			return fmt.Errorf("%w: %s: %d", errUnknownReplaceIndex, replaceIndexesAnnotation, idx)
+		}
+	}
+
+	return nil
+}
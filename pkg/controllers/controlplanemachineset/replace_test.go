@@ -0,0 +1,241 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	machinev1 "github.com/openshift/api/machine/v1"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/cluster-api-actuator-pkg/testutils"
+	corev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/core/v1"
+	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
+	machinev1beta1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1beta1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	machineprovidersresourcebuilder "github.com/openshift/cluster-control-plane-machine-set-operator/pkg/test/resourcebuilder/machineproviders"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/util"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/envtest/komega"
+)
+
+var _ = Describe("Replace indexes", func() {
+	var namespaceName string
+	var logger testutils.TestLogger
+	var reconciler *ControlPlaneMachineSetReconciler
+	var cpms *machinev1.ControlPlaneMachineSet
+
+	var machines map[int32]*machinev1beta1.Machine
+	var machineInfos map[int32][]machineproviders.MachineInfo
+
+	machineGVR := machinev1beta1.GroupVersion.WithResource("machines")
+
+	BeforeEach(func() {
+		By("Setting up a namespace for the test")
+		ns := corev1resourcebuilder.Namespace().WithGenerateName("control-plane-machine-set-replace-").Build()
+		Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+		namespaceName = ns.GetName()
+
+		logger = testutils.NewTestLogger()
+		reconciler = &ControlPlaneMachineSetReconciler{
+			Client:     k8sClient,
+			Scheme:     testScheme,
+			RESTMapper: testRESTMapper,
+			Namespace:  namespaceName,
+		}
+
+		By("Creating the control plane machine set")
+		cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithNamespace(namespaceName).WithName(clusterControlPlaneMachineSetName).Build()
+		Expect(k8sClient.Create(ctx, cpms)).To(Succeed())
+
+		By("Creating machines to mark for replacement")
+		machines = map[int32]*machinev1beta1.Machine{}
+		machineInfos = map[int32][]machineproviders.MachineInfo{}
+		machineBuilder := machinev1beta1resourcebuilder.Machine().WithNamespace(namespaceName)
+
+		for i := int32(0); i < 3; i++ {
+			machine := machineBuilder.WithName(fmt.Sprintf("machine-%d", i)).Build()
+			Expect(k8sClient.Create(ctx, machine)).To(Succeed())
+
+			machines[i] = machine
+			machineInfos[i] = []machineproviders.MachineInfo{
+				machineprovidersresourcebuilder.MachineInfo().WithIndex(i).WithMachineGVR(machineGVR).
+					WithMachineName(machine.GetName()).WithMachineNamespace(namespaceName).Build(),
+			}
+		}
+	})
+
+	AfterEach(func() {
+		testutils.CleanupResources(Default, ctx, cfg, k8sClient, namespaceName,
+			&machinev1beta1.Machine{},
+			&machinev1.ControlPlaneMachineSet{},
+		)
+	})
+
+	Context("reconcileReplaceIndexes", func() {
+		Context("with no replace indexes annotation", func() {
+			It("does not mark any machines for replacement", func() {
+				done, result, err := reconciler.reconcileReplaceIndexes(ctx, logger.Logger(), cpms, machineInfos)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(done).To(BeFalse())
+				Expect(result).To(Equal(ctrl.Result{}))
+
+				for _, machine := range machines {
+					Consistently(komega.Object(machine)).ShouldNot(HaveField("ObjectMeta.Annotations", HaveKey(util.ReplaceMachineAnnotation)))
+				}
+			})
+		})
+
+		Context("with replace indexes", func() {
+			BeforeEach(func() {
+				Eventually(komega.Update(cpms, func() {
+					cpms.Annotations = map[string]string{replaceIndexesAnnotation: "0,2"}
+				})).Should(Succeed())
+			})
+
+			Context("when the indexes have settled", func() {
+				BeforeEach(func() {
+					done, result, err := reconciler.reconcileReplaceIndexes(ctx, logger.Logger(), cpms, machineInfos)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(done).To(BeTrue())
+					Expect(result).To(Equal(ctrl.Result{Requeue: true}))
+				})
+
+				It("marks the machines in the indexes for replacement", func() {
+					Eventually(komega.Object(machines[0])).Should(HaveField("ObjectMeta.Annotations", HaveKey(util.ReplaceMachineAnnotation)))
+					Eventually(komega.Object(machines[2])).Should(HaveField("ObjectMeta.Annotations", HaveKey(util.ReplaceMachineAnnotation)))
+				})
+
+				It("does not mark machines in other indexes", func() {
+					Consistently(komega.Object(machines[1])).ShouldNot(HaveField("ObjectMeta.Annotations", HaveKey(util.ReplaceMachineAnnotation)))
+				})
+
+				It("keeps the indexes in the annotation until they are replaced", func() {
+					Eventually(komega.Object(cpms)).Should(HaveField("ObjectMeta.Annotations", HaveKeyWithValue(replaceIndexesAnnotation, "0,2")))
+				})
+
+				It("records the marked indexes on the control plane machine set", func() {
+					Eventually(komega.Object(cpms)).Should(HaveField("ObjectMeta.Annotations", HaveKeyWithValue(markedReplaceIndexesAnnotation, "0,2")))
+				})
+			})
+
+			Context("when the marked indexes are waiting for their replacement", func() {
+				BeforeEach(func() {
+					Eventually(komega.Update(cpms, func() {
+						cpms.Annotations[markedReplaceIndexesAnnotation] = "0,2"
+					})).Should(Succeed())
+
+					// The marked Machine in index 0 is still in need of replacement, while the marked Machine in
+					// index 2 has been removed before its replacement is Ready.
+					machineInfos[0][0].NeedsUpdate = true
+					machineInfos[2] = []machineproviders.MachineInfo{
+						machineprovidersresourcebuilder.MachineInfo().WithIndex(2).WithMachineGVR(machineGVR).
+							WithMachineName("machine-replacement-2").WithMachineNamespace(namespaceName).WithReady(false).Build(),
+					}
+
+					done, result, err := reconciler.reconcileReplaceIndexes(ctx, logger.Logger(), cpms, machineInfos)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(done).To(BeFalse())
+					Expect(result).To(Equal(ctrl.Result{}))
+				})
+
+				It("does not mark the replacement machines", func() {
+					Consistently(komega.Object(machines[0])).ShouldNot(HaveField("ObjectMeta.Annotations", HaveKey(util.ReplaceMachineAnnotation)))
+				})
+
+				It("keeps the indexes in the annotations", func() {
+					Consistently(komega.Object(cpms)).Should(HaveField("ObjectMeta.Annotations", SatisfyAll(
+						HaveKeyWithValue(replaceIndexesAnnotation, "0,2"),
+						HaveKeyWithValue(markedReplaceIndexesAnnotation, "0,2"),
+					)))
+				})
+			})
+
+			Context("when the marked indexes have been replaced", func() {
+				BeforeEach(func() {
+					Eventually(komega.Update(cpms, func() {
+						cpms.Annotations[markedReplaceIndexesAnnotation] = "0,2"
+					})).Should(Succeed())
+
+					done, result, err := reconciler.reconcileReplaceIndexes(ctx, logger.Logger(), cpms, machineInfos)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(done).To(BeFalse())
+					Expect(result).To(Equal(ctrl.Result{}))
+				})
+
+				It("does not mark the replacement machines", func() {
+					for _, machine := range machines {
+						Consistently(komega.Object(machine)).ShouldNot(HaveField("ObjectMeta.Annotations", HaveKey(util.ReplaceMachineAnnotation)))
+					}
+				})
+
+				It("removes the annotations from the control plane machine set", func() {
+					Eventually(komega.Object(cpms)).Should(HaveField("ObjectMeta.Annotations", SatisfyAll(
+						Not(HaveKey(replaceIndexesAnnotation)),
+						Not(HaveKey(markedReplaceIndexesAnnotation)),
+					)))
+				})
+			})
+
+			Context("when an index has a pending replacement", func() {
+				BeforeEach(func() {
+					machineInfos[2] = append(machineInfos[2], machineprovidersresourcebuilder.MachineInfo().WithIndex(2).WithMachineGVR(machineGVR).
+						WithMachineName("machine-replacement-2").WithMachineNamespace(namespaceName).WithReady(false).Build())
+
+					done, _, err := reconciler.reconcileReplaceIndexes(ctx, logger.Logger(), cpms, machineInfos)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(done).To(BeTrue())
+				})
+
+				It("only marks the machines in the settled index", func() {
+					Eventually(komega.Object(machines[0])).Should(HaveField("ObjectMeta.Annotations", HaveKey(util.ReplaceMachineAnnotation)))
+					Consistently(komega.Object(machines[2])).ShouldNot(HaveField("ObjectMeta.Annotations", HaveKey(util.ReplaceMachineAnnotation)))
+				})
+
+				It("only records the settled index as marked", func() {
+					Eventually(komega.Object(cpms)).Should(HaveField("ObjectMeta.Annotations", SatisfyAll(
+						HaveKeyWithValue(replaceIndexesAnnotation, "0,2"),
+						HaveKeyWithValue(markedReplaceIndexesAnnotation, "0"),
+					)))
+				})
+			})
+		})
+
+		Context("with an unknown index", func() {
+			BeforeEach(func() {
+				cpms.Annotations = map[string]string{replaceIndexesAnnotation: "3"}
+			})
+
+			It("sets the degraded condition", func() {
+				done, _, err := reconciler.reconcileReplaceIndexes(ctx, logger.Logger(), cpms, machineInfos)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(done).To(BeTrue())
+
+				Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionDegraded)).To(SatisfyAll(
+					HaveField("Status", metav1.ConditionTrue),
+					HaveField("Reason", reasonInvalidAnnotation),
+					HaveField("Message", ContainSubstring(errUnknownReplaceIndex.Error())),
+				))
+			})
+		})
+	})
+})
@@ -0,0 +1,249 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/go-logr/logr"
+	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/util"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// revisionHistoryLimit is the number of previous revisions of the ControlPlaneMachineSet template
+	// that are retained to allow a rollback.
+	revisionHistoryLimit = 10
+
+	// createdRevision is a log message used to inform the user that a new revision of the
+	// ControlPlaneMachineSet template has been stored.
+	createdRevision = "Created control plane machine set revision"
+
+	// promotedRevision is a log message used to inform the user that a previous revision of the
+	// ControlPlaneMachineSet template has become the latest revision.
+	promotedRevision = "Promoted control plane machine set revision"
+
+	// rolledBackRevision is a log message used to inform the user that the ControlPlaneMachineSet template
+	// has been restored from a previous revision.
+	rolledBackRevision = "Rolled back control plane machine set to revision"
+)
+
+var (
+	// errRevisionNotFound is used to inform users that the revision requested for a rollback does not exist.
+	errRevisionNotFound = errors.New("revision not found")
+
+	// errInvalidRevision is used to inform users that the revision requested for a rollback is not valid.
+	errInvalidRevision = errors.New("revision must be a positive integer")
+)
+
+// reconcileRevisionHistory ensures that the current ControlPlaneMachineSet template is stored as a revision.
+// Each distinct template is stored as a ControllerRevision owned by the ControlPlaneMachineSet.
+// When the template matches a previous revision, that revision is promoted to become the latest revision.
+// Only the latest revisions, up to the revision history limit, are retained.
+func (r *ControlPlaneMachineSetReconciler) reconcileRevisionHistory(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet) error {
+	hash, err := util.ComputeTemplateHash(cpms.Spec.Template)
+	if err != nil {
+		return fmt.Errorf("could not compute template revision hash: %w", err)
+	}
+
+	revisions, err := r.listRevisions(ctx, cpms)
+	if err != nil {
+		return err
+	}
+
+	var current *appsv1.ControllerRevision
+
+	for i := range revisions {
+		if revisions[i].Labels[util.RevisionHashLabel] == hash {
+			current = &revisions[i]
+		}
+	}
+
+	nextRevision := int64(1)
+	if len(revisions) > 0 {
+		nextRevision = revisions[len(revisions)-1].Revision + 1
+	}
+
+	switch {
+	case current == nil:
+		if err := r.createRevision(ctx, logger, cpms, hash, nextRevision); err != nil {
+			return err
+		}
+	case current.Revision != nextRevision-1:
+		// The template has been returned to a previous revision, make this the latest revision.
+		patchBase := client.MergeFrom(current.DeepCopy())
+		current.Revision = nextRevision
+
+		if err := r.Patch(ctx, current, patchBase); err != nil {
+			return fmt.Errorf("error updating revision %s: %w", current.Name, err)
+		}
+
+		logger.V(2).Info(promotedRevision, "revision", current.Revision, "revisionHash", hash)
+	}
+
+	return r.pruneRevisions(ctx, logger, cpms, hash)
+}
+
+// createRevision stores the ControlPlaneMachineSet template as a new ControllerRevision.
+func (r *ControlPlaneMachineSetReconciler) createRevision(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, hash string, revision int64) error {
+	data, err := json.Marshal(cpms.Spec.Template)
+	if err != nil {
+		return fmt.Errorf("could not marshal control plane machine set template: %w", err)
+	}
+
+	controllerRevision := &appsv1.ControllerRevision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", cpms.Name, hash),
+			Namespace: cpms.Namespace,
+			Labels: map[string]string{
+				util.RevisionHashLabel: hash,
+			},
+		},
+		Data:     runtime.RawExtension{Raw: data},
+		Revision: revision,
+	}
+
+	if err := controllerutil.SetControllerReference(cpms, controllerRevision, r.Scheme); err != nil {
+		return fmt.Errorf("could not set owner reference: %w", err)
+	}
+
+	if err := r.Create(ctx, controllerRevision); err != nil {
+		return fmt.Errorf("error creating revision %s: %w", controllerRevision.Name, err)
+	}
+
+	logger.V(2).Info(createdRevision, "revision", revision, "revisionHash", hash)
+
+	return nil
+}
+
+// pruneRevisions removes the oldest revisions once the revision history limit has been exceeded.
+// The revision matching the current template is never removed.
+func (r *ControlPlaneMachineSetReconciler) pruneRevisions(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, currentHash string) error {
+	revisions, err := r.listRevisions(ctx, cpms)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < len(revisions)-revisionHistoryLimit; i++ {
+		if revisions[i].Labels[util.RevisionHashLabel] == currentHash {
+			continue
+		}
+
+		if err := r.Delete(ctx, &revisions[i]); err != nil {
+			return fmt.Errorf("error deleting revision %s: %w", revisions[i].Name, err)
+		}
+
+		logger.V(4).Info("Removed control plane machine set revision", "revision", revisions[i].Revision)
+	}
+
+	return nil
+}
+
+// listRevisions returns the ControllerRevisions owned by the ControlPlaneMachineSet, sorted by revision.
+func (r *ControlPlaneMachineSetReconciler) listRevisions(ctx context.Context, cpms *machinev1.ControlPlaneMachineSet) ([]appsv1.ControllerRevision, error) {
+	revisionList := &appsv1.ControllerRevisionList{}
+	if err := r.List(ctx, revisionList, client.InNamespace(cpms.Namespace), client.HasLabels{util.RevisionHashLabel}); err != nil {
+		return nil, fmt.Errorf("failed to list revisions: %w", err)
+	}
+
+	revisions := []appsv1.ControllerRevision{}
+
+	for i := range revisionList.Items {
+		if metav1.IsControlledBy(&revisionList.Items[i], cpms) {
+			revisions = append(revisions, revisionList.Items[i])
+		}
+	}
+
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Revision < revisions[j].Revision
+	})
+
+	return revisions, nil
+}
+
+// reconcileRollback restores the ControlPlaneMachineSet template from a previous revision when requested by the
+// rollback annotation. Once restored, the normal update strategy will roll the Machines back to the restored template.
+// It returns true when no further reconciliation should occur, either because the ControlPlaneMachineSet was updated
+// and must be requeued, or because the requested revision was invalid.
+func (r *ControlPlaneMachineSetReconciler) reconcileRollback(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet) (bool, ctrl.Result, error) {
+	value, ok := cpms.Annotations[rollbackToRevisionAnnotation]
+	if !ok {
+		return false, ctrl.Result{}, nil
+	}
+
+	template, err := r.getRevisionTemplate(ctx, cpms, value)
+	if err != nil {
+		if !errors.Is(err, errRevisionNotFound) && !errors.Is(err, errInvalidRevision) {
+			return false, ctrl.Result{}, err
+		}
+
+		setInvalidAnnotationDegraded(logger, cpms, err)
+
+		// Do not return an error here as the annotation will need user intervention to resolve.
+		return true, ctrl.Result{}, nil
+	}
+
+	cpms.Spec.Template = template
+	delete(cpms.Annotations, rollbackToRevisionAnnotation)
+
+	if err := r.Update(ctx, cpms); err != nil {
+		return false, ctrl.Result{}, fmt.Errorf("error updating control plane machine set: %w", err)
+	}
+
+	logger.V(2).Info(rolledBackRevision, "revision", value)
+
+	return true, ctrl.Result{Requeue: true}, nil
+}
+
+// getRevisionTemplate returns the ControlPlaneMachineSet template stored in the given revision.
+func (r *ControlPlaneMachineSetReconciler) getRevisionTemplate(ctx context.Context, cpms *machinev1.ControlPlaneMachineSet, value string) (machinev1.ControlPlaneMachineSetTemplate, error) {
+	revision, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || revision < 1 {
+		return machinev1.ControlPlaneMachineSetTemplate{}, fmt.Errorf("%w: %s: %q", errInvalidRevision, rollbackToRevisionAnnotation, value)
+	}
+
+	revisions, err := r.listRevisions(ctx, cpms)
+	if err != nil {
+		return machinev1.ControlPlaneMachineSetTemplate{}, err
+	}
+
+	for _, controllerRevision := range revisions {
+		if controllerRevision.Revision != revision {
+			continue
+		}
+
+		template := machinev1.ControlPlaneMachineSetTemplate{}
+		if err := json.Unmarshal(controllerRevision.Data.Raw, &template); err != nil {
+			return machinev1.ControlPlaneMachineSetTemplate{}, fmt.Errorf("could not unmarshal revision %d: %w", revision, err)
+		}
+
+		return template, nil
+	}
+
+	return machinev1.ControlPlaneMachineSetTemplate{}, fmt.Errorf("%w: %s: %d", errRevisionNotFound, rollbackToRevisionAnnotation, revision)
+}
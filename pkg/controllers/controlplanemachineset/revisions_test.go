@@ -0,0 +1,287 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-api-actuator-pkg/testutils"
+	corev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/core/v1"
+	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
+	machinev1beta1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1beta1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/util"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest/komega"
+)
+
+var _ = Describe("Revision history", func() {
+	var namespaceName string
+	var logger testutils.TestLogger
+	var reconciler *ControlPlaneMachineSetReconciler
+	var cpms *machinev1.ControlPlaneMachineSet
+
+	originalTemplate := machinev1beta1resourcebuilder.AWSProviderSpec().WithInstanceType("m6i.xlarge").BuildRawExtension()
+	updatedTemplate := machinev1beta1resourcebuilder.AWSProviderSpec().WithInstanceType("m6i.2xlarge").BuildRawExtension()
+
+	revisionHashFor := func(cpms *machinev1.ControlPlaneMachineSet) string {
+		hash, err := util.ComputeTemplateHash(cpms.Spec.Template)
+		Expect(err).ToNot(HaveOccurred())
+
+		return hash
+	}
+
+	listRevisions := func() []appsv1.ControllerRevision {
+		revisionList := &appsv1.ControllerRevisionList{}
+		Expect(k8sClient.List(ctx, revisionList, client.InNamespace(namespaceName))).To(Succeed())
+
+		return revisionList.Items
+	}
+
+	BeforeEach(func() {
+		By("Setting up a namespace for the test")
+		ns := corev1resourcebuilder.Namespace().WithGenerateName("control-plane-machine-set-revisions-").Build()
+		Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+		namespaceName = ns.GetName()
+
+		logger = testutils.NewTestLogger()
+		reconciler = &ControlPlaneMachineSetReconciler{
+			Client:    k8sClient,
+			Scheme:    testScheme,
+			Namespace: namespaceName,
+		}
+
+		By("Creating the control plane machine set")
+		cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithNamespace(namespaceName).WithName(clusterControlPlaneMachineSetName).Build()
+		cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.Spec.ProviderSpec.Value = originalTemplate.DeepCopy()
+		Expect(k8sClient.Create(ctx, cpms)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		testutils.CleanupResources(Default, ctx, cfg, k8sClient, namespaceName,
+			&appsv1.ControllerRevision{},
+			&machinev1.ControlPlaneMachineSet{},
+		)
+	})
+
+	Context("reconcileRevisionHistory", func() {
+		Context("with no existing revisions", func() {
+			var originalHash string
+
+			BeforeEach(func() {
+				originalHash = revisionHashFor(cpms)
+
+				Expect(reconciler.reconcileRevisionHistory(ctx, logger.Logger(), cpms)).To(Succeed())
+			})
+
+			It("creates the first revision", func() {
+				Expect(listRevisions()).To(ConsistOf(SatisfyAll(
+					HaveField("Revision", int64(1)),
+					HaveField("ObjectMeta.Labels", HaveKeyWithValue(util.RevisionHashLabel, originalHash)),
+					HaveField("ObjectMeta.OwnerReferences", ConsistOf(HaveField("UID", cpms.UID))),
+				)))
+			})
+
+			It("logs that the revision was created", func() {
+				Expect(logger.Entries()).To(ConsistOf(testutils.LogEntry{
+					Level:         2,
+					KeysAndValues: []interface{}{"revision", int64(1), "revisionHash", originalHash},
+					Message:       createdRevision,
+				}))
+			})
+
+			Context("and the template is unchanged", func() {
+				BeforeEach(func() {
+					Expect(reconciler.reconcileRevisionHistory(ctx, logger.Logger(), cpms)).To(Succeed())
+				})
+
+				It("does not create another revision", func() {
+					Expect(listRevisions()).To(HaveLen(1))
+				})
+			})
+
+			Context("and the template is changed", func() {
+				var updatedHash string
+
+				BeforeEach(func() {
+					cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.Spec.ProviderSpec.Value = updatedTemplate.DeepCopy()
+					updatedHash = revisionHashFor(cpms)
+
+					Expect(reconciler.reconcileRevisionHistory(ctx, logger.Logger(), cpms)).To(Succeed())
+				})
+
+				It("creates a second revision", func() {
+					Expect(listRevisions()).To(ConsistOf(
+						SatisfyAll(
+							HaveField("Revision", int64(1)),
+							HaveField("ObjectMeta.Labels", HaveKeyWithValue(util.RevisionHashLabel, originalHash)),
+						),
+						SatisfyAll(
+							HaveField("Revision", int64(2)),
+							HaveField("ObjectMeta.Labels", HaveKeyWithValue(util.RevisionHashLabel, updatedHash)),
+						),
+					))
+				})
+
+				Context("and the template is returned to the original", func() {
+					BeforeEach(func() {
+						cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.Spec.ProviderSpec.Value = originalTemplate.DeepCopy()
+
+						Expect(reconciler.reconcileRevisionHistory(ctx, logger.Logger(), cpms)).To(Succeed())
+					})
+
+					It("promotes the original revision to be the latest revision", func() {
+						Expect(listRevisions()).To(ConsistOf(
+							SatisfyAll(
+								HaveField("Revision", int64(3)),
+								HaveField("ObjectMeta.Labels", HaveKeyWithValue(util.RevisionHashLabel, originalHash)),
+							),
+							SatisfyAll(
+								HaveField("Revision", int64(2)),
+								HaveField("ObjectMeta.Labels", HaveKeyWithValue(util.RevisionHashLabel, updatedHash)),
+							),
+						))
+					})
+				})
+			})
+		})
+
+		Context("with more revisions than the revision history limit", func() {
+			BeforeEach(func() {
+				for i := 0; i < revisionHistoryLimit+2; i++ {
+					cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.Spec.ProviderSpec.Value = machinev1beta1resourcebuilder.AWSProviderSpec().
+						WithInstanceType(fmt.Sprintf("m6i.%dxlarge", i+1)).BuildRawExtension()
+
+					Expect(reconciler.reconcileRevisionHistory(ctx, logger.Logger(), cpms)).To(Succeed())
+				}
+			})
+
+			It("only retains the latest revisions", func() {
+				revisions := listRevisions()
+				Expect(revisions).To(HaveLen(revisionHistoryLimit))
+				Expect(revisions).ToNot(ContainElement(HaveField("Revision", BeNumerically("<", 3))))
+			})
+		})
+	})
+
+	Context("reconcileRollback", func() {
+		var done bool
+		var result ctrl.Result
+		var err error
+
+		BeforeEach(func() {
+			By("Storing the original and updated revisions")
+			Expect(reconciler.reconcileRevisionHistory(ctx, logger.Logger(), cpms)).To(Succeed())
+
+			cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.Spec.ProviderSpec.Value = updatedTemplate.DeepCopy()
+			Expect(k8sClient.Update(ctx, cpms)).To(Succeed())
+			Expect(reconciler.reconcileRevisionHistory(ctx, logger.Logger(), cpms)).To(Succeed())
+		})
+
+		Context("without the rollback annotation", func() {
+			BeforeEach(func() {
+				done, result, err = reconciler.reconcileRollback(ctx, logger.Logger(), cpms)
+			})
+
+			It("does not stop the reconcile", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(done).To(BeFalse())
+				Expect(result).To(Equal(ctrl.Result{}))
+			})
+
+			It("does not modify the template", func() {
+				Expect(komega.Object(cpms)()).To(HaveField("Spec.Template.OpenShiftMachineV1Beta1Machine.Spec.ProviderSpec.Value.Raw", MatchJSON(updatedTemplate.Raw)))
+			})
+		})
+
+		Context("with the rollback annotation set to a previous revision", func() {
+			BeforeEach(func() {
+				cpms.Annotations = map[string]string{rollbackToRevisionAnnotation: "1"}
+				Expect(k8sClient.Update(ctx, cpms)).To(Succeed())
+
+				done, result, err = reconciler.reconcileRollback(ctx, logger.Logger(), cpms)
+			})
+
+			It("requeues the reconcile", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(done).To(BeTrue())
+				Expect(result).To(Equal(ctrl.Result{Requeue: true}))
+			})
+
+			It("restores the template from the revision", func() {
+				Expect(komega.Object(cpms)()).To(HaveField("Spec.Template.OpenShiftMachineV1Beta1Machine.Spec.ProviderSpec.Value.Raw", MatchJSON(originalTemplate.Raw)))
+			})
+
+			It("removes the rollback annotation", func() {
+				Expect(komega.Object(cpms)()).To(HaveField("ObjectMeta.Annotations", Not(HaveKey(rollbackToRevisionAnnotation))))
+			})
+		})
+
+		Context("with the rollback annotation set to a revision that does not exist", func() {
+			BeforeEach(func() {
+				cpms.Annotations = map[string]string{rollbackToRevisionAnnotation: "5"}
+
+				done, result, err = reconciler.reconcileRollback(ctx, logger.Logger(), cpms)
+			})
+
+			It("stops the reconcile without an error", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(done).To(BeTrue())
+				Expect(result).To(Equal(ctrl.Result{}))
+			})
+
+			It("sets the degraded condition", func() {
+				Expect(cpms.Status.Conditions).To(ConsistOf(testutils.MatchCondition(metav1.Condition{
+					Type:    conditionDegraded,
+					Status:  metav1.ConditionTrue,
+					Reason:  reasonInvalidAnnotation,
+					Message: fmt.Sprintf("%s: %s: %s: %d", invalidAnnotationMessage, errRevisionNotFound, rollbackToRevisionAnnotation, 5),
+				})))
+			})
+		})
+
+		Context("with the rollback annotation set to an invalid value", func() {
+			BeforeEach(func() {
+				cpms.Annotations = map[string]string{rollbackToRevisionAnnotation: "previous"}
+
+				done, result, err = reconciler.reconcileRollback(ctx, logger.Logger(), cpms)
+			})
+
+			It("stops the reconcile without an error", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(done).To(BeTrue())
+				Expect(result).To(Equal(ctrl.Result{}))
+			})
+
+			It("sets the degraded condition", func() {
+				Expect(cpms.Status.Conditions).To(ConsistOf(testutils.MatchCondition(metav1.Condition{
+					Type:    conditionDegraded,
+					Status:  metav1.ConditionTrue,
+					Reason:  reasonInvalidAnnotation,
+					Message: fmt.Sprintf("%s: %s: %s: %q", invalidAnnotationMessage, errInvalidRevision, rollbackToRevisionAnnotation, "previous"),
+				})))
+			})
+		})
+	})
+})
@@ -0,0 +1,279 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/api/config/v1"
+	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	"github.com/openshift/library-go/pkg/config/clusteroperator/v1helpers"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// clusterVersionName is the name of the ClusterVersion singleton.
+	clusterVersionName = "version"
+
+	// stabilityGatePollInterval is the interval at which the cluster is checked again while the stability gate is
+	// blocking updates. ClusterOperator and ClusterVersion events do not trigger a reconcile of the
+	// ControlPlaneMachineSet.
+	stabilityGatePollInterval = 30 * time.Second
+
+	// waitingForClusterStability is a log message used to inform the user that no Machines are being created or
+	// deleted because a component of the cluster is not yet stable.
+	waitingForClusterStability = "Waiting for the cluster to be stable before creating or deleting machines"
+)
+
+var (
+	// errInvalidStabilityGateOperators is used to inform users that the value of the stability gate cluster
+	// operators annotation is not valid.
+	errInvalidStabilityGateOperators = errors.New("stability gate cluster operators must be a comma separated list of cluster operator names")
+
+	// errInvalidStabilityGateSettleSeconds is used to inform users that the value of the stability gate settle
+	// seconds annotation is not valid.
+	errInvalidStabilityGateSettleSeconds = errors.New("stability gate settle seconds must be a non-negative integer")
+)
+
+// stabilityGate is the configuration of the stability gate.
+// When disabled, Machines may be created and deleted regardless of the state of the cluster.
+type stabilityGate struct {
+	// enabled determines whether the stability gate has been configured.
+	enabled bool
+
+	// clusterOperators are the names of the ClusterOperators which must be stable.
+	clusterOperators []string
+
+	// settle is the duration for which the components must have been stable.
+	settle time.Duration
+}
+
+// unstableComponent describes the component of the cluster which is blocking the stability gate.
+type unstableComponent struct {
+	// kind is the kind of the component, either ClusterOperator or ClusterVersion.
+	kind string
+
+	// name is the name of the component.
+	name string
+
+	// reason describes why the component is not stable.
+	reason string
+
+	// settleRemaining is the remaining duration until the component has been stable for the settle period.
+	// This is only set when the component is otherwise stable.
+	settleRemaining time.Duration
+}
+
+// getStabilityGate returns the stability gate configured by the stability gate annotations.
+// When the cluster operators annotation is not present, the stability gate is disabled.
+func getStabilityGate(cpms *machinev1.ControlPlaneMachineSet) (stabilityGate, error) {
+	value, ok := cpms.Annotations[stabilityGateClusterOperatorsAnnotation]
+	if !ok {
+		return stabilityGate{}, nil
+	}
+
+	clusterOperators := []string{}
+
+	if strings.TrimSpace(value) != "" {
+		for _, field := range strings.Split(value, ",") {
+			name := strings.TrimSpace(field)
+			if name == "" {
+				return stabilityGate{}, fmt.Errorf("%w: %s: %q", errInvalidStabilityGateOperators, stabilityGateClusterOperatorsAnnotation, value)
+			}
+
+			clusterOperators = append(clusterOperators, name)
+		}
+	}
+
+	settle := time.Duration(0)
+
+	if settleValue, ok := cpms.Annotations[stabilityGateSettleSecondsAnnotation]; ok {
+		settleSeconds, err := strconv.ParseInt(settleValue, 10, 32)
+		if err != nil || settleSeconds < 0 {
+			return stabilityGate{}, fmt.Errorf("%w: %s: %q", errInvalidStabilityGateSettleSeconds, stabilityGateSettleSecondsAnnotation, settleValue)
+		}
+
+		settle = time.Duration(settleSeconds) * time.Second
+	}
+
+	return stabilityGate{
+		enabled:          true,
+		clusterOperators: clusterOperators,
+		settle:           settle,
+	}, nil
+}
+
+// reconcileStabilityGate blocks the creation and deletion of Machines while any of the configured ClusterOperators,
+// or the ClusterVersion, is not stable, or has not been stable for the settle period.
+// It must be checked before any other step which may create or delete Machines.
+// When blocked, the unstable component is added to the Progressing condition and the ControlPlaneMachineSet is
+// requeued so that the cluster is checked again.
+func (r *ControlPlaneMachineSetReconciler) reconcileStabilityGate(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, machineInfos map[int32][]machineproviders.MachineInfo) (bool, ctrl.Result, error) {
+	gate, err := getStabilityGate(cpms)
+	if err != nil {
+		setInvalidAnnotationDegraded(logger, cpms, err)
+
+		// Do not return an error here as the annotation will need user intervention to resolve.
+		return true, ctrl.Result{}, nil
+	}
+
+	if !gate.enabled || !requiresMachineChanges(pointer.Int32Deref(cpms.Spec.Replicas, 0), machineInfos) {
+		return false, ctrl.Result{}, nil
+	}
+
+	unstable, found, err := r.findUnstableComponent(ctx, gate, time.Now())
+	if err != nil {
+		return false, ctrl.Result{}, fmt.Errorf("error checking cluster stability: %w", err)
+	}
+
+	if !found {
+		return false, ctrl.Result{}, nil
+	}
+
+	logger.V(2).WithValues("kind", unstable.kind, "name", unstable.name, "reason", unstable.reason).Info(waitingForClusterStability)
+
+	setStabilityGateCondition(cpms, unstable)
+
+	requeueAfter := stabilityGatePollInterval
+	if unstable.settleRemaining > 0 && unstable.settleRemaining < requeueAfter {
+		requeueAfter = unstable.settleRemaining
+	}
+
+	return true, ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// findUnstableComponent returns the first of the configured ClusterOperators, or the ClusterVersion, which is not
+// stable, or has not been stable for the settle period. When every component is stable, found is false.
+// The ClusterOperator of the control plane machine set operator itself is never considered, as it reports
+// Progressing while the control plane is being updated.
+func (r *ControlPlaneMachineSetReconciler) findUnstableComponent(ctx context.Context, gate stabilityGate, now time.Time) (unstableComponent, bool, error) {
+	for _, name := range gate.clusterOperators {
+		if name == r.OperatorName {
+			continue
+		}
+
+		co := &configv1.ClusterOperator{}
+		if err := r.Get(ctx, client.ObjectKey{Name: name}, co); apierrors.IsNotFound(err) {
+			return unstableComponent{kind: "ClusterOperator", name: name, reason: "not found"}, true, nil
+		} else if err != nil {
+			return unstableComponent{}, false, fmt.Errorf("error getting cluster operator %s: %w", name, err)
+		}
+
+		if reason, settleRemaining, stable := isStable(co.Status.Conditions, gate.settle, now, configv1.OperatorAvailable, configv1.OperatorDegraded, configv1.OperatorProgressing); !stable {
+			return unstableComponent{kind: "ClusterOperator", name: name, reason: reason, settleRemaining: settleRemaining}, true, nil
+		}
+	}
+
+	cv := &configv1.ClusterVersion{}
+	if err := r.Get(ctx, client.ObjectKey{Name: clusterVersionName}, cv); apierrors.IsNotFound(err) {
+		return unstableComponent{kind: "ClusterVersion", name: clusterVersionName, reason: "not found"}, true, nil
+	} else if err != nil {
+		return unstableComponent{}, false, fmt.Errorf("error getting cluster version: %w", err)
+	}
+
+	if reason, settleRemaining, stable := isStable(cv.Status.Conditions, gate.settle, now, configv1.OperatorProgressing); !stable {
+		return unstableComponent{kind: "ClusterVersion", name: clusterVersionName, reason: reason, settleRemaining: settleRemaining}, true, nil
+	}
+
+	return unstableComponent{}, false, nil
+}
+
+// isStable determines whether the given conditions report a stable component, and whether they have done so for
+// the settle period. A stable component is Available, and is neither Degraded nor Progressing.
+// Only the given condition types are considered.
+// When the component is not stable, the reason is returned, along with the remaining settle period when the
+// component is stable but has not been for long enough.
+func isStable(conditions []configv1.ClusterOperatorStatusCondition, settle time.Duration, now time.Time, conditionTypes ...configv1.ClusterStatusConditionType) (string, time.Duration, bool) {
+	var lastTransition time.Time
+
+	for _, conditionType := range conditionTypes {
+		expected := configv1.ConditionFalse
+		if conditionType == configv1.OperatorAvailable {
+			expected = configv1.ConditionTrue
+		}
+
+		condition := v1helpers.FindStatusCondition(conditions, conditionType)
+
+		switch {
+		case condition == nil && expected == configv1.ConditionTrue:
+			return fmt.Sprintf("%s condition not found", conditionType), 0, false
+		case condition == nil:
+			continue
+		case condition.Status != expected:
+			return fmt.Sprintf("%s=%s", conditionType, condition.Status), 0, false
+		}
+
+		if condition.LastTransitionTime.Time.After(lastTransition) {
+			lastTransition = condition.LastTransitionTime.Time
+		}
+	}
+
+	if settleRemaining := lastTransition.Add(settle).Sub(now); settleRemaining > 0 {
+		return fmt.Sprintf("stable for less than %s", settle), settleRemaining, false
+	}
+
+	return "", 0, true
+}
+
+// setStabilityGateCondition adds the component blocking the stability gate to the Progressing condition.
+func setStabilityGateCondition(cpms *machinev1.ControlPlaneMachineSet, unstable unstableComponent) {
+	progressingCondition := meta.FindStatusCondition(cpms.Status.Conditions, conditionProgressing)
+	if progressingCondition == nil {
+		return
+	}
+
+	message := fmt.Sprintf("waiting for %s %s to be stable: %s", unstable.kind, unstable.name, unstable.reason)
+	if progressingCondition.Message != "" {
+		message = fmt.Sprintf("%s, %s", progressingCondition.Message, message)
+	}
+
+	progressingCondition.Message = message
+}
+
+// requiresMachineChanges determines whether any Machine needs to be created or deleted, that is whether any index
+// does not have an up to date Machine, or has more than a single Machine, or whether any index is missing or in
+// excess of the replicas.
+func requiresMachineChanges(replicas int32, indexedMachineInfos map[int32][]machineproviders.MachineInfo) bool {
+	if len(outdatedIndexes(indexedMachineInfos)) > 0 {
+		return true
+	}
+
+	for idx, machineInfos := range indexedMachineInfos {
+		if len(machineInfos) > 1 || idx >= replicas {
+			return true
+		}
+	}
+
+	for idx := int32(0); idx < replicas; idx++ {
+		if _, ok := indexedMachineInfos[idx]; !ok {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,272 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/cluster-api-actuator-pkg/testutils"
+	configv1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/config/v1"
+	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	machineprovidersresourcebuilder "github.com/openshift/cluster-control-plane-machine-set-operator/pkg/test/resourcebuilder/machineproviders"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Stability gate", func() {
+	now := time.Date(2023, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	stableConditions := func(lastTransition time.Time) []configv1.ClusterOperatorStatusCondition {
+		return []configv1.ClusterOperatorStatusCondition{
+			{Type: configv1.OperatorAvailable, Status: configv1.ConditionTrue, LastTransitionTime: metav1.NewTime(lastTransition)},
+			{Type: configv1.OperatorDegraded, Status: configv1.ConditionFalse, LastTransitionTime: metav1.NewTime(lastTransition)},
+			{Type: configv1.OperatorProgressing, Status: configv1.ConditionFalse, LastTransitionTime: metav1.NewTime(lastTransition)},
+		}
+	}
+
+	Context("getStabilityGate", func() {
+		type getStabilityGateTableInput struct {
+			annotations   map[string]string
+			expectedGate  stabilityGate
+			expectedError error
+		}
+
+		DescribeTable("should return the configured stability gate", func(in getStabilityGateTableInput) {
+			cpms := machinev1resourcebuilder.ControlPlaneMachineSet().Build()
+			cpms.Annotations = in.annotations
+
+			gate, err := getStabilityGate(cpms)
+			if in.expectedError != nil {
+				Expect(err).To(MatchError(in.expectedError))
+				return
+			}
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(gate).To(Equal(in.expectedGate))
+		},
+			Entry("with no annotations", getStabilityGateTableInput{
+				expectedGate: stabilityGate{},
+			}),
+			Entry("with a list of cluster operators", getStabilityGateTableInput{
+				annotations: map[string]string{
+					stabilityGateClusterOperatorsAnnotation: "etcd, kube-apiserver,machine-config",
+				},
+				expectedGate: stabilityGate{
+					enabled:          true,
+					clusterOperators: []string{"etcd", "kube-apiserver", "machine-config"},
+				},
+			}),
+			Entry("with an empty list of cluster operators and a settle period", getStabilityGateTableInput{
+				annotations: map[string]string{
+					stabilityGateClusterOperatorsAnnotation: "",
+					stabilityGateSettleSecondsAnnotation:    "300",
+				},
+				expectedGate: stabilityGate{
+					enabled:          true,
+					clusterOperators: []string{},
+					settle:           5 * time.Minute,
+				},
+			}),
+			Entry("with an empty cluster operator name", getStabilityGateTableInput{
+				annotations: map[string]string{
+					stabilityGateClusterOperatorsAnnotation: "etcd,,kube-apiserver",
+				},
+				expectedError: errInvalidStabilityGateOperators,
+			}),
+			Entry("with a negative settle period", getStabilityGateTableInput{
+				annotations: map[string]string{
+					stabilityGateClusterOperatorsAnnotation: "etcd",
+					stabilityGateSettleSecondsAnnotation:    "-1",
+				},
+				expectedError: errInvalidStabilityGateSettleSeconds,
+			}),
+		)
+	})
+
+	Context("isStable", func() {
+		type isStableTableInput struct {
+			conditions              []configv1.ClusterOperatorStatusCondition
+			settle                  time.Duration
+			expectedStable          bool
+			expectedReason          string
+			expectedSettleRemaining time.Duration
+		}
+
+		DescribeTable("should determine whether a cluster operator is stable", func(in isStableTableInput) {
+			reason, settleRemaining, stable := isStable(in.conditions, in.settle, now, configv1.OperatorAvailable, configv1.OperatorDegraded, configv1.OperatorProgressing)
+
+			Expect(stable).To(Equal(in.expectedStable))
+			Expect(reason).To(Equal(in.expectedReason))
+			Expect(settleRemaining).To(Equal(in.expectedSettleRemaining))
+		},
+			Entry("with stable conditions", isStableTableInput{
+				conditions:     stableConditions(now.Add(-time.Hour)),
+				settle:         5 * time.Minute,
+				expectedStable: true,
+			}),
+			Entry("with stable conditions, within the settle period", isStableTableInput{
+				conditions:              stableConditions(now.Add(-time.Minute)),
+				settle:                  5 * time.Minute,
+				expectedStable:          false,
+				expectedReason:          "stable for less than 5m0s",
+				expectedSettleRemaining: 4 * time.Minute,
+			}),
+			Entry("with a progressing cluster operator", isStableTableInput{
+				conditions: []configv1.ClusterOperatorStatusCondition{
+					{Type: configv1.OperatorAvailable, Status: configv1.ConditionTrue},
+					{Type: configv1.OperatorProgressing, Status: configv1.ConditionTrue},
+				},
+				expectedStable: false,
+				expectedReason: "Progressing=True",
+			}),
+			Entry("with a degraded cluster operator", isStableTableInput{
+				conditions: []configv1.ClusterOperatorStatusCondition{
+					{Type: configv1.OperatorAvailable, Status: configv1.ConditionTrue},
+					{Type: configv1.OperatorDegraded, Status: configv1.ConditionTrue},
+				},
+				expectedStable: false,
+				expectedReason: "Degraded=True",
+			}),
+			Entry("with no Available condition", isStableTableInput{
+				conditions:     []configv1.ClusterOperatorStatusCondition{},
+				expectedStable: false,
+				expectedReason: "Available condition not found",
+			}),
+		)
+	})
+
+	Context("requiresMachineChanges", func() {
+		updatedMachineBuilder := machineprovidersresourcebuilder.MachineInfo().WithReady(true).WithNeedsUpdate(false)
+
+		It("should not require changes when every index has a single up to date Machine", func() {
+			Expect(requiresMachineChanges(2, map[int32][]machineproviders.MachineInfo{
+				0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+				1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+			})).To(BeFalse())
+		})
+
+		It("should require changes when an index has an outdated Machine", func() {
+			Expect(requiresMachineChanges(2, map[int32][]machineproviders.MachineInfo{
+				0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+				1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").WithNeedsUpdate(true).Build()},
+			})).To(BeTrue())
+		})
+
+		It("should require changes when an index has an excess Machine", func() {
+			Expect(requiresMachineChanges(1, map[int32][]machineproviders.MachineInfo{
+				0: {
+					updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build(),
+					updatedMachineBuilder.WithIndex(0).WithMachineName("machine-replacement-0").WithNodeName("node-replacement-0").Build(),
+				},
+			})).To(BeTrue())
+		})
+
+		It("should require changes when an index is missing", func() {
+			Expect(requiresMachineChanges(3, map[int32][]machineproviders.MachineInfo{
+				0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+				1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+			})).To(BeTrue())
+		})
+
+		It("should require changes when an index is in excess of the replicas", func() {
+			Expect(requiresMachineChanges(1, map[int32][]machineproviders.MachineInfo{
+				0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+				1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+			})).To(BeTrue())
+		})
+	})
+
+	Context("findUnstableComponent", func() {
+		var reconciler *ControlPlaneMachineSetReconciler
+
+		gate := stabilityGate{
+			enabled:          true,
+			clusterOperators: []string{"etcd", "control-plane-machine-set"},
+		}
+
+		createClusterOperator := func(name string, conditions []configv1.ClusterOperatorStatusCondition) {
+			co := configv1resourcebuilder.ClusterOperator().WithName(name).Build()
+			Expect(k8sClient.Create(ctx, co)).To(Succeed())
+
+			co.Status.Conditions = conditions
+			Expect(k8sClient.Status().Update(ctx, co)).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			reconciler = &ControlPlaneMachineSetReconciler{
+				Client:         k8sClient,
+				UncachedClient: k8sClient,
+				OperatorName:   "control-plane-machine-set",
+			}
+
+			// The control plane machine set operator itself is never considered.
+			createClusterOperator("control-plane-machine-set", []configv1.ClusterOperatorStatusCondition{
+				{Type: configv1.OperatorAvailable, Status: configv1.ConditionTrue, LastTransitionTime: metav1.Now()},
+				{Type: configv1.OperatorProgressing, Status: configv1.ConditionTrue, LastTransitionTime: metav1.Now()},
+			})
+
+			cv := &configv1.ClusterVersion{
+				ObjectMeta: metav1.ObjectMeta{Name: clusterVersionName},
+				Spec:       configv1.ClusterVersionSpec{ClusterID: "00000000-0000-0000-0000-000000000000"},
+			}
+			Expect(k8sClient.Create(ctx, cv)).To(Succeed())
+
+			cv.Status.Conditions = []configv1.ClusterOperatorStatusCondition{
+				{Type: configv1.OperatorProgressing, Status: configv1.ConditionFalse, LastTransitionTime: metav1.Now()},
+			}
+			Expect(k8sClient.Status().Update(ctx, cv)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			testutils.CleanupResources(Default, ctx, cfg, k8sClient, "",
+				&configv1.ClusterOperator{},
+				&configv1.ClusterVersion{},
+			)
+		})
+
+		It("should report a missing cluster operator", func() {
+			unstable, found, err := reconciler.findUnstableComponent(ctx, gate, time.Now())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(unstable).To(Equal(unstableComponent{kind: "ClusterOperator", name: "etcd", reason: "not found"}))
+		})
+
+		It("should report a progressing cluster operator", func() {
+			createClusterOperator("etcd", []configv1.ClusterOperatorStatusCondition{
+				{Type: configv1.OperatorAvailable, Status: configv1.ConditionTrue, LastTransitionTime: metav1.Now()},
+				{Type: configv1.OperatorProgressing, Status: configv1.ConditionTrue, LastTransitionTime: metav1.Now()},
+			})
+
+			unstable, found, err := reconciler.findUnstableComponent(ctx, gate, time.Now())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(unstable).To(Equal(unstableComponent{kind: "ClusterOperator", name: "etcd", reason: "Progressing=True"}))
+		})
+
+		It("should not report anything when the cluster is stable", func() {
+			createClusterOperator("etcd", stableConditions(time.Now().Add(-time.Hour)))
+
+			_, found, err := reconciler.findUnstableComponent(ctx, gate, time.Now())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+	})
+})
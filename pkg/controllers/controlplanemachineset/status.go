@@ -19,12 +19,16 @@ package controlplanemachineset
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	machinev1 "github.com/openshift/api/machine/v1"
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -124,7 +128,7 @@ func reconcileStatusWithMachineInfo(logger logr.Logger, cpms *machinev1.ControlP
 		"unavailableReplicas", cpms.Status.UnavailableReplicas,
 	)
 
-	if err := setConditions(cpms); err != nil {
+	if err := setConditions(cpms, machineInfosByIndex); err != nil {
 		return fmt.Errorf("could not set control plane machine set conditions: %w", err)
 	}
 
@@ -132,7 +136,7 @@ func reconcileStatusWithMachineInfo(logger logr.Logger, cpms *machinev1.ControlP
 }
 
 // setConditions sets Available, Degraded and Progressing conditions on the ControlPlaneMachineSet.
-func setConditions(cpms *machinev1.ControlPlaneMachineSet) error {
+func setConditions(cpms *machinev1.ControlPlaneMachineSet, machineInfosByIndex map[int32][]machineproviders.MachineInfo) error {
 	availableCondition := getAvailableCondition(cpms)
 	meta.SetStatusCondition(&cpms.Status.Conditions, availableCondition)
 
@@ -144,11 +148,94 @@ func setConditions(cpms *machinev1.ControlPlaneMachineSet) error {
 		return fmt.Errorf("could not set progressing condition: %w", err)
 	}
 
+	for _, step := range progressingConditionSteps(time.Now()) {
+		if step.appliesTo(cpms, progressingCondition) {
+			progressingCondition = step.apply(cpms, machineInfosByIndex, progressingCondition)
+		}
+	}
+
 	meta.SetStatusCondition(&cpms.Status.Conditions, progressingCondition)
 
 	return nil
 }
 
+// progressingConditionStep adds to the Progressing condition why the update of the ControlPlaneMachineSet is, or is
+// not, progressing.
+type progressingConditionStep struct {
+	// reasons, when set, limits the step to a Progressing condition with one of these reasons.
+	reasons []string
+
+	// rollingUpdateOnly limits the step to the RollingUpdate strategy.
+	rollingUpdateOnly bool
+
+	// enabled, when set, limits the step to a ControlPlaneMachineSet on which it has been configured.
+	enabled func(cpms *machinev1.ControlPlaneMachineSet) bool
+
+	// apply computes the Progressing condition from the existing Progressing condition.
+	apply func(cpms *machinev1.ControlPlaneMachineSet, machineInfosByIndex map[int32][]machineproviders.MachineInfo, progressingCondition metav1.Condition) metav1.Condition
+}
+
+// appliesTo determines whether the step applies to the ControlPlaneMachineSet and its Progressing condition.
+func (s progressingConditionStep) appliesTo(cpms *machinev1.ControlPlaneMachineSet, progressingCondition metav1.Condition) bool {
+	if len(s.reasons) > 0 && !sets.New(s.reasons...).Has(progressingCondition.Reason) {
+		return false
+	}
+
+	if s.rollingUpdateOnly && cpms.Spec.Strategy.Type != machinev1.RollingUpdate {
+		return false
+	}
+
+	return s.enabled == nil || s.enabled(cpms)
+}
+
+// progressingConditionSteps returns, in order, the steps computing the Progressing condition.
+// The reasons holding back the update are added to the message in the order of these steps.
+func progressingConditionSteps(now time.Time) []progressingConditionStep {
+	// replacingReasons are the reasons of a Progressing condition while Machines are being replaced.
+	replacingReasons := []string{reasonNeedsUpdateReplicas, reasonMachineAgeExceeded, reasonExcessReplicas}
+
+	return []progressingConditionStep{
+		{apply: getExcessIndexesCondition},
+		{
+			reasons: []string{reasonNeedsUpdateReplicas},
+			enabled: isPaused,
+			apply: func(cpms *machinev1.ControlPlaneMachineSet, machineInfosByIndex map[int32][]machineproviders.MachineInfo, _ metav1.Condition) metav1.Condition {
+				return getPausedCondition(cpms, machineInfosByIndex)
+			},
+		},
+		{reasons: []string{reasonNeedsUpdateReplicas}, rollingUpdateOnly: true, enabled: hasPartitionAnnotation, apply: getPartitionedCondition},
+		{reasons: []string{reasonNeedsUpdateReplicas}, apply: getMachineAgeExceededCondition},
+		{reasons: replacingReasons, rollingUpdateOnly: true, enabled: hasMinReadySecondsAnnotation, apply: getMinReadyCondition},
+		{
+			reasons:           replacingReasons,
+			rollingUpdateOnly: true,
+			apply: func(_ *machinev1.ControlPlaneMachineSet, machineInfosByIndex map[int32][]machineproviders.MachineInfo, progressingCondition metav1.Condition) metav1.Condition {
+				return getEtcdMemberCondition(machineInfosByIndex, progressingCondition)
+			},
+		},
+		{
+			reasons:           replacingReasons,
+			rollingUpdateOnly: true,
+			apply: func(_ *machinev1.ControlPlaneMachineSet, machineInfosByIndex map[int32][]machineproviders.MachineInfo, progressingCondition metav1.Condition) metav1.Condition {
+				return getFailureDomainFailoverCondition(machineInfosByIndex, progressingCondition)
+			},
+		},
+		{
+			reasons:           replacingReasons,
+			rollingUpdateOnly: true,
+			enabled:           hasMaintenanceWindowsAnnotation,
+			apply: func(cpms *machinev1.ControlPlaneMachineSet, _ map[int32][]machineproviders.MachineInfo, progressingCondition metav1.Condition) metav1.Condition {
+				return getMaintenanceWindowCondition(cpms, progressingCondition, now)
+			},
+		},
+		{
+			apply: func(_ *machinev1.ControlPlaneMachineSet, machineInfosByIndex map[int32][]machineproviders.MachineInfo, progressingCondition metav1.Condition) metav1.Condition {
+				return getIgnoredDifferencesCondition(machineInfosByIndex, progressingCondition)
+			},
+		},
+	}
+}
+
 // getProgressingCondition computes Available condition based on the current ControlPlaneMachineSet status.
 func getAvailableCondition(cpms *machinev1.ControlPlaneMachineSet) metav1.Condition {
 	if cpms.Status.UnavailableReplicas != 0 {
@@ -217,11 +304,20 @@ func getProgressingCondition(cpms *machinev1.ControlPlaneMachineSet) (metav1.Con
 	desiredReplicas := *cpms.Spec.Replicas
 
 	if desiredReplicas > cpms.Status.UpdatedReplicas {
+		message := fmt.Sprintf("Observed %d replica(s) in need of update", desiredReplicas-cpms.Status.UpdatedReplicas)
+
+		if cpms.Spec.Strategy.Type == machinev1.RollingUpdate && hasMaxSurgeAnnotation(cpms) {
+			// Only report the surge when it has been configured, an invalid value is reported via the Degraded condition.
+			if maxSurge, err := getMaxSurge(cpms); err == nil {
+				message = fmt.Sprintf("%s, replacing up to %d replica(s) at a time", message, maxSurge)
+			}
+		}
+
 		return metav1.Condition{
 			Type:               conditionProgressing,
 			Status:             metav1.ConditionTrue,
 			Reason:             reasonNeedsUpdateReplicas,
-			Message:            fmt.Sprintf("Observed %d replica(s) in need of update", desiredReplicas-cpms.Status.UpdatedReplicas),
+			Message:            message,
 			ObservedGeneration: cpms.Generation,
 		}, nil
 	}
@@ -243,3 +339,99 @@ func getProgressingCondition(cpms *machinev1.ControlPlaneMachineSet) (metav1.Con
 		ObservedGeneration: cpms.Generation,
 	}, nil
 }
+
+// getPausedCondition computes the Progressing condition when updates are required but have been paused.
+func getPausedCondition(cpms *machinev1.ControlPlaneMachineSet, machineInfosByIndex map[int32][]machineproviders.MachineInfo) metav1.Condition {
+	indexes := []string{}
+	for _, idx := range outdatedIndexes(machineInfosByIndex) {
+		indexes = append(indexes, strconv.Itoa(int(idx)))
+	}
+
+	return metav1.Condition{
+		Type:               conditionProgressing,
+		Status:             metav1.ConditionFalse,
+		Reason:             reasonPaused,
+		Message:            fmt.Sprintf("Updates are paused, observed %d index(es) in need of update: %s", len(indexes), strings.Join(indexes, ", ")),
+		ObservedGeneration: cpms.Generation,
+	}
+}
+
+// getPartitionedCondition computes the Progressing condition when updates are required and a partition has been
+// configured. When every outdated index is held back by the partition, no update is progressing.
+// Otherwise, the number of replicas held back by the partition is added to the existing condition.
+func getPartitionedCondition(cpms *machinev1.ControlPlaneMachineSet, machineInfosByIndex map[int32][]machineproviders.MachineInfo, progressingCondition metav1.Condition) metav1.Condition {
+	partition, err := getPartition(cpms)
+	if err != nil {
+		// An invalid value is reported via the Degraded condition.
+		return progressingCondition
+	}
+
+	heldBack := heldBackIndexes(machineInfosByIndex, partition)
+	if len(heldBack) == 0 {
+		return progressingCondition
+	}
+
+	if len(heldBack) == len(outdatedIndexes(machineInfosByIndex)) {
+		return metav1.Condition{
+			Type:               conditionProgressing,
+			Status:             metav1.ConditionFalse,
+			Reason:             reasonPartitioned,
+			Message:            fmt.Sprintf("Observed %d replica(s) in need of update held back by partition %d", len(heldBack), partition),
+			ObservedGeneration: cpms.Generation,
+		}
+	}
+
+	progressingCondition.Message = fmt.Sprintf("%s, %d replica(s) held back by partition %d", progressingCondition.Message, len(heldBack), partition)
+
+	return progressingCondition
+}
+
+// getMinReadyCondition adds the number of replacement replicas, which have not yet been Ready for the minimum ready
+// duration, to the existing Progressing condition.
+func getMinReadyCondition(cpms *machinev1.ControlPlaneMachineSet, machineInfosByIndex map[int32][]machineproviders.MachineInfo, progressingCondition metav1.Condition) metav1.Condition {
+	minReady, err := getMinReady(cpms)
+	if err != nil {
+		// An invalid value is reported via the Degraded condition.
+		return progressingCondition
+	}
+
+	waiting := minReadyIndexes(machineInfosByIndex, minReady, time.Now())
+	if len(waiting) == 0 {
+		return progressingCondition
+	}
+
+	progressingCondition.Message = fmt.Sprintf("%s, %d replacement replica(s) must be ready for %d second(s) before the old replica(s) are removed", progressingCondition.Message, len(waiting), int64(minReady/time.Second))
+
+	return progressingCondition
+}
+
+// getEtcdMemberCondition adds the number of replacement replicas, which are waiting for their etcd member to be
+// healthy and voting, to the Progressing condition.
+func getEtcdMemberCondition(machineInfosByIndex map[int32][]machineproviders.MachineInfo, progressingCondition metav1.Condition) metav1.Condition {
+	waiting := etcdMemberIndexes(machineInfosByIndex)
+	if len(waiting) == 0 {
+		return progressingCondition
+	}
+
+	progressingCondition.Message = fmt.Sprintf("%s, %d replacement replica(s) must have a healthy and voting etcd member before the old replica(s) are removed", progressingCondition.Message, len(waiting))
+
+	return progressingCondition
+}
+
+// getIgnoredDifferencesCondition adds the number of replicas, which have differences in fields ignored by the
+// ignored fields annotation, to the existing Progressing condition so that the differences remain visible.
+func getIgnoredDifferencesCondition(machineInfosByIndex map[int32][]machineproviders.MachineInfo, progressingCondition metav1.Condition) metav1.Condition {
+	ignored := ignoredDifferencesMachines(machineInfosMaptoSlice(machineInfosByIndex))
+	if len(ignored) == 0 {
+		return progressingCondition
+	}
+
+	message := fmt.Sprintf("%d replica(s) have differences in ignored fields", len(ignored))
+	if progressingCondition.Message != "" {
+		message = fmt.Sprintf("%s, %s", progressingCondition.Message, message)
+	}
+
+	progressingCondition.Message = message
+
+	return progressingCondition
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package controlplanemachineset
 
 import (
+	"time"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
@@ -27,6 +29,7 @@ import (
 	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
 	machineprovidersresourcebuilder "github.com/openshift/cluster-control-plane-machine-set-operator/pkg/test/resourcebuilder/machineproviders"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/util"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -293,6 +296,181 @@ var _ = Describe("Status", func() {
 					},
 				},
 			}),
+			Entry("when Machines need updates, and updates are paused", &reconcileStatusTableInput{
+				cpmsBuilder: &machinev1resourcebuilder.ControlPlaneMachineSetFuncs{
+					BuildFunc: func() *machinev1.ControlPlaneMachineSet {
+						cpms := machinev1resourcebuilder.ControlPlaneMachineSet().WithGeneration(2).Build()
+						cpms.Annotations = map[string]string{pausedAnnotation: ""}
+
+						return cpms
+					},
+				},
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+					1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").WithNeedsUpdate(true).Build()},
+					2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").WithNeedsUpdate(true).Build()},
+				},
+				expectedError: nil,
+				expectedStatus: machinev1.ControlPlaneMachineSetStatus{
+					Conditions: []metav1.Condition{
+						{
+							Type:               conditionAvailable,
+							Status:             metav1.ConditionTrue,
+							Reason:             reasonAllReplicasAvailable,
+							ObservedGeneration: 2,
+						},
+						{
+							Type:               conditionDegraded,
+							Status:             metav1.ConditionFalse,
+							Reason:             reasonAsExpected,
+							ObservedGeneration: 2,
+						},
+						{
+							Type:               conditionProgressing,
+							Status:             metav1.ConditionFalse,
+							Reason:             reasonPaused,
+							ObservedGeneration: 2,
+							Message:            "Updates are paused, observed 2 index(es) in need of update: 1, 2",
+						},
+					},
+					ObservedGeneration:  2,
+					Replicas:            3,
+					ReadyReplicas:       3,
+					UpdatedReplicas:     1,
+					UnavailableReplicas: 0,
+				},
+			}),
+			Entry("with up to date Machines, and updates are paused", &reconcileStatusTableInput{
+				cpmsBuilder: &machinev1resourcebuilder.ControlPlaneMachineSetFuncs{
+					BuildFunc: func() *machinev1.ControlPlaneMachineSet {
+						cpms := machinev1resourcebuilder.ControlPlaneMachineSet().WithGeneration(1).Build()
+						cpms.Annotations = map[string]string{pausedAnnotation: ""}
+
+						return cpms
+					},
+				},
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+					1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+					2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+				},
+				expectedError: nil,
+				expectedStatus: machinev1.ControlPlaneMachineSetStatus{
+					Conditions: []metav1.Condition{
+						{
+							Type:               conditionAvailable,
+							Status:             metav1.ConditionTrue,
+							Reason:             reasonAllReplicasAvailable,
+							ObservedGeneration: 1,
+						},
+						{
+							Type:               conditionDegraded,
+							Status:             metav1.ConditionFalse,
+							Reason:             reasonAsExpected,
+							ObservedGeneration: 1,
+						},
+						{
+							Type:               conditionProgressing,
+							Status:             metav1.ConditionFalse,
+							Reason:             reasonAllReplicasUpdated,
+							ObservedGeneration: 1,
+						},
+					},
+					ObservedGeneration:  1,
+					Replicas:            3,
+					ReadyReplicas:       3,
+					UpdatedReplicas:     3,
+					UnavailableReplicas: 0,
+				},
+			}),
+			Entry("when Machines need updates, and some are held back by the partition", &reconcileStatusTableInput{
+				cpmsBuilder: &machinev1resourcebuilder.ControlPlaneMachineSetFuncs{
+					BuildFunc: func() *machinev1.ControlPlaneMachineSet {
+						cpms := machinev1resourcebuilder.ControlPlaneMachineSet().WithStrategyType(machinev1.RollingUpdate).WithGeneration(2).Build()
+						cpms.Annotations = map[string]string{partitionAnnotation: "2"}
+
+						return cpms
+					},
+				},
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+					1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").WithNeedsUpdate(true).Build()},
+					2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").WithNeedsUpdate(true).Build()},
+				},
+				expectedError: nil,
+				expectedStatus: machinev1.ControlPlaneMachineSetStatus{
+					Conditions: []metav1.Condition{
+						{
+							Type:               conditionAvailable,
+							Status:             metav1.ConditionTrue,
+							Reason:             reasonAllReplicasAvailable,
+							ObservedGeneration: 2,
+						},
+						{
+							Type:               conditionDegraded,
+							Status:             metav1.ConditionFalse,
+							Reason:             reasonAsExpected,
+							ObservedGeneration: 2,
+						},
+						{
+							Type:               conditionProgressing,
+							Status:             metav1.ConditionTrue,
+							Reason:             reasonNeedsUpdateReplicas,
+							ObservedGeneration: 2,
+							Message:            "Observed 2 replica(s) in need of update, 1 replica(s) held back by partition 2",
+						},
+					},
+					ObservedGeneration:  2,
+					Replicas:            3,
+					ReadyReplicas:       3,
+					UpdatedReplicas:     1,
+					UnavailableReplicas: 0,
+				},
+			}),
+			Entry("when Machines need updates, and all are held back by the partition", &reconcileStatusTableInput{
+				cpmsBuilder: &machinev1resourcebuilder.ControlPlaneMachineSetFuncs{
+					BuildFunc: func() *machinev1.ControlPlaneMachineSet {
+						cpms := machinev1resourcebuilder.ControlPlaneMachineSet().WithStrategyType(machinev1.RollingUpdate).WithGeneration(2).Build()
+						cpms.Annotations = map[string]string{partitionAnnotation: "3"}
+
+						return cpms
+					},
+				},
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+					1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").WithNeedsUpdate(true).Build()},
+					2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").WithNeedsUpdate(true).Build()},
+				},
+				expectedError: nil,
+				expectedStatus: machinev1.ControlPlaneMachineSetStatus{
+					Conditions: []metav1.Condition{
+						{
+							Type:               conditionAvailable,
+							Status:             metav1.ConditionTrue,
+							Reason:             reasonAllReplicasAvailable,
+							ObservedGeneration: 2,
+						},
+						{
+							Type:               conditionDegraded,
+							Status:             metav1.ConditionFalse,
+							Reason:             reasonAsExpected,
+							ObservedGeneration: 2,
+						},
+						{
+							Type:               conditionProgressing,
+							Status:             metav1.ConditionFalse,
+							Reason:             reasonPartitioned,
+							ObservedGeneration: 2,
+							Message:            "Observed 2 replica(s) in need of update held back by partition 3",
+						},
+					},
+					ObservedGeneration:  2,
+					Replicas:            3,
+					ReadyReplicas:       3,
+					UpdatedReplicas:     1,
+					UnavailableReplicas: 0,
+				},
+			}),
 			Entry("with pending replacement replicas", &reconcileStatusTableInput{
 				cpmsBuilder: machinev1resourcebuilder.ControlPlaneMachineSet().WithGeneration(3),
 				machineInfos: map[int32][]machineproviders.MachineInfo{
@@ -349,6 +527,356 @@ var _ = Describe("Status", func() {
 					},
 				},
 			}),
+			Entry("with ready replacement replicas, and a minimum ready duration", &reconcileStatusTableInput{
+				cpmsBuilder: &machinev1resourcebuilder.ControlPlaneMachineSetFuncs{
+					BuildFunc: func() *machinev1.ControlPlaneMachineSet {
+						cpms := machinev1resourcebuilder.ControlPlaneMachineSet().WithStrategyType(machinev1.RollingUpdate).WithGeneration(4).Build()
+						cpms.Annotations = map[string]string{minReadySecondsAnnotation: "300"}
+
+						return cpms
+					},
+				},
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+					1: {
+						updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").WithNeedsUpdate(true).Build(),
+						updatedMachineBuilder.WithIndex(1).WithMachineName("machine-replacement-1").WithNodeName("node-replacement-1").WithReadySince(metav1.NewTime(time.Now().Add(-10 * time.Second))).Build(),
+					},
+					2: {
+						updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").WithNeedsUpdate(true).Build(),
+						updatedMachineBuilder.WithIndex(2).WithMachineName("machine-replacement-2").WithNodeName("node-replacement-2").WithReadySince(metav1.NewTime(time.Now().Add(-10 * time.Minute))).Build(),
+					},
+				},
+				expectedError: nil,
+				expectedStatus: machinev1.ControlPlaneMachineSetStatus{
+					Conditions: []metav1.Condition{
+						{
+							Type:               conditionAvailable,
+							Status:             metav1.ConditionTrue,
+							Reason:             reasonAllReplicasAvailable,
+							ObservedGeneration: 4,
+						},
+						{
+							Type:               conditionDegraded,
+							Status:             metav1.ConditionFalse,
+							Reason:             reasonAsExpected,
+							ObservedGeneration: 4,
+						},
+						{
+							Type:               conditionProgressing,
+							Status:             metav1.ConditionTrue,
+							Reason:             reasonExcessReplicas,
+							ObservedGeneration: 4,
+							Message:            "Waiting for 2 old replica(s) to be removed, 1 replacement replica(s) must be ready for 300 second(s) before the old replica(s) are removed",
+						},
+					},
+					ObservedGeneration:  4,
+					Replicas:            5,
+					ReadyReplicas:       5,
+					UpdatedReplicas:     3,
+					UnavailableReplicas: 0,
+				},
+			}),
+			Entry("with an excess index, and reconciling excess indexes enabled, and the excess index marked for migration", &reconcileStatusTableInput{
+				cpmsBuilder: &machinev1resourcebuilder.ControlPlaneMachineSetFuncs{
+					BuildFunc: func() *machinev1.ControlPlaneMachineSet {
+						cpms := machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).WithGeneration(4).Build()
+						cpms.Annotations = map[string]string{reconcileExcessIndexesAnnotation: ""}
+
+						return cpms
+					},
+				},
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+					1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+					2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+					3: {updatedMachineBuilder.WithIndex(3).WithMachineName("machine-3").WithNodeName("node-3").WithMachineAnnotations(map[string]string{
+						migrateToIndexAnnotation: "2",
+					}).Build()},
+				},
+				expectedError: nil,
+				expectedStatus: machinev1.ControlPlaneMachineSetStatus{
+					Conditions: []metav1.Condition{
+						{
+							Type:               conditionAvailable,
+							Status:             metav1.ConditionTrue,
+							Reason:             reasonAllReplicasAvailable,
+							ObservedGeneration: 4,
+						},
+						{
+							Type:               conditionDegraded,
+							Status:             metav1.ConditionFalse,
+							Reason:             reasonAsExpected,
+							ObservedGeneration: 4,
+						},
+						{
+							Type:               conditionProgressing,
+							Status:             metav1.ConditionTrue,
+							Reason:             reasonReconcilingExcessIndexes,
+							ObservedGeneration: 4,
+							Message:            "Observed 1 index(es) in excess, migrating excess index 3 into index 2",
+						},
+					},
+					ObservedGeneration:  4,
+					Replicas:            4,
+					ReadyReplicas:       4,
+					UpdatedReplicas:     4,
+					UnavailableReplicas: 0,
+				},
+			}),
+			Entry("with an excess index and a missing index, and reconciling excess indexes enabled", &reconcileStatusTableInput{
+				cpmsBuilder: &machinev1resourcebuilder.ControlPlaneMachineSetFuncs{
+					BuildFunc: func() *machinev1.ControlPlaneMachineSet {
+						cpms := machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).WithGeneration(4).Build()
+						cpms.Annotations = map[string]string{reconcileExcessIndexesAnnotation: ""}
+
+						return cpms
+					},
+				},
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+					1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+					3: {updatedMachineBuilder.WithIndex(3).WithMachineName("machine-3").WithNodeName("node-3").Build()},
+				},
+				expectedError: nil,
+				expectedStatus: machinev1.ControlPlaneMachineSetStatus{
+					Conditions: []metav1.Condition{
+						{
+							Type:               conditionAvailable,
+							Status:             metav1.ConditionTrue,
+							Reason:             reasonAllReplicasAvailable,
+							ObservedGeneration: 4,
+						},
+						{
+							Type:               conditionDegraded,
+							Status:             metav1.ConditionFalse,
+							Reason:             reasonAsExpected,
+							ObservedGeneration: 4,
+						},
+						{
+							Type:               conditionProgressing,
+							Status:             metav1.ConditionTrue,
+							Reason:             reasonReconcilingExcessIndexes,
+							ObservedGeneration: 4,
+							Message:            "Observed 1 index(es) in excess, migrating excess index 3 into index 2",
+						},
+					},
+					ObservedGeneration:  4,
+					Replicas:            3,
+					ReadyReplicas:       3,
+					UpdatedReplicas:     3,
+					UnavailableReplicas: 0,
+				},
+			}),
+			Entry("with ready replacement replicas, and etcd members which are not yet healthy and voting", &reconcileStatusTableInput{
+				cpmsBuilder: machinev1resourcebuilder.ControlPlaneMachineSet().WithStrategyType(machinev1.RollingUpdate).WithGeneration(4),
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").WithEtcdMember(true, true).Build()},
+					1: {
+						updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").WithNeedsUpdate(true).WithEtcdMember(true, true).Build(),
+						updatedMachineBuilder.WithIndex(1).WithMachineName("machine-replacement-1").WithNodeName("node-replacement-1").WithEtcdMember(false, true).Build(),
+					},
+					2: {
+						updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").WithNeedsUpdate(true).WithEtcdMember(true, true).Build(),
+						updatedMachineBuilder.WithIndex(2).WithMachineName("machine-replacement-2").WithNodeName("node-replacement-2").WithEtcdMember(true, true).Build(),
+					},
+				},
+				expectedError: nil,
+				expectedStatus: machinev1.ControlPlaneMachineSetStatus{
+					Conditions: []metav1.Condition{
+						{
+							Type:               conditionAvailable,
+							Status:             metav1.ConditionTrue,
+							Reason:             reasonAllReplicasAvailable,
+							ObservedGeneration: 4,
+						},
+						{
+							Type:               conditionDegraded,
+							Status:             metav1.ConditionFalse,
+							Reason:             reasonAsExpected,
+							ObservedGeneration: 4,
+						},
+						{
+							Type:               conditionProgressing,
+							Status:             metav1.ConditionTrue,
+							Reason:             reasonExcessReplicas,
+							ObservedGeneration: 4,
+							Message:            "Waiting for 2 old replica(s) to be removed, 1 replacement replica(s) must have a healthy and voting etcd member before the old replica(s) are removed",
+						},
+					},
+					ObservedGeneration:  4,
+					Replicas:            5,
+					ReadyReplicas:       5,
+					UpdatedReplicas:     3,
+					UnavailableReplicas: 0,
+				},
+			}),
+			Entry("with a replacement replica which failed over to another failure domain", &reconcileStatusTableInput{
+				cpmsBuilder: machinev1resourcebuilder.ControlPlaneMachineSet().WithStrategyType(machinev1.RollingUpdate).WithGeneration(5),
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+					1: {
+						updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").WithNeedsUpdate(true).Build(),
+						pendingMachineBuilder.WithIndex(1).WithMachineName("machine-failed-1").WithNeedsUpdate(true).WithErrorMessage("InsufficientInstanceCapacity").
+							WithFailureDomainFailover("AWSFailureDomain{AZ:us-east-1b}", "AWSFailureDomain{AZ:us-east-1d}").Build(),
+					},
+					2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+				},
+				expectedError: nil,
+				expectedStatus: machinev1.ControlPlaneMachineSetStatus{
+					Conditions: []metav1.Condition{
+						{
+							Type:               conditionAvailable,
+							Status:             metav1.ConditionTrue,
+							Reason:             reasonAllReplicasAvailable,
+							ObservedGeneration: 5,
+						},
+						{
+							Type:               conditionDegraded,
+							Status:             metav1.ConditionFalse,
+							Reason:             reasonAsExpected,
+							ObservedGeneration: 5,
+						},
+						{
+							Type:               conditionProgressing,
+							Status:             metav1.ConditionTrue,
+							Reason:             reasonNeedsUpdateReplicas,
+							ObservedGeneration: 5,
+							Message:            "Observed 1 replica(s) in need of update, index 1 failed over from failure domain AWSFailureDomain{AZ:us-east-1b} to AWSFailureDomain{AZ:us-east-1d} for lack of capacity",
+						},
+					},
+					ObservedGeneration:  5,
+					Replicas:            4,
+					ReadyReplicas:       3,
+					UpdatedReplicas:     2,
+					UnavailableReplicas: 0,
+				},
+			}),
+			Entry("with up to date Machines, and differences in ignored fields", &reconcileStatusTableInput{
+				cpmsBuilder: machinev1resourcebuilder.ControlPlaneMachineSet().WithGeneration(2),
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").WithIgnoredDiff([]string{"Tags: <nil slice> != [{owner external-tool}]"}).Build()},
+					1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+					2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").WithIgnoredDiff([]string{"Tags: <nil slice> != [{owner external-tool}]"}).Build()},
+				},
+				expectedError: nil,
+				expectedStatus: machinev1.ControlPlaneMachineSetStatus{
+					Conditions: []metav1.Condition{
+						{
+							Type:               conditionAvailable,
+							Status:             metav1.ConditionTrue,
+							Reason:             reasonAllReplicasAvailable,
+							ObservedGeneration: 2,
+						},
+						{
+							Type:               conditionDegraded,
+							Status:             metav1.ConditionFalse,
+							Reason:             reasonAsExpected,
+							ObservedGeneration: 2,
+						},
+						{
+							Type:               conditionProgressing,
+							Status:             metav1.ConditionFalse,
+							Reason:             reasonAllReplicasUpdated,
+							ObservedGeneration: 2,
+							Message:            "2 replica(s) have differences in ignored fields",
+						},
+					},
+					ObservedGeneration:  2,
+					Replicas:            3,
+					ReadyReplicas:       3,
+					UpdatedReplicas:     3,
+					UnavailableReplicas: 0,
+				},
+			}),
+			Entry("when Machines need updates only because they exceeded the maximum machine age", &reconcileStatusTableInput{
+				cpmsBuilder: &machinev1resourcebuilder.ControlPlaneMachineSetFuncs{
+					BuildFunc: func() *machinev1.ControlPlaneMachineSet {
+						cpms := machinev1resourcebuilder.ControlPlaneMachineSet().WithStrategyType(machinev1.RollingUpdate).WithGeneration(2).Build()
+						cpms.Annotations = map[string]string{util.MaxMachineAgeAnnotation: "86400"}
+
+						return cpms
+					},
+				},
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+					1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").WithAgeExceeded(true).WithDiff([]string{"Machine has exceeded the maximum machine age"}).Build()},
+					2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").WithAgeExceeded(true).WithDiff([]string{"Machine has exceeded the maximum machine age"}).Build()},
+				},
+				expectedError: nil,
+				expectedStatus: machinev1.ControlPlaneMachineSetStatus{
+					Conditions: []metav1.Condition{
+						{
+							Type:               conditionAvailable,
+							Status:             metav1.ConditionTrue,
+							Reason:             reasonAllReplicasAvailable,
+							ObservedGeneration: 2,
+						},
+						{
+							Type:               conditionDegraded,
+							Status:             metav1.ConditionFalse,
+							Reason:             reasonAsExpected,
+							ObservedGeneration: 2,
+						},
+						{
+							Type:               conditionProgressing,
+							Status:             metav1.ConditionTrue,
+							Reason:             reasonMachineAgeExceeded,
+							ObservedGeneration: 2,
+							Message:            "Observed 2 replica(s) in need of update, 2 replica(s) exceeded the maximum machine age of 24h0m0s",
+						},
+					},
+					ObservedGeneration:  2,
+					Replicas:            3,
+					ReadyReplicas:       3,
+					UpdatedReplicas:     1,
+					UnavailableReplicas: 0,
+				},
+			}),
+			Entry("when Machines need updates, and some exceeded the maximum machine age", &reconcileStatusTableInput{
+				cpmsBuilder: &machinev1resourcebuilder.ControlPlaneMachineSetFuncs{
+					BuildFunc: func() *machinev1.ControlPlaneMachineSet {
+						cpms := machinev1resourcebuilder.ControlPlaneMachineSet().WithStrategyType(machinev1.RollingUpdate).WithGeneration(2).Build()
+						cpms.Annotations = map[string]string{util.MaxMachineAgeAnnotation: "86400"}
+
+						return cpms
+					},
+				},
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+					1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").WithAgeExceeded(true).WithDiff([]string{"Machine has exceeded the maximum machine age"}).Build()},
+					2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").WithDiff([]string{"InstanceType: m6i.xlarge != m6i.2xlarge"}).Build()},
+				},
+				expectedError: nil,
+				expectedStatus: machinev1.ControlPlaneMachineSetStatus{
+					Conditions: []metav1.Condition{
+						{
+							Type:               conditionAvailable,
+							Status:             metav1.ConditionTrue,
+							Reason:             reasonAllReplicasAvailable,
+							ObservedGeneration: 2,
+						},
+						{
+							Type:               conditionDegraded,
+							Status:             metav1.ConditionFalse,
+							Reason:             reasonAsExpected,
+							ObservedGeneration: 2,
+						},
+						{
+							Type:               conditionProgressing,
+							Status:             metav1.ConditionTrue,
+							Reason:             reasonNeedsUpdateReplicas,
+							ObservedGeneration: 2,
+							Message:            "Observed 2 replica(s) in need of update, 1 replica(s) exceeded the maximum machine age of 24h0m0s",
+						},
+					},
+					ObservedGeneration:  2,
+					Replicas:            3,
+					ReadyReplicas:       3,
+					UpdatedReplicas:     1,
+					UnavailableReplicas: 0,
+				},
+			}),
 			Entry("with ready replacement replicas", &reconcileStatusTableInput{
 				cpmsBuilder: machinev1resourcebuilder.ControlPlaneMachineSet().WithGeneration(4),
 				machineInfos: map[int32][]machineproviders.MachineInfo{
@@ -617,4 +1145,58 @@ var _ = Describe("Status", func() {
 			}),
 		)
 	})
+
+	Context("progressingConditionStep", func() {
+		type appliesToTableInput struct {
+			step          progressingConditionStep
+			strategy      machinev1.ControlPlaneMachineSetStrategyType
+			annotations   map[string]string
+			reason        string
+			expectApplied bool
+		}
+
+		DescribeTable("determines whether the step applies", func(in appliesToTableInput) {
+			cpms := machinev1resourcebuilder.ControlPlaneMachineSet().WithStrategyType(in.strategy).Build()
+			cpms.Annotations = in.annotations
+
+			Expect(in.step.appliesTo(cpms, metav1.Condition{Reason: in.reason})).To(Equal(in.expectApplied))
+		},
+			Entry("with no restrictions", appliesToTableInput{
+				strategy:      machinev1.OnDelete,
+				reason:        reasonAllReplicasUpdated,
+				expectApplied: true,
+			}),
+			Entry("with a matching reason", appliesToTableInput{
+				step:          progressingConditionStep{reasons: []string{reasonNeedsUpdateReplicas, reasonExcessReplicas}},
+				strategy:      machinev1.RollingUpdate,
+				reason:        reasonExcessReplicas,
+				expectApplied: true,
+			}),
+			Entry("with a different reason", appliesToTableInput{
+				step:          progressingConditionStep{reasons: []string{reasonNeedsUpdateReplicas}},
+				strategy:      machinev1.RollingUpdate,
+				reason:        reasonPaused,
+				expectApplied: false,
+			}),
+			Entry("with a RollingUpdate only step and the OnDelete strategy", appliesToTableInput{
+				step:          progressingConditionStep{rollingUpdateOnly: true},
+				strategy:      machinev1.OnDelete,
+				reason:        reasonNeedsUpdateReplicas,
+				expectApplied: false,
+			}),
+			Entry("with a step which has not been configured", appliesToTableInput{
+				step:          progressingConditionStep{enabled: hasPartitionAnnotation},
+				strategy:      machinev1.RollingUpdate,
+				reason:        reasonNeedsUpdateReplicas,
+				expectApplied: false,
+			}),
+			Entry("with a step which has been configured", appliesToTableInput{
+				step:          progressingConditionStep{enabled: hasPartitionAnnotation},
+				strategy:      machinev1.RollingUpdate,
+				annotations:   map[string]string{partitionAnnotation: "1"},
+				reason:        reasonNeedsUpdateReplicas,
+				expectApplied: true,
+			}),
+		)
+	})
 })
@@ -52,6 +52,10 @@ const (
 	// for the update strategy.
 	invalidStrategyMessage = "invalid value for spec.strategy.type"
 
+	// invalidAnnotationMessage is used to inform the user that they have provided an invalid value
+	// for an annotation configuring the behaviour of the update strategy.
+	invalidAnnotationMessage = "invalid value for control plane machine set annotation"
+
 	// machineRequiresUpdate is a log message used to inform the user that a Machine requires an update.
 	// This is used with the RollingUpdate replacement strategy.
 	machineRequiresUpdate = "Machine requires an update"
@@ -88,6 +92,30 @@ const (
 	// This is used when replacing a Machine within an index.
 	waitingForReplacement = "Waiting for replacement machine to become ready"
 
+	// heldBackByPartition is a log message used to inform the user that a Machine requires an update,
+	// but that its index is below the partition and so it will not be replaced.
+	// This is used with the RollingUpdate replacement strategy.
+	heldBackByPartition = "Machine requires an update, but its index is held back by the partition"
+
+	// waitingForMinReady is a log message used to inform the user that no operations are taking
+	// place because the rollout is waiting for a replacement Machine to have been ready for the minimum ready duration.
+	// This is used with the RollingUpdate replacement strategy.
+	waitingForMinReady = "Waiting for replacement machine to be ready for the minimum ready duration"
+
+	// waitingForEtcdMember is a log message used to inform the user that no operations are taking place because
+	// the rollout is waiting for the etcd member of a replacement Machine to be healthy and voting.
+	// This is used with the RollingUpdate replacement strategy.
+	waitingForEtcdMember = "Waiting for the etcd member of the replacement machine to be healthy and voting"
+
+	// heldBackByMaintenanceWindow is a log message used to inform the user that Machines require an update,
+	// but that no maintenance window is open and so they will not be replaced until the next window opens.
+	// This is used with the RollingUpdate replacement strategy.
+	heldBackByMaintenanceWindow = "Machines require an update, but are held back until the next maintenance window"
+
+	// updatesPaused is a log message used to inform the user that no operations are taking place
+	// because updates have been paused on the control plane machine set.
+	updatesPaused = "Updates are paused, no machines will be created or deleted"
+
 	// unknownMachineName is a value used for logging new machines when we do not know the name
 	// of the upcoming machine. This can occur when all machines have been removed from an index
 	// and a new one will be created.
@@ -110,12 +138,51 @@ var (
 // reconcileMachineUpdates determines if any Machines are in need of an update and then handles those updates as per the
 // update strategy within the ControlPlaneMachineSet.
 // When a Machine needs an update, this function should create a replacement where appropriate.
+// Each step configured by the annotations on the ControlPlaneMachineSet runs before the update strategy, and may
+// hold it back.
 func (r *ControlPlaneMachineSetReconciler) reconcileMachineUpdates(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, machineProvider machineproviders.MachineProvider, machineInfos map[int32][]machineproviders.MachineInfo) (ctrl.Result, error) {
+	if isPaused(cpms) {
+		// The status is still reported while updates are paused, but no Machines may be created or deleted.
+		logger.V(2).WithValues("outdatedIndexes", outdatedIndexes(machineInfos)).Info(updatesPaused)
+
+		return ctrl.Result{}, nil
+	}
+
+	if done, result, err := r.reconcileStabilityGate(ctx, logger, cpms, machineInfos); err != nil {
+		return result, err
+	} else if done {
+		return result, nil
+	}
+
+	if done, result, err := r.reconcileProgressDeadline(ctx, logger, cpms, machineProvider, machineInfos); err != nil {
+		return result, err
+	} else if done {
+		return result, nil
+	}
+
+	if done, result, err := r.reconcileFailedReplacements(ctx, logger, cpms, machineProvider, machineInfos); err != nil {
+		return result, err
+	} else if done {
+		return result, nil
+	}
+
+	if done, result, err := r.reconcileExcessIndexes(ctx, logger, cpms, machineProvider, machineInfos); err != nil {
+		return result, err
+	} else if done {
+		return result, nil
+	}
+
+	if done, result, err := r.reconcileRemediation(ctx, logger, cpms, machineProvider, machineInfos); err != nil {
+		return result, err
+	} else if done {
+		return result, nil
+	}
+
 	switch cpms.Spec.Strategy.Type {
 	case machinev1.RollingUpdate:
-		return r.reconcileMachineRollingUpdate(ctx, logger, cpms, machineProvider, machineInfos)
+		return r.withReplacementBackoff(r.reconcileMachineRollingUpdate(ctx, logger, cpms, machineProvider, machineInfos))
 	case machinev1.OnDelete:
-		return r.reconcileMachineOnDeleteUpdate(ctx, logger, cpms, machineProvider, machineInfos)
+		return r.withReplacementBackoff(r.reconcileMachineOnDeleteUpdate(ctx, logger, cpms, machineProvider, machineInfos))
 	case machinev1.Recreate:
 		meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
 			Type:    conditionDegraded,
@@ -142,14 +209,29 @@ func (r *ControlPlaneMachineSetReconciler) reconcileMachineUpdates(ctx context.C
 	return ctrl.Result{}, nil
 }
 
+// withReplacementBackoff ensures that the ControlPlaneMachineSet is requeued once the backoff of any failed
+// replacement has elapsed, so that a new replacement Machine can be created.
+func (r *ControlPlaneMachineSetReconciler) withReplacementBackoff(result ctrl.Result, err error) (ctrl.Result, error) {
+	if err != nil {
+		return result, err
+	}
+
+	backoff := r.nextReplacementBackoff(time.Now())
+	if backoff > 0 && (result.RequeueAfter == 0 || backoff < result.RequeueAfter) {
+		result.RequeueAfter = backoff
+	}
+
+	return result, nil
+}
+
 // reconcileMachineRollingUpdate implements the rolling update strategy for the ControlPlaneMachineSet. It uses the
 // indexed machine information to determine when a new Machine is required to be created. When a new Machine is required,
 // it uses the machine provider to create the new Machine.
 //
 // For rolling updates, a new Machine is required when a machine index has a Machine, which needs an update, but does
-// not yet have replacement created. It must also observe the surge semantics of a rolling update, so, if an existing
-// index is already going through the process of a rolling update, it should not start the update of any other index.
-// At present, the surge is limited to a single Machine instance.
+// not yet have replacement created. It must also observe the surge semantics of a rolling update, so, if the number of
+// indexes already going through the process of a rolling update has reached the maximum surge, it should not start the
+// update of any other index.
 //
 // Once a replacement Machine is ready, the strategy should also delete the old Machine to allow it to be removed from
 // the cluster.
@@ -157,33 +239,72 @@ func (r *ControlPlaneMachineSetReconciler) reconcileMachineUpdates(ctx context.C
 // In certain scenarios, there may be indexes with missing Machines. In these circumstances, the update should attempt
 // to create a new Machine to fulfil the requirement of that index.
 //
+// The surge, partition, minimum ready duration, maintenance windows and replacement order are configured by
+// annotations, see getRollingUpdateParameters.
+//
 //nolint:cyclop
 func (r *ControlPlaneMachineSetReconciler) reconcileMachineRollingUpdate(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, machineProvider machineproviders.MachineProvider, indexedMachineInfos map[int32][]machineproviders.MachineInfo) (ctrl.Result, error) {
 	logger = logger.WithValues("updateStrategy", cpms.Spec.Strategy.Type)
 
+	// The maximum number of machines that can be scheduled above the original number of desired machines,
+	// the partition below which outdated Machines are not replaced, the minimum duration for which a
+	// replacement Machine must be Ready before the outdated Machine is deleted, and the order in which
+	// outdated indexes are replaced.
+	params, err := getRollingUpdateParameters(cpms)
+	if err != nil {
+		setInvalidAnnotationDegraded(logger, cpms, err)
+
+		// Do not return an error here as the annotation will need user intervention to resolve.
+		return ctrl.Result{}, nil
+	}
+
 	// To ensure an ordered and safe reconciliation,
 	// one index at a time is considered.
-	// Indexes are sorted in ascending order, so that all the operations of the same importance,
-	// are executed prioritizing the lower indexes first.
-	sortedIndexedMs := sortMachineInfosByIndex(indexedMachineInfos)
+	// Indexes are sorted according to the replacement order, so that all the operations of the same importance,
+	// are executed prioritizing the indexes which should be replaced first.
+	sortedIndexedMs := sortMachineInfosForReplacement(indexedMachineInfos, params.order)
 
-	// The maximum number of machines that
-	// can be scheduled above the original number of desired machines.
-	// At present, the surge is limited to a single Machine instance.
-	// NOTE: If this gets changed or parametrized,
-	// the tests will need to be updated accordingly.
-	maxSurge := 1
 	// Devise the existing surge and keep track of the current surge count.
 	// No check for early stoppage is done here,
 	// as deletions can continue even if the maxSurge has been already reached.
 	surgeCount := deviseExistingSurge(cpms, sortedIndexedMs)
 
+	now := time.Now()
+	outsideMaintenanceWindow := !params.maintenanceWindows.isOpen(now)
+
+	if outdated := outdatedIndexes(indexedMachineInfos); outsideMaintenanceWindow && len(outdated) > 0 {
+		logger.V(2).WithValues("outdatedIndexes", outdated, "nextMaintenanceWindow", params.maintenanceWindows.nextStart(now)).Info(heldBackByMaintenanceWindow)
+	}
+
 	var updated, shouldRequeue bool
 
 	for _, indexToMachines := range sortedIndexedMs {
 		idx := indexToMachines.index
 		machines := indexToMachines.machineInfos
 
+		heldBack := outsideMaintenanceWindow && !params.maintenanceWindows.isExempt(machines)
+		if heldBack && !hasStartedReplacement(machines) {
+			// No Machines may be created or deleted for this index until the next maintenance window opens.
+			continue
+		}
+
+		if r.waitForMinReadyMachine(logger, machines, params.minReady) {
+			// The outdated Machine must not be deleted until the replacement has been Ready for the minimum duration.
+			// Node readiness does not generate Machine events, so a manual requeue is needed to observe the elapsed time.
+			updated, shouldRequeue = true, true
+
+			continue
+		}
+
+		if r.waitForEtcdMember(logger, machines) {
+			// The outdated Machine must not be deleted until the etcd member of the replacement is healthy and voting,
+			// otherwise the etcd cluster would temporarily lose a voting member.
+			// Etcd membership changes do not generate Machine events, so a manual requeue is needed.
+			updated, shouldRequeue = true, true
+
+			continue
+		}
+
 		if done, result, err := r.deleteReplacedMachines(ctx, logger, machineProvider, machines); err != nil {
 			return result, err
 		} else if done {
@@ -201,7 +322,19 @@ func (r *ControlPlaneMachineSetReconciler) reconcileMachineRollingUpdate(ctx con
 			updated = true
 		}
 
-		if done, result, err := r.createRollingUpdateReplacementMachines(ctx, logger, machineProvider, machines, idx, maxSurge, &surgeCount); err != nil {
+		if heldBack {
+			// The started replacement has been completed, no new replacement may be created until the next
+			// maintenance window opens.
+			continue
+		}
+
+		if isHeldBackByPartition(idx, params.partition, machines) {
+			logger.V(2).WithValues("index", idx, "partition", params.partition).Info(heldBackByPartition)
+
+			continue
+		}
+
+		if done, result, err := r.createRollingUpdateReplacementMachines(ctx, logger, machineProvider, machines, idx, params.maxSurge, &surgeCount); err != nil {
 			return result, err
 		} else if done {
 			updated = true
@@ -212,11 +345,21 @@ func (r *ControlPlaneMachineSetReconciler) reconcileMachineRollingUpdate(ctx con
 		logger.V(4).Info(noUpdatesRequired)
 	}
 
+	result := ctrl.Result{}
+
 	if shouldRequeue {
-		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+		result.RequeueAfter = 5 * time.Second
 	}
 
-	return ctrl.Result{}, nil
+	if outsideMaintenanceWindow {
+		// Nothing else may trigger a reconcile when the next maintenance window opens.
+		untilNextWindow := params.maintenanceWindows.nextStart(now).Sub(now)
+		if result.RequeueAfter == 0 || untilNextWindow < result.RequeueAfter {
+			result.RequeueAfter = untilNextWindow
+		}
+	}
+
+	return result, nil
 }
 
 // reconcileMachineOnDeleteUpdate implements the rolling update strategy for the ControlPlaneMachineSet. It uses the
@@ -325,6 +468,36 @@ func (r *ControlPlaneMachineSetReconciler) waitForReplacementMachine(logger logr
 	return false
 }
 
+// waitForMinReadyMachine checks if the index contains an outdated Ready Machine, which is due to be deleted,
+// and whose replacement has not yet been Ready for the minimum ready duration.
+func (r *ControlPlaneMachineSetReconciler) waitForMinReadyMachine(logger logr.Logger, machines []machineproviders.MachineInfo, minReady time.Duration) bool {
+	if !isWaitingForMinReady(machines, minReady, time.Now()) {
+		return false
+	}
+
+	replacementMachine := updatedMachines(machines)[0]
+
+	logger = logger.WithValues("index", replacementMachine.Index, "namespace", r.Namespace, "name", replacementMachine.MachineRef.ObjectMeta.Name)
+	logger.V(2).WithValues("minReadySeconds", int64(minReady/time.Second)).Info(waitingForMinReady)
+
+	return true
+}
+
+// waitForEtcdMember checks if the outdated Machine of an index must not yet be deleted because the etcd member of
+// its replacement Machine is not yet healthy and voting.
+func (r *ControlPlaneMachineSetReconciler) waitForEtcdMember(logger logr.Logger, machines []machineproviders.MachineInfo) bool {
+	if !isWaitingForEtcdMember(machines) {
+		return false
+	}
+
+	replacementMachine := updatedMachines(machines)[0]
+
+	logger = logger.WithValues("index", replacementMachine.Index, "namespace", r.Namespace, "name", replacementMachine.MachineRef.ObjectMeta.Name)
+	logger.V(2).WithValues("etcdMemberVoting", replacementMachine.EtcdMember.Voting, "etcdMemberHealthy", replacementMachine.EtcdMember.Healthy).Info(waitingForEtcdMember)
+
+	return true
+}
+
 // waitForRemoveMachine checks machines and finds out whether to wait or not for any of them to be removed.
 func (r *ControlPlaneMachineSetReconciler) waitForRemoveMachine(logger logr.Logger, machines []machineproviders.MachineInfo) bool {
 	machinesDeleting := deletingMachines(machines)
@@ -350,6 +523,12 @@ func (r *ControlPlaneMachineSetReconciler) deleteReplacedMachines(ctx context.Co
 	machinesUpdated := updatedMachines(machines)
 	machinesOutdatedNonReady := nonReadyMachines(machinesNeedingReplacement)
 
+	if isEmpty(machinesUpdated) && isEmpty(pendingMachines(machines)) {
+		// A Machine which failed over to another failure domain must be kept until its replacement has been created,
+		// as the index is only mapped to the other failure domain while the failed Machine exists.
+		machinesOutdatedNonReady = nonFailedOverMachines(machinesOutdatedNonReady)
+	}
+
 	var toDeleteMachine machineproviders.MachineInfo
 
 	if hasAny(machinesNeedingReplacement) && hasAny(machinesUpdated) {
@@ -500,6 +679,14 @@ func deleteMachine(ctx context.Context, logger logr.Logger, machineProvider mach
 
 // createMachine checks if a machine already exists and otherwise creates the Machine provided.
 func (r *ControlPlaneMachineSetReconciler) createMachine(ctx context.Context, logger logr.Logger, machineProvider machineproviders.MachineProvider, idx int32) (bool, ctrl.Result, error) { //nolint:unparam
+	// A previous replacement for this index failed and was removed.
+	// Wait for the backoff to elapse before creating a new replacement.
+	if backoff := r.replacementBackoff(idx, time.Now()); backoff > 0 {
+		logger.V(2).WithValues("backoff", backoff.Round(time.Second).String()).Info(waitingForReplacementBackoff)
+
+		return false, ctrl.Result{}, nil
+	}
+
 	// Check if a replacement machine already exists and
 	// was not previously detected due to potential stale cache.
 	exists, err := r.checkForExistingReplacement(ctx, logger, machineProvider, idx)
@@ -680,6 +867,130 @@ func nonReadyMachines(machinesInfo []machineproviders.MachineInfo) []machineprov
 	return result
 }
 
+// ignoredDifferencesMachines returns the list of non-deleted Machines which have differences in fields ignored by
+// the ignored fields annotation.
+func ignoredDifferencesMachines(machinesInfo []machineproviders.MachineInfo) []machineproviders.MachineInfo {
+	result := []machineproviders.MachineInfo{}
+
+	for _, m := range machinesInfo {
+		if len(m.IgnoredDiff) > 0 && m.MachineRef != nil && !isDeletedMachine(m) {
+			result = append(result, m)
+		}
+	}
+
+	return result
+}
+
+// outdatedIndexes returns the sorted list of indexes which do not yet have an Updated (Spec up-to-date and Ready) Machine
+// that is not pending deletion.
+func outdatedIndexes(indexedMachineInfos map[int32][]machineproviders.MachineInfo) []int32 {
+	result := []int32{}
+
+	for _, indexToMachines := range sortMachineInfosByIndex(indexedMachineInfos) {
+		if isEmpty(updatedNonDeletedMachines(indexToMachines.machineInfos)) {
+			result = append(result, indexToMachines.index)
+		}
+	}
+
+	return result
+}
+
+// isHeldBackByPartition determines whether the update of the index is held back by the partition.
+// An index below the partition is held back when it has an outdated Machine and no replacement has been created.
+// Indexes with a deleted Machine are never held back, so that the control plane can be restored to full health.
+func isHeldBackByPartition(idx, partition int32, machinesInfo []machineproviders.MachineInfo) bool {
+	return idx < partition &&
+		hasAny(needReplacementMachines(machinesInfo)) &&
+		isEmpty(deletingMachines(machinesInfo)) &&
+		isEmpty(updatedNonDeletedMachines(machinesInfo)) &&
+		isEmpty(pendingMachines(machinesInfo))
+}
+
+// heldBackIndexes returns the sorted list of indexes which are held back by the partition.
+func heldBackIndexes(indexedMachineInfos map[int32][]machineproviders.MachineInfo, partition int32) []int32 {
+	result := []int32{}
+
+	for _, indexToMachines := range sortMachineInfosByIndex(indexedMachineInfos) {
+		if isHeldBackByPartition(indexToMachines.index, partition, indexToMachines.machineInfos) {
+			result = append(result, indexToMachines.index)
+		}
+	}
+
+	return result
+}
+
+// isWaitingForMinReady determines whether the index contains an outdated Ready Machine, which is not yet marked for
+// deletion, and a single Updated replacement Machine, which has not yet been Ready for the minimum ready duration.
+// When the time at which the replacement became Ready is not known, the minimum ready duration is considered elapsed.
+func isWaitingForMinReady(machinesInfo []machineproviders.MachineInfo, minReady time.Duration, now time.Time) bool {
+	machinesNeedingReplacement := needReplacementMachines(machinesInfo)
+	machinesUpdated := updatedMachines(machinesInfo)
+
+	if minReady <= 0 || len(machinesUpdated) != 1 || isEmpty(machinesNeedingReplacement) {
+		return false
+	}
+
+	if hasAny(nonReadyMachines(machinesNeedingReplacement)) || hasAny(deletingMachines(machinesNeedingReplacement)) {
+		// Non-Ready or deleted outdated Machines do not contribute to the control plane,
+		// there is no benefit in waiting before removing them.
+		return false
+	}
+
+	readySince := machinesUpdated[0].ReadySince
+
+	return readySince != nil && now.Sub(readySince.Time) < minReady
+}
+
+// isWaitingForEtcdMember determines whether an index has a Ready outdated Machine and a Ready replacement Machine,
+// but the etcd member of the replacement Machine is not yet healthy and voting.
+// When the etcd membership is not known, there is nothing to wait for.
+func isWaitingForEtcdMember(machinesInfo []machineproviders.MachineInfo) bool {
+	machinesNeedingReplacement := needReplacementMachines(machinesInfo)
+	machinesUpdated := updatedMachines(machinesInfo)
+
+	if len(machinesUpdated) != 1 || isEmpty(machinesNeedingReplacement) {
+		return false
+	}
+
+	if hasAny(nonReadyMachines(machinesNeedingReplacement)) || hasAny(deletingMachines(machinesNeedingReplacement)) {
+		// Non-Ready or deleted outdated Machines do not contribute to the control plane,
+		// there is no benefit in waiting before removing them.
+		return false
+	}
+
+	etcdMember := machinesUpdated[0].EtcdMember
+
+	return etcdMember != nil && !(etcdMember.Voting && etcdMember.Healthy)
+}
+
+// etcdMemberIndexes returns the sorted list of indexes which are waiting for the etcd member of their replacement
+// Machine to be healthy and voting before the outdated Machine is removed.
+func etcdMemberIndexes(indexedMachineInfos map[int32][]machineproviders.MachineInfo) []int32 {
+	result := []int32{}
+
+	for _, indexToMachines := range sortMachineInfosByIndex(indexedMachineInfos) {
+		if isWaitingForEtcdMember(indexToMachines.machineInfos) {
+			result = append(result, indexToMachines.index)
+		}
+	}
+
+	return result
+}
+
+// minReadyIndexes returns the sorted list of indexes which are waiting for a replacement Machine to have been
+// Ready for the minimum ready duration.
+func minReadyIndexes(indexedMachineInfos map[int32][]machineproviders.MachineInfo, minReady time.Duration, now time.Time) []int32 {
+	result := []int32{}
+
+	for _, indexToMachines := range sortMachineInfosByIndex(indexedMachineInfos) {
+		if isWaitingForMinReady(indexToMachines.machineInfos, minReady, now) {
+			result = append(result, indexToMachines.index)
+		}
+	}
+
+	return result
+}
+
 // indexToMachineInfos pairs an index with a list of machineInfos.
 type indexToMachineInfos struct {
 	// index is the index of the machines represented in the MachineInfos.
@@ -728,12 +1039,16 @@ func machineInfosMaptoSlice(indexedMachineInfos map[int32][]machineproviders.Mac
 }
 
 // deviseExistingSurge computes the current amount of replicas surge for the ControlPlaneMachineSet.
+// Every Machine above the desired number of replicas, whether it is a pending replacement or an outdated Machine
+// awaiting removal, counts towards the surge, so that no more than the maximum surge indexes are replaced at once.
 func deviseExistingSurge(cpms *machinev1.ControlPlaneMachineSet, mis []indexToMachineInfos) int {
 	desiredReplicas := int(*cpms.Spec.Replicas)
 	currentReplicas := 0
 
 	for _, mi := range mis {
-		currentReplicas += len(mi.machineInfos)
+		// Machines which failed over to another failure domain failed for lack of capacity,
+		// they have no instance and do not count towards the surge.
+		currentReplicas += len(nonFailedOverMachines(mi.machineInfos))
 	}
 
 	return currentReplicas - desiredReplicas
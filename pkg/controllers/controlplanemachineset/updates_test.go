@@ -397,6 +397,95 @@ var _ = Describe("reconcileMachineUpdates", func() {
 					}
 				},
 			}),
+			Entry("with updates required in a single index, and the replacement machine failed over to another failure domain", rollingUpdateTableInput{
+				cpmsBuilder: cpmsBuilder.WithReplicas(3),
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+					1: {
+						updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").WithDiff(instanceDiff).Build(),
+						outdatedNonReadyMachineBuilder.WithIndex(1).WithMachineName("machine-failed-1").WithErrorMessage("InsufficientInstanceCapacity").
+							WithFailureDomainFailover("us-east-1a", "us-east-1d").Build(),
+					},
+					2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+				},
+				setupMock: func(machineInfos map[int32][]machineproviders.MachineInfo) {
+					mockMachineProvider.EXPECT().WithClient(gomock.Any(), gomock.Any(), gomock.Any()).Return(mockMachineProvider, nil).AnyTimes()
+					mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(machineInfos), nil).AnyTimes()
+					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return(nil).Times(1)
+					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				},
+				expectedLogsBuilder: func() []testutils.LogEntry {
+					return []testutils.LogEntry{
+						{
+							Level: 2,
+							KeysAndValues: []interface{}{
+								"updateStrategy", machinev1.RollingUpdate,
+								"index", int32(1),
+								"namespace", namespaceName,
+								"name", "machine-1",
+								"diff", instanceDiff,
+							},
+							Message: machineRequiresUpdate,
+						},
+						{
+							Level: 2,
+							KeysAndValues: []interface{}{
+								"updateStrategy", machinev1.RollingUpdate,
+								"index", int32(1),
+								"namespace", namespaceName,
+								"name", "machine-1",
+							},
+							Message: createdReplacement,
+						},
+					}
+				},
+			}),
+			Entry("with updates required in a single index, and the replacement for the machine which failed over to another failure domain is pending", rollingUpdateTableInput{
+				cpmsBuilder: cpmsBuilder.WithReplicas(3),
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+					1: {
+						updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").WithNeedsUpdate(true).Build(),
+						outdatedNonReadyMachineBuilder.WithIndex(1).WithMachineName("machine-failed-1").WithErrorMessage("InsufficientInstanceCapacity").
+							WithFailureDomainFailover("us-east-1a", "us-east-1d").Build(),
+						pendingMachineBuilder.WithIndex(1).WithMachineName("machine-replacement-1").Build(),
+					},
+					2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+				},
+				setupMock: func(machineInfos map[int32][]machineproviders.MachineInfo) {
+					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+					// We expect the failed machine to be removed now that its replacement has been created.
+					machineInfo := outdatedNonReadyMachineBuilder.WithIndex(1).WithMachineName("machine-failed-1").Build()
+					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), machineInfo.MachineRef).Return(nil).Times(1)
+				},
+				expectedLogsBuilder: func() []testutils.LogEntry {
+					return []testutils.LogEntry{
+						{
+							Level: 2,
+							KeysAndValues: []interface{}{
+								"updateStrategy", machinev1.RollingUpdate,
+								"index", int32(1),
+								"namespace", namespaceName,
+								"name", "machine-failed-1",
+							},
+							Message: removingOldMachine,
+						},
+						{
+							Level: 2,
+							KeysAndValues: []interface{}{
+								"updateStrategy", machinev1.RollingUpdate,
+								"index", int32(1),
+								"namespace", namespaceName,
+								"name", "machine-1",
+								"replacementName", "machine-replacement-1",
+							},
+							Message: waitingForReplacement,
+						},
+					}
+				},
+				expectedResult: ctrl.Result{RequeueAfter: 5 * time.Second},
+			}),
 			Entry("with updates are required in multiple indexes", rollingUpdateTableInput{
 				cpmsBuilder: cpmsBuilder.WithReplicas(3),
 				machineInfos: map[int32][]machineproviders.MachineInfo{
@@ -1296,6 +1385,649 @@ var _ = Describe("reconcileMachineUpdates", func() {
 				},
 			}),
 		)
+
+		Context("with a maximum surge configured", func() {
+			type maxSurgeTableInput struct {
+				replicas          int32
+				maxSurge          string
+				expectedReplacing []int32
+			}
+
+			DescribeTable("should replace indexes up to the effective maximum surge", func(in maxSurgeTableInput) {
+				cpms := cpmsBuilder.WithReplicas(in.replicas).Build()
+				cpms.Annotations = map[string]string{maxSurgeAnnotation: in.maxSurge}
+
+				machineInfos := map[int32][]machineproviders.MachineInfo{}
+				for i := int32(0); i < in.replicas; i++ {
+					// All but the first index need an update.
+					machineInfos[i] = []machineproviders.MachineInfo{
+						updatedMachineBuilder.WithIndex(i).WithMachineName(fmt.Sprintf("machine-%d", i)).WithNodeName(fmt.Sprintf("node-%d", i)).WithNeedsUpdate(i > 0).Build(),
+					}
+				}
+
+				mockMachineProvider.EXPECT().WithClient(gomock.Any(), gomock.Any(), gomock.Any()).Return(mockMachineProvider, nil).AnyTimes()
+				mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(machineInfos), nil).AnyTimes()
+				mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+				expectedLogs := []testutils.LogEntry{}
+
+				for i := int32(1); i < in.replicas; i++ {
+					message := noCapacityForExpansion
+
+					if i <= int32(len(in.expectedReplacing)) {
+						mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), in.expectedReplacing[i-1]).Return(nil).Times(1)
+						message = createdReplacement
+					}
+
+					expectedLogs = append(expectedLogs, testutils.LogEntry{
+						Level: 2,
+						KeysAndValues: []interface{}{
+							"updateStrategy", machinev1.RollingUpdate,
+							"index", i,
+							"namespace", namespaceName,
+							"name", fmt.Sprintf("machine-%d", i),
+						},
+						Message: message,
+					})
+				}
+
+				result, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, mockMachineProvider, machineInfos)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(Equal(ctrl.Result{}))
+				Expect(logger.Entries()).To(ConsistOf(expectedLogs))
+			},
+				Entry("with 3 replicas and a maximum surge of 1", maxSurgeTableInput{
+					replicas:          3,
+					maxSurge:          "1",
+					expectedReplacing: []int32{1},
+				}),
+				Entry("with 3 replicas and a maximum surge of 2, limited by the etcd quorum", maxSurgeTableInput{
+					replicas:          3,
+					maxSurge:          "2",
+					expectedReplacing: []int32{1},
+				}),
+				Entry("with 5 replicas and a maximum surge of 2", maxSurgeTableInput{
+					replicas:          5,
+					maxSurge:          "2",
+					expectedReplacing: []int32{1, 2},
+				}),
+				Entry("with 5 replicas and a maximum surge of 4, limited by the etcd quorum", maxSurgeTableInput{
+					replicas:          5,
+					maxSurge:          "4",
+					expectedReplacing: []int32{1, 2},
+				}),
+			)
+
+			Context("with an invalid maximum surge", func() {
+				var cpms *machinev1.ControlPlaneMachineSet
+				var result ctrl.Result
+				var err error
+
+				BeforeEach(func() {
+					cpms = cpmsBuilder.WithReplicas(3).Build()
+					cpms.Annotations = map[string]string{maxSurgeAnnotation: "none"}
+
+					machineInfos := map[int32][]machineproviders.MachineInfo{
+						0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").WithNeedsUpdate(true).Build()},
+					}
+
+					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+					result, err = reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, mockMachineProvider, machineInfos)
+				})
+
+				It("Returns an empty result", func() {
+					Expect(result).To(Equal(ctrl.Result{}))
+				})
+
+				It("Does not return an error", func() {
+					Expect(err).ToNot(HaveOccurred(), "This is a terminal error, returning an error would force a requeue which is not desired")
+				})
+
+				It("Sets the degraded condition", func() {
+					Expect(cpms.Status.Conditions).To(ConsistOf(testutils.MatchCondition(metav1.Condition{
+						Type:    conditionDegraded,
+						Status:  metav1.ConditionTrue,
+						Reason:  reasonInvalidAnnotation,
+						Message: fmt.Sprintf("%s: %s: %s: %q", invalidAnnotationMessage, errInvalidMaxSurge, maxSurgeAnnotation, "none"),
+					})))
+				})
+			})
+		})
+
+		Context("with a partition configured", func() {
+			type partitionTableInput struct {
+				partition        int32
+				deletedIndexes   []int32
+				expectedHeldBack []int32
+			}
+
+			DescribeTable("should only replace indexes at or above the partition", func(in partitionTableInput) {
+				cpms := cpmsBuilder.WithReplicas(3).Build()
+				cpms.Annotations = map[string]string{partitionAnnotation: fmt.Sprintf("%d", in.partition)}
+
+				contains := func(indexes []int32, idx int32) bool {
+					for _, i := range indexes {
+						if i == idx {
+							return true
+						}
+					}
+
+					return false
+				}
+
+				machineInfos := map[int32][]machineproviders.MachineInfo{}
+				for i := int32(0); i < 3; i++ {
+					// All indexes need an update.
+					builder := updatedMachineBuilder.WithIndex(i).WithMachineName(fmt.Sprintf("machine-%d", i)).WithNodeName(fmt.Sprintf("node-%d", i)).WithNeedsUpdate(true)
+					if contains(in.deletedIndexes, i) {
+						builder = builder.WithMachineDeletionTimestamp(metav1.Now())
+					}
+
+					machineInfos[i] = []machineproviders.MachineInfo{builder.Build()}
+				}
+
+				mockMachineProvider.EXPECT().WithClient(gomock.Any(), gomock.Any(), gomock.Any()).Return(mockMachineProvider, nil).AnyTimes()
+				mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(machineInfos), nil).AnyTimes()
+				mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+				expectedLogs := []testutils.LogEntry{}
+				created := false
+
+				for i := int32(0); i < 3; i++ {
+					if contains(in.expectedHeldBack, i) {
+						expectedLogs = append(expectedLogs, testutils.LogEntry{
+							Level: 2,
+							KeysAndValues: []interface{}{
+								"updateStrategy", machinev1.RollingUpdate,
+								"index", i,
+								"partition", in.partition,
+							},
+							Message: heldBackByPartition,
+						})
+
+						continue
+					}
+
+					// Only a single index may be replaced at a time with the default maximum surge.
+					message := noCapacityForExpansion
+
+					if !created {
+						mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), i).Return(nil).Times(1)
+						message = createdReplacement
+						created = true
+					}
+
+					expectedLogs = append(expectedLogs, testutils.LogEntry{
+						Level: 2,
+						KeysAndValues: []interface{}{
+							"updateStrategy", machinev1.RollingUpdate,
+							"index", i,
+							"namespace", namespaceName,
+							"name", fmt.Sprintf("machine-%d", i),
+						},
+						Message: message,
+					})
+				}
+
+				if !created {
+					// When every index is held back by the partition, no updates take place.
+					expectedLogs = append(expectedLogs, testutils.LogEntry{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"updateStrategy", machinev1.RollingUpdate,
+						},
+						Message: noUpdatesRequired,
+					})
+				}
+
+				result, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, mockMachineProvider, machineInfos)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(Equal(ctrl.Result{}))
+				Expect(logger.Entries()).To(ConsistOf(expectedLogs))
+			},
+				Entry("with a partition of 0", partitionTableInput{
+					partition: 0,
+				}),
+				Entry("with a partition of 2", partitionTableInput{
+					partition:        2,
+					expectedHeldBack: []int32{0, 1},
+				}),
+				Entry("with a partition equal to the number of replicas", partitionTableInput{
+					partition:        3,
+					expectedHeldBack: []int32{0, 1, 2},
+				}),
+				Entry("with a partition of 2 and a deleted Machine below the partition", partitionTableInput{
+					partition:        2,
+					deletedIndexes:   []int32{0},
+					expectedHeldBack: []int32{1},
+				}),
+			)
+
+			Context("with an invalid partition", func() {
+				var cpms *machinev1.ControlPlaneMachineSet
+				var result ctrl.Result
+				var err error
+
+				BeforeEach(func() {
+					cpms = cpmsBuilder.WithReplicas(3).Build()
+					cpms.Annotations = map[string]string{partitionAnnotation: "-1"}
+
+					machineInfos := map[int32][]machineproviders.MachineInfo{
+						0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").WithNeedsUpdate(true).Build()},
+					}
+
+					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+					result, err = reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, mockMachineProvider, machineInfos)
+				})
+
+				It("Returns an empty result", func() {
+					Expect(result).To(Equal(ctrl.Result{}))
+				})
+
+				It("Does not return an error", func() {
+					Expect(err).ToNot(HaveOccurred(), "This is a terminal error, returning an error would force a requeue which is not desired")
+				})
+
+				It("Sets the degraded condition", func() {
+					Expect(cpms.Status.Conditions).To(ConsistOf(testutils.MatchCondition(metav1.Condition{
+						Type:    conditionDegraded,
+						Status:  metav1.ConditionTrue,
+						Reason:  reasonInvalidAnnotation,
+						Message: fmt.Sprintf("%s: %s: %s: %q", invalidAnnotationMessage, errInvalidPartition, partitionAnnotation, "-1"),
+					})))
+				})
+			})
+		})
+
+		Context("with a minimum ready duration configured", func() {
+			type minReadyTableInput struct {
+				readySince     *metav1.Time
+				expectDelete   bool
+				expectedResult ctrl.Result
+			}
+
+			DescribeTable("should only delete the outdated machine once the replacement has been ready for the minimum duration", func(in minReadyTableInput) {
+				cpms := cpmsBuilder.WithReplicas(3).Build()
+				cpms.Annotations = map[string]string{minReadySecondsAnnotation: "300"}
+
+				replacementBuilder := updatedMachineBuilder.WithIndex(1).WithMachineName("machine-replacement-1").WithNodeName("node-replacement-1")
+				if in.readySince != nil {
+					replacementBuilder = replacementBuilder.WithReadySince(*in.readySince)
+				}
+
+				outdatedMachine := updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").WithNeedsUpdate(true).Build()
+
+				machineInfos := map[int32][]machineproviders.MachineInfo{
+					0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+					1: {outdatedMachine, replacementBuilder.Build()},
+					2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+				}
+
+				mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+				expectedLog := testutils.LogEntry{
+					Level: 2,
+					KeysAndValues: []interface{}{
+						"updateStrategy", machinev1.RollingUpdate,
+						"index", int32(1),
+						"namespace", namespaceName,
+						"name", "machine-replacement-1",
+						"minReadySeconds", int64(300),
+					},
+					Message: waitingForMinReady,
+				}
+
+				if in.expectDelete {
+					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), outdatedMachine.MachineRef).Return(nil).Times(1)
+
+					expectedLog = testutils.LogEntry{
+						Level: 2,
+						KeysAndValues: []interface{}{
+							"updateStrategy", machinev1.RollingUpdate,
+							"index", int32(1),
+							"namespace", namespaceName,
+							"name", "machine-1",
+						},
+						Message: removingOldMachine,
+					}
+				} else {
+					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				}
+
+				result, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, mockMachineProvider, machineInfos)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(Equal(in.expectedResult))
+				Expect(logger.Entries()).To(ConsistOf(expectedLog))
+			},
+				Entry("when the replacement has not been ready for the minimum duration", minReadyTableInput{
+					readySince:     &metav1.Time{Time: time.Now().Add(-10 * time.Second)},
+					expectDelete:   false,
+					expectedResult: ctrl.Result{RequeueAfter: 5 * time.Second},
+				}),
+				Entry("when the replacement has been ready for the minimum duration", minReadyTableInput{
+					readySince:     &metav1.Time{Time: time.Now().Add(-10 * time.Minute)},
+					expectDelete:   true,
+					expectedResult: ctrl.Result{},
+				}),
+				Entry("when the time the replacement became ready is not known", minReadyTableInput{
+					readySince:     nil,
+					expectDelete:   true,
+					expectedResult: ctrl.Result{},
+				}),
+			)
+		})
+
+		Context("with etcd membership known", func() {
+			type etcdMemberTableInput struct {
+				voting         bool
+				healthy        bool
+				expectDelete   bool
+				expectedResult ctrl.Result
+			}
+
+			DescribeTable("should only delete the outdated machine once the etcd member of the replacement is healthy and voting", func(in etcdMemberTableInput) {
+				cpms := cpmsBuilder.WithReplicas(3).Build()
+
+				replacementMachine := updatedMachineBuilder.WithIndex(1).WithMachineName("machine-replacement-1").WithNodeName("node-replacement-1").WithEtcdMember(in.voting, in.healthy).Build()
+				outdatedMachine := updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").WithNeedsUpdate(true).WithEtcdMember(true, true).Build()
+
+				machineInfos := map[int32][]machineproviders.MachineInfo{
+					0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").WithEtcdMember(true, true).Build()},
+					1: {outdatedMachine, replacementMachine},
+					2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").WithEtcdMember(true, true).Build()},
+				}
+
+				mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+				expectedLog := testutils.LogEntry{
+					Level: 2,
+					KeysAndValues: []interface{}{
+						"updateStrategy", machinev1.RollingUpdate,
+						"index", int32(1),
+						"namespace", namespaceName,
+						"name", "machine-replacement-1",
+						"etcdMemberVoting", in.voting,
+						"etcdMemberHealthy", in.healthy,
+					},
+					Message: waitingForEtcdMember,
+				}
+
+				if in.expectDelete {
+					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), outdatedMachine.MachineRef).Return(nil).Times(1)
+
+					expectedLog = testutils.LogEntry{
+						Level: 2,
+						KeysAndValues: []interface{}{
+							"updateStrategy", machinev1.RollingUpdate,
+							"index", int32(1),
+							"namespace", namespaceName,
+							"name", "machine-1",
+						},
+						Message: removingOldMachine,
+					}
+				} else {
+					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				}
+
+				result, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, mockMachineProvider, machineInfos)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(Equal(in.expectedResult))
+				Expect(logger.Entries()).To(ConsistOf(expectedLog))
+			},
+				Entry("when the etcd member of the replacement is not yet voting", etcdMemberTableInput{
+					voting:         false,
+					healthy:        true,
+					expectDelete:   false,
+					expectedResult: ctrl.Result{RequeueAfter: 5 * time.Second},
+				}),
+				Entry("when the etcd member of the replacement is not yet healthy", etcdMemberTableInput{
+					voting:         true,
+					healthy:        false,
+					expectDelete:   false,
+					expectedResult: ctrl.Result{RequeueAfter: 5 * time.Second},
+				}),
+				Entry("when the etcd member of the replacement is healthy and voting", etcdMemberTableInput{
+					voting:         true,
+					healthy:        true,
+					expectDelete:   true,
+					expectedResult: ctrl.Result{},
+				}),
+			)
+		})
+
+		Context("with a failed replacement in backoff", func() {
+			var result ctrl.Result
+			var err error
+
+			BeforeEach(func() {
+				cpms := cpmsBuilder.WithReplicas(3).Build()
+
+				machineInfos := map[int32][]machineproviders.MachineInfo{
+					0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+					1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").WithNeedsUpdate(true).Build()},
+					2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+				}
+
+				reconciler.recordFailedReplacement(1, time.Now())
+
+				mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+				result, err = reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, mockMachineProvider, machineInfos)
+			})
+
+			It("Does not return an error", func() {
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("Requeues once the backoff has elapsed", func() {
+				Expect(result.RequeueAfter).To(BeNumerically("~", baseReplacementBackoff, time.Second))
+			})
+
+			It("Logs that the replacement is waiting for the backoff", func() {
+				Expect(logger.Entries()).To(ConsistOf(
+					HaveField("Message", waitingForReplacementBackoff),
+				))
+			})
+		})
+
+		Context("with the replicas increased from 3 to 5", func() {
+			var result ctrl.Result
+			var err error
+
+			BeforeEach(func() {
+				cpms := cpmsBuilder.WithReplicas(5).Build()
+
+				machineInfos := map[int32][]machineproviders.MachineInfo{
+					0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+					1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+					2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+					3: {},
+					4: {},
+				}
+
+				mockMachineProvider.EXPECT().WithClient(gomock.Any(), gomock.Any(), gomock.Any()).Return(mockMachineProvider, nil).AnyTimes()
+				mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(machineInfos), nil).AnyTimes()
+				mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(3)).Return(nil).Times(1)
+				mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(4)).Return(nil).Times(1)
+				mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+				result, err = reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, mockMachineProvider, machineInfos)
+			})
+
+			It("Does not return an error", func() {
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("Does not requeue", func() {
+				Expect(result).To(Equal(ctrl.Result{}))
+			})
+
+			It("Creates a Machine for each new index", func() {
+				Expect(logger.Entries()).To(ConsistOf(
+					HaveField("Message", createdReplacement),
+					HaveField("Message", createdReplacement),
+				))
+			})
+		})
+
+		Context("with reconciling excess indexes enabled", func() {
+			DescribeTable("should reconcile the excess indexes", func(in rollingUpdateTableInput) {
+				in.setupMock(in.machineInfos)
+
+				cpms := cpmsBuilder.WithReplicas(3).Build()
+				cpms.Annotations = map[string]string{reconcileExcessIndexesAnnotation: ""}
+
+				result, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, mockMachineProvider, in.machineInfos)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(Equal(in.expectedResult))
+				Expect(logger.Entries()).To(ConsistOf(in.expectedLogsBuilder()))
+			},
+				Entry("with an excess index and a missing index", rollingUpdateTableInput{
+					machineInfos: map[int32][]machineproviders.MachineInfo{
+						0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+						1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+						3: {updatedMachineBuilder.WithIndex(3).WithMachineName("machine-3").WithNodeName("node-3").Build()},
+					},
+					setupMock: func(machineInfos map[int32][]machineproviders.MachineInfo) {
+						mockMachineProvider.EXPECT().WithClient(gomock.Any(), gomock.Any(), gomock.Any()).Return(mockMachineProvider, nil).AnyTimes()
+						mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(machineInfos), nil).AnyTimes()
+						mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(2)).Return(nil).Times(1)
+						mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+					},
+					expectedLogsBuilder: func() []testutils.LogEntry {
+						return []testutils.LogEntry{
+							{
+								Level: 2,
+								KeysAndValues: []interface{}{
+									"index", int32(2),
+									"excessIndex", int32(3),
+								},
+								Message: migratingExcessIndex,
+							},
+							{
+								Level: 2,
+								KeysAndValues: []interface{}{
+									"index", int32(2),
+									"excessIndex", int32(3),
+								},
+								Message: createdReplacement,
+							},
+						}
+					},
+				}),
+				Entry("with an excess index marked for migration, and the migrated index ready", rollingUpdateTableInput{
+					machineInfos: map[int32][]machineproviders.MachineInfo{
+						0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+						1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+						2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+						3: {updatedMachineBuilder.WithIndex(3).WithMachineName("machine-3").WithNodeName("node-3").WithMachineAnnotations(map[string]string{
+							migrateToIndexAnnotation: "2",
+						}).Build()},
+					},
+					setupMock: func(machineInfos map[int32][]machineproviders.MachineInfo) {
+						mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+						mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), machineInfos[3][0].MachineRef).Return(nil).Times(1)
+					},
+					expectedLogsBuilder: func() []testutils.LogEntry {
+						return []testutils.LogEntry{
+							{
+								Level: 2,
+								KeysAndValues: []interface{}{
+									"index", int32(3),
+									"migrationIndex", int32(2),
+								},
+								Message: removingMigratedExcessIndex,
+							},
+							{
+								Level: 2,
+								KeysAndValues: []interface{}{
+									"index", int32(3),
+									"migrationIndex", int32(2),
+									"namespace", namespaceName,
+									"name", "machine-3",
+								},
+								Message: removingOldMachine,
+							},
+						}
+					},
+				}),
+				Entry("with an excess index marked for migration, and the migrated index not yet ready", rollingUpdateTableInput{
+					machineInfos: map[int32][]machineproviders.MachineInfo{
+						0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+						1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+						2: {pendingMachineBuilder.WithIndex(2).WithMachineName("machine-2").Build()},
+						3: {updatedMachineBuilder.WithIndex(3).WithMachineName("machine-3").WithNodeName("node-3").WithMachineAnnotations(map[string]string{
+							migrateToIndexAnnotation: "2",
+						}).Build()},
+					},
+					setupMock: func(machineInfos map[int32][]machineproviders.MachineInfo) {
+						mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+						mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+					},
+					expectedLogsBuilder: func() []testutils.LogEntry {
+						return []testutils.LogEntry{
+							{
+								Level: 2,
+								KeysAndValues: []interface{}{
+									"index", int32(2),
+									"excessIndex", int32(3),
+								},
+								Message: waitingForMigratedIndex,
+							},
+						}
+					},
+					expectedResult: ctrl.Result{RequeueAfter: 5 * time.Second},
+				}),
+			)
+		})
+
+		Context("outside of the maintenance windows", func() {
+			var result ctrl.Result
+			var err error
+
+			BeforeEach(func() {
+				// A daily window which opens in two hours, so that no window is currently open.
+				start := time.Now().UTC().Add(2 * time.Hour).Format("15:04")
+
+				cpms := cpmsBuilder.WithReplicas(3).Build()
+				cpms.Annotations = map[string]string{
+					maintenanceWindowsAnnotation: fmt.Sprintf(`{"windows": [{"start": %q, "duration": "1h"}]}`, start),
+				}
+
+				machineInfos := map[int32][]machineproviders.MachineInfo{
+					0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+					1: {
+						updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").WithNeedsUpdate(true).Build(),
+						updatedMachineBuilder.WithIndex(1).WithMachineName("machine-replacement-1").WithNodeName("node-replacement-1").Build(),
+					},
+					2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").WithNeedsUpdate(true).Build()},
+				}
+
+				mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), machineInfos[1][0].MachineRef).Return(nil).Times(1)
+
+				result, err = reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, mockMachineProvider, machineInfos)
+			})
+
+			It("Does not return an error", func() {
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("Requeues once the next maintenance window opens", func() {
+				Expect(result.RequeueAfter).To(BeNumerically("~", 2*time.Hour, time.Minute))
+			})
+
+			It("Completes the started replacement without starting a new replacement", func() {
+				Expect(logger.Entries()).To(ContainElement(HaveField("Message", heldBackByMaintenanceWindow)))
+				Expect(logger.Entries()).To(ContainElement(HaveField("Message", removingOldMachine)))
+				Expect(logger.Entries()).ToNot(ContainElement(HaveField("Message", createdReplacement)))
+			})
+		})
 	})
 
 	Context("When the update strategy is OnDelete", func() {
@@ -2153,6 +2885,50 @@ var _ = Describe("reconcileMachineUpdates", func() {
 		})
 	})
 
+	Context("When updates are paused", func() {
+		var cpms *machinev1.ControlPlaneMachineSet
+		var result ctrl.Result
+		var err error
+
+		BeforeEach(func() {
+			cpms = cpmsBuilder.WithStrategyType(machinev1.RollingUpdate).WithReplicas(3).Build()
+			cpms.Annotations = map[string]string{pausedAnnotation: ""}
+
+			machineInfos := map[int32][]machineproviders.MachineInfo{
+				0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+				1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").WithNeedsUpdate(true).Build()},
+				2: {},
+			}
+
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			result, err = reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, mockMachineProvider, machineInfos)
+		})
+
+		It("Returns an empty result", func() {
+			Expect(result).To(Equal(ctrl.Result{}))
+		})
+
+		It("Does not return an error", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("Logs that updates are paused", func() {
+			Expect(logger.Entries()).To(ConsistOf(testutils.LogEntry{
+				Level: 2,
+				KeysAndValues: []interface{}{
+					"outdatedIndexes", []int32{1, 2},
+				},
+				Message: updatesPaused,
+			}))
+		})
+
+		It("Does not set any conditions", func() {
+			Expect(cpms.Status.Conditions).To(BeEmpty())
+		})
+	})
+
 	Context("When the update strategy is invalid", func() {
 		var cpms *machinev1.ControlPlaneMachineSet
 		var result ctrl.Result
@@ -248,6 +248,11 @@ func (r *ControlPlaneMachineSetGeneratorReconciler) generateControlPlaneMachineS
 		if err != nil {
 			return nil, fmt.Errorf("unable to generate control plane machine set spec: %w", err)
 		}
+	case configv1.PowerVSPlatformType:
+		cpmsSpecApplyConfig, err = generateControlPlaneMachineSetPowerVSSpec(logger, machines)
+		if err != nil {
+			return nil, fmt.Errorf("unable to generate control plane machine set spec: %w", err)
+		}
 	default:
 		logger.V(1).WithValues("platform", platformType).Info(unsupportedPlatform)
 		return nil, errUnsupportedPlatform
@@ -36,6 +36,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/json"
 	"k8s.io/utils/pointer"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -2194,6 +2195,198 @@ var _ = Describe("controlplanemachinesetgenerator controller on Nutanix", func()
 	})
 })
 
+type powerVSMachineProviderSpecBuilder struct {
+	memoryGiB int32
+}
+
+func (p powerVSMachineProviderSpecBuilder) BuildRawExtension() *runtime.RawExtension {
+	pmpc := &machinev1.PowerVSMachineProviderConfig{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: machinev1.GroupVersion.String(),
+			Kind:       "PowerVSMachineProviderConfig",
+		},
+		UserDataSecret:    &machinev1.PowerVSSecretReference{Name: "master-user-data"},
+		CredentialsSecret: &machinev1.PowerVSSecretReference{Name: "powervs-credentials"},
+		ServiceInstance: machinev1.PowerVSResource{
+			Type: machinev1.PowerVSResourceTypeID,
+			ID:   pointer.String("3e2ea4a6-5ff6-4a3f-8e8f-3c5c7c2f7a1b"),
+		},
+		Image: machinev1.PowerVSResource{
+			Type: machinev1.PowerVSResourceTypeName,
+			Name: pointer.String("rhcos"),
+		},
+		Network: machinev1.PowerVSResource{
+			Type:  machinev1.PowerVSResourceTypeRegEx,
+			RegEx: pointer.String("^DHCPSERVER.*_Private$"),
+		},
+		KeyPairName:   "powervs-keypair",
+		SystemType:    "s922",
+		ProcessorType: machinev1.PowerVSProcessorTypeShared,
+		Processors:    intstr.FromString("0.5"),
+		MemoryGiB:     p.memoryGiB,
+		LoadBalancers: []machinev1.LoadBalancerReference{
+			{Name: "powervs-loadbalancer", Type: machinev1.ApplicationLoadBalancerType},
+			{Name: "powervs-loadbalancer-int", Type: machinev1.ApplicationLoadBalancerType},
+		},
+	}
+
+	raw, err := json.Marshal(pmpc)
+	if err != nil {
+		// As we are building the input to json.Marshal, this should never happen.
+		panic(err)
+	}
+
+	return &runtime.RawExtension{Raw: raw}
+}
+
+var _ = Describe("controlplanemachinesetgenerator controller on PowerVS", func() {
+	var mgrCancel context.CancelFunc
+	var mgrDone chan struct{}
+	var mgr manager.Manager
+	var reconciler *ControlPlaneMachineSetGeneratorReconciler
+
+	var namespaceName string
+	var cpms *machinev1.ControlPlaneMachineSet
+	var machine0, machine1, machine2 *machinev1beta1.Machine
+
+	startManager := func(mgr *manager.Manager) (context.CancelFunc, chan struct{}) {
+		mgrCtx, mgrCancel := context.WithCancel(context.Background())
+		mgrDone := make(chan struct{})
+
+		go func() {
+			defer GinkgoRecover()
+			defer close(mgrDone)
+
+			Expect((*mgr).Start(mgrCtx)).To(Succeed())
+		}()
+
+		return mgrCancel, mgrDone
+	}
+
+	stopManager := func() {
+		mgrCancel()
+		// Wait for the mgrDone to be closed, which will happen once the mgr has stopped
+		<-mgrDone
+	}
+
+	create3CPMachines := func() *[]machinev1beta1.Machine {
+		// Create 3 control plane machines with differing Provider Specs,
+		// so then we can reliably check which machine Provider Spec is picked for the ControlPlaneMachineSet.
+		machineBuilder := machinev1beta1resourcebuilder.Machine().AsMaster().WithNamespace(namespaceName)
+		machine0 = machineBuilder.WithProviderSpecBuilder(powerVSMachineProviderSpecBuilder{memoryGiB: 32}).WithName("master-0").Build()
+		machine1 = machineBuilder.WithProviderSpecBuilder(powerVSMachineProviderSpecBuilder{memoryGiB: 32}).WithName("master-1").Build()
+		machine2 = machineBuilder.WithProviderSpecBuilder(powerVSMachineProviderSpecBuilder{memoryGiB: 64}).WithName("master-2").Build()
+
+		// Create Machines with some wait time between them
+		// to achieve staggered CreationTimestamp(s).
+		Expect(k8sClient.Create(ctx, machine0)).To(Succeed())
+		Expect(k8sClient.Create(ctx, machine1)).To(Succeed())
+		Expect(k8sClient.Create(ctx, machine2)).To(Succeed())
+
+		return &[]machinev1beta1.Machine{*machine0, *machine1, *machine2}
+	}
+
+	BeforeEach(func() {
+		Expect(k8sClient).NotTo(BeNil())
+		By("Setting up a namespace for the test")
+		ns := corev1resourcebuilder.Namespace().WithGenerateName("control-plane-machine-set-controller-").Build()
+		Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+		namespaceName = ns.GetName()
+
+		By("Setting up a new infrastructure for the test")
+		// Create infrastructure object.
+		infra := configv1resourcebuilder.Infrastructure().WithName(infrastructureName).Build()
+		infra.Status.ControlPlaneTopology = configv1.HighlyAvailableTopologyMode
+		infra.Status.InfrastructureTopology = configv1.HighlyAvailableTopologyMode
+		infra.Status.PlatformStatus = &configv1.PlatformStatus{
+			Type:    configv1.PowerVSPlatformType,
+			PowerVS: &configv1.PowerVSPlatformStatus{},
+		}
+		infraStatus := infra.Status.DeepCopy()
+		Expect(k8sClient.Create(ctx, infra)).To(Succeed())
+		// Update Infrastructure Status.
+		Eventually(komega.UpdateStatus(infra, func() {
+			infra.Status = *infraStatus
+		})).Should(Succeed())
+
+		By("Setting up a manager and controller")
+		var err error
+		mgr, err = ctrl.NewManager(cfg, ctrl.Options{
+			Scheme:             testScheme,
+			MetricsBindAddress: "0",
+			Port:               testEnv.WebhookInstallOptions.LocalServingPort,
+			Host:               testEnv.WebhookInstallOptions.LocalServingHost,
+			CertDir:            testEnv.WebhookInstallOptions.LocalServingCertDir,
+		})
+		Expect(err).ToNot(HaveOccurred(), "Manager should be able to be created")
+		reconciler = &ControlPlaneMachineSetGeneratorReconciler{
+			Client:    mgr.GetClient(),
+			Namespace: namespaceName,
+		}
+		Expect(reconciler.SetupWithManager(mgr)).To(Succeed(), "Reconciler should be able to setup with manager")
+	})
+
+	AfterEach(func() {
+		testutils.CleanupResources(Default, ctx, cfg, k8sClient, namespaceName,
+			&corev1.Node{},
+			&machinev1beta1.Machine{},
+			&configv1.Infrastructure{},
+			&machinev1beta1.MachineSet{},
+			&machinev1.ControlPlaneMachineSet{},
+		)
+	})
+
+	JustBeforeEach(func() {
+		By("Starting the manager")
+		mgrCancel, mgrDone = startManager(&mgr)
+	})
+
+	JustAfterEach(func() {
+		By("Stopping the manager")
+		stopManager()
+	})
+
+	Context("when a Control Plane Machine Set doesn't exist", func() {
+		BeforeEach(func() {
+			cpms = &machinev1.ControlPlaneMachineSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      clusterControlPlaneMachineSetName,
+					Namespace: namespaceName,
+				},
+			}
+		})
+
+		Context("with 3 existing control plane machines", func() {
+			BeforeEach(func() {
+				By("Creating Control Plane Machines")
+				create3CPMachines()
+			})
+
+			It("should create the ControlPlaneMachineSet with the expected fields", func() {
+				By("Checking the Control Plane Machine Set has been created")
+				Eventually(komega.Get(cpms)).Should(Succeed())
+				Expect(cpms.Spec.State).To(Equal(machinev1.ControlPlaneMachineSetStateInactive))
+				Expect(*cpms.Spec.Replicas).To(Equal(int32(3)))
+				Expect(cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.FailureDomains).To(BeZero())
+			})
+
+			It("should create the ControlPlaneMachineSet with the provider spec matching the youngest machine provider spec", func() {
+				By("Checking the Control Plane Machine Set has been created")
+				Eventually(komega.Get(cpms)).Should(Succeed())
+				// In this case expect the machine Provider Spec of the youngest machine to be used here.
+				// In this case it should be `machine-2` given that's the one we created last.
+				cpmsProviderSpec, err := providerconfig.NewProviderConfigFromMachineSpec(mgr.GetLogger(), cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.Spec)
+				Expect(err).To(BeNil())
+
+				machineProviderSpec, err := providerconfig.NewProviderConfigFromMachineSpec(mgr.GetLogger(), machine2.Spec)
+				Expect(err).To(BeNil())
+
+				Expect(cpmsProviderSpec.PowerVS().Config()).To(Equal(machineProviderSpec.PowerVS().Config()))
+			})
+		})
+	})
+})
+
 var _ = Describe("controlplanemachinesetgenerator controller on OpenStack", func() {
 
 	var (
@@ -29,6 +29,9 @@ import (
 )
 
 // generateControlPlaneMachineSetNutanixSpec generates a Nutanix flavored ControlPlaneMachineSet Spec.
+// The ControlPlaneMachineSet API has no Nutanix failure domains, so none are generated here.
+// Instead, the failure domains are built from the Prism Elements of the Infrastructure
+// when the ControlPlaneMachineSet is reconciled.
 func generateControlPlaneMachineSetNutanixSpec(logger logr.Logger, machines []machinev1beta1.Machine) (machinev1builder.ControlPlaneMachineSetSpecApplyConfiguration, error) {
 	controlPlaneMachineSetMachineSpecApplyConfig, err := buildControlPlaneMachineSetNutanixMachineSpec(logger, machines)
 	if err != nil {
@@ -39,10 +39,11 @@ var (
 
 // NewMachineProvider constructs a MachineProvider based on the machine type passed.
 // This can then be used to access and manipulate machines within the cluster.
-func NewMachineProvider(ctx context.Context, logger logr.Logger, cl client.Client, cpms *machinev1.ControlPlaneMachineSet) (machineproviders.MachineProvider, error) {
+// The etcd client is used to read the state of the etcd members of the control plane from the etcd namespace.
+func NewMachineProvider(ctx context.Context, logger logr.Logger, cl client.Client, etcdClient client.Reader, cpms *machinev1.ControlPlaneMachineSet) (machineproviders.MachineProvider, error) {
 	switch cpms.Spec.Template.MachineType {
 	case machinev1.OpenShiftMachineV1Beta1MachineType:
-		provider, err := openshiftmachinev1beta1.NewMachineProvider(ctx, logger, cl, cpms)
+		provider, err := openshiftmachinev1beta1.NewMachineProvider(ctx, logger, cl, etcdClient, cpms)
 		if err != nil {
 			return nil, fmt.Errorf("error constructing %s machine provider: %w", machinev1.OpenShiftMachineV1Beta1MachineType, err)
 		}
@@ -174,7 +174,7 @@ var _ = Describe("MachineProviders", func() {
 				cpms := cpmsBuilder.Build()
 				cpms.Spec.Template.MachineType = invalidCPMSType
 
-				provider, err = NewMachineProvider(ctx, logger.Logger(), k8sClient, cpms)
+				provider, err = NewMachineProvider(ctx, logger.Logger(), k8sClient, k8sClient, cpms)
 			})
 
 			It("returns an error", func() {
@@ -222,7 +222,7 @@ var _ = Describe("MachineProviders", func() {
 				var err error
 
 				BeforeEach(func() {
-					provider, err = NewMachineProvider(ctx, logger.Logger(), k8sClient, cpmsBuilder.Build())
+					provider, err = NewMachineProvider(ctx, logger.Logger(), k8sClient, k8sClient, cpmsBuilder.Build())
 				})
 
 				It("does not error", func() {
@@ -284,7 +284,7 @@ var _ = Describe("MachineProviders", func() {
 
 				BeforeEach(func() {
 
-					provider, err = NewMachineProvider(ctx, logger.Logger(), k8sClient, cpmsBuilder.Build())
+					provider, err = NewMachineProvider(ctx, logger.Logger(), k8sClient, k8sClient, cpmsBuilder.Build())
 				})
 
 				It("does not error", func() {
@@ -321,7 +321,7 @@ var _ = Describe("MachineProviders", func() {
 					cpms := cpmsBuilder.Build()
 					cpms.Spec.Template.OpenShiftMachineV1Beta1Machine = nil
 
-					provider, err = NewMachineProvider(ctx, logger.Logger(), k8sClient, cpms)
+					provider, err = NewMachineProvider(ctx, logger.Logger(), k8sClient, k8sClient, cpms)
 				})
 
 				It("returns an error", func() {
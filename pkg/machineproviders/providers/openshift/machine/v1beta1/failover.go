@@ -0,0 +1,137 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	"k8s.io/utils/pointer"
+
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers/openshift/machine/v1beta1/failuredomain"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers/openshift/machine/v1beta1/providerconfig"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/util"
+)
+
+// failoverCapacityExhaustedIndexes remaps any index whose mapped failure domain did not have the capacity to create a
+// Machine within the index. The index is mapped instead to another of the given failure domains, which has not run
+// out of capacity for the index, and which can be used without any failure domain being used by more indexes than the
+// spread of the mapping allows.
+// The failover only lasts while the failed Machine exists, once a replacement has been created in the new failure
+// domain, the mapping follows the replacement Machine as it is the newest Machine in the index.
+func failoverCapacityExhaustedIndexes(logger logr.Logger, machines []machinev1beta1.Machine, mapping map[int32]failuredomain.FailureDomain, failureDomains []failuredomain.FailureDomain) error {
+	exhausted, err := capacityExhaustedFailureDomains(logger, machines)
+	if err != nil {
+		return fmt.Errorf("could not determine capacity exhausted failure domains: %w", err)
+	}
+
+	maxPerFailureDomain := maxIndexesPerFailureDomain(mapping)
+
+	for _, idx := range sortedIndexes(mapping) {
+		exhaustedFailureDomains, ok := exhausted[idx]
+		if !ok || !exhaustedFailureDomains.Has(mapping[idx]) {
+			continue
+		}
+
+		alternative, ok := alternativeFailureDomain(mapping, failureDomains, exhaustedFailureDomains, maxPerFailureDomain)
+		if !ok {
+			logger.V(4).Info(
+				"No alternative failure domain with capacity for index",
+				"index", int(idx),
+				"failureDomain", mapping[idx].String(),
+			)
+
+			continue
+		}
+
+		logger.V(4).Info(
+			"Failed over index to another failure domain",
+			"index", int(idx),
+			"oldFailureDomain", mapping[idx].String(),
+			"newFailureDomain", alternative.String(),
+		)
+
+		mapping[idx] = alternative
+	}
+
+	return nil
+}
+
+// capacityExhaustedFailureDomains returns, for each index, the failure domains in which a Machine within the index
+// failed because the failure domain did not have the capacity to create it.
+func capacityExhaustedFailureDomains(logger logr.Logger, machines []machinev1beta1.Machine) (map[int32]*failuredomain.Set, error) {
+	out := make(map[int32]*failuredomain.Set)
+
+	for _, machine := range machines {
+		if !isCapacityExhaustedMachine(machine) {
+			continue
+		}
+
+		machineNameIndex, ok := parseMachineNameIndex(machine.Name)
+		if !ok {
+			continue
+		}
+
+		failureDomain, err := providerconfig.ExtractFailureDomainFromMachine(logger, machine)
+		if err != nil {
+			return nil, fmt.Errorf("could not extract failure domain from machine %s: %w", machine.Name, err)
+		}
+
+		idx := int32(machineNameIndex)
+		if _, ok := out[idx]; !ok {
+			out[idx] = failuredomain.NewSet()
+		}
+
+		out[idx].Insert(failureDomain)
+	}
+
+	return out, nil
+}
+
+// alternativeFailureDomain returns the failure domain, which is not exhausted, and which is used by the fewest indexes
+// within the mapping, as long as it is used by fewer than the maximum number of indexes per failure domain.
+// Failure domains used by the same number of indexes are considered in alphabetical order.
+func alternativeFailureDomain(mapping map[int32]failuredomain.FailureDomain, failureDomains []failuredomain.FailureDomain, exhausted *failuredomain.Set, maxPerFailureDomain int) (failuredomain.FailureDomain, bool) {
+	var (
+		alternative failuredomain.FailureDomain
+		lowestCount int
+	)
+
+	for _, failureDomain := range failuredomain.NewSet(failureDomains...).List() {
+		if exhausted.Has(failureDomain) {
+			continue
+		}
+
+		count := countForFailureDomain(mapping, failureDomain)
+		if count >= maxPerFailureDomain {
+			continue
+		}
+
+		if alternative == nil || count < lowestCount {
+			alternative, lowestCount = failureDomain, count
+		}
+	}
+
+	return alternative, alternative != nil
+}
+
+// isCapacityExhaustedMachine determines whether the Machine failed, before it had a Node, because its failure domain
+// did not have the capacity to create it.
+func isCapacityExhaustedMachine(machine machinev1beta1.Machine) bool {
+	return machine.Status.NodeRef == nil && util.IsCapacityError(pointer.StringDeref(machine.Status.ErrorMessage, ""))
+}
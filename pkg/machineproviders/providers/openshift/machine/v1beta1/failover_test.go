@@ -0,0 +1,270 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	machinev1 "github.com/openshift/api/machine/v1"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/cluster-api-actuator-pkg/testutils"
+	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
+	machinev1beta1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers/openshift/machine/v1beta1/failuredomain"
+)
+
+var _ = Describe("Failure Domain Failover", func() {
+	const capacityError = "error launching instance: InsufficientInstanceCapacity: We currently do not have sufficient m6i.xlarge capacity in the Availability Zone you requested"
+
+	failureDomainFor := func(zone string) failuredomain.FailureDomain {
+		return failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().
+			WithAvailabilityZone(zone).
+			WithSubnet(machinev1.AWSResourceReference{
+				Type: machinev1.AWSFiltersReferenceType,
+				Filters: &[]machinev1.AWSResourceFilter{
+					{
+						Name:   "tag:Name",
+						Values: []string{"aws-subnet-12345678"},
+					},
+				},
+			}).Build())
+	}
+
+	machineBuilderFor := func(name, zone string) machinev1beta1resourcebuilder.MachineBuilder {
+		return machinev1beta1resourcebuilder.Machine().AsMaster().WithName(name).
+			WithProviderSpecBuilder(machinev1beta1resourcebuilder.AWSProviderSpec().WithAvailabilityZone(zone))
+	}
+
+	usEast1a := failureDomainFor("us-east-1a")
+	usEast1b := failureDomainFor("us-east-1b")
+	usEast1c := failureDomainFor("us-east-1c")
+	usEast1d := failureDomainFor("us-east-1d")
+	usEast1e := failureDomainFor("us-east-1e")
+
+	Context("failoverCapacityExhaustedIndexes", func() {
+		type failoverTableInput struct {
+			failureDomains  []failuredomain.FailureDomain
+			mapping         map[int32]failuredomain.FailureDomain
+			machines        []*machinev1beta1.Machine
+			expectedMapping map[int32]failuredomain.FailureDomain
+			expectedLogs    []testutils.LogEntry
+		}
+
+		DescribeTable("should fail over indexes without capacity", func(in failoverTableInput) {
+			logger := testutils.NewTestLogger()
+
+			machines := []machinev1beta1.Machine{}
+			for _, machine := range in.machines {
+				machines = append(machines, *machine)
+			}
+
+			Expect(failoverCapacityExhaustedIndexes(logger.Logger(), machines, in.mapping, in.failureDomains)).To(Succeed())
+			Expect(in.mapping).To(Equal(in.expectedMapping))
+			Expect(logger.Entries()).To(ConsistOf(in.expectedLogs))
+		},
+			Entry("with no failed machines", failoverTableInput{
+				failureDomains: []failuredomain.FailureDomain{usEast1a, usEast1b, usEast1c, usEast1d},
+				mapping:        map[int32]failuredomain.FailureDomain{0: usEast1a, 1: usEast1b, 2: usEast1c},
+				machines: []*machinev1beta1.Machine{
+					machineBuilderFor("machine-0", "us-east-1a").Build(),
+					machineBuilderFor("machine-1", "us-east-1b").Build(),
+					machineBuilderFor("machine-2", "us-east-1c").Build(),
+				},
+				expectedMapping: map[int32]failuredomain.FailureDomain{0: usEast1a, 1: usEast1b, 2: usEast1c},
+				expectedLogs:    []testutils.LogEntry{},
+			}),
+			Entry("with a machine which failed for lack of capacity, and an unused failure domain", failoverTableInput{
+				failureDomains: []failuredomain.FailureDomain{usEast1a, usEast1b, usEast1c, usEast1d},
+				mapping:        map[int32]failuredomain.FailureDomain{0: usEast1a, 1: usEast1b, 2: usEast1c},
+				machines: []*machinev1beta1.Machine{
+					machineBuilderFor("machine-0", "us-east-1a").Build(),
+					machineBuilderFor("machine-1", "us-east-1b").Build(),
+					machineBuilderFor("machine-replacement-1", "us-east-1b").WithErrorMessage(capacityError).Build(),
+					machineBuilderFor("machine-2", "us-east-1c").Build(),
+				},
+				expectedMapping: map[int32]failuredomain.FailureDomain{0: usEast1a, 1: usEast1d, 2: usEast1c},
+				expectedLogs: []testutils.LogEntry{
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"index", 1,
+							"oldFailureDomain", usEast1b.String(),
+							"newFailureDomain", usEast1d.String(),
+						},
+						Message: "Failed over index to another failure domain",
+					},
+				},
+			}),
+			Entry("with a machine which failed for another reason", failoverTableInput{
+				failureDomains: []failuredomain.FailureDomain{usEast1a, usEast1b, usEast1c, usEast1d},
+				mapping:        map[int32]failuredomain.FailureDomain{0: usEast1a, 1: usEast1b, 2: usEast1c},
+				machines: []*machinev1beta1.Machine{
+					machineBuilderFor("machine-0", "us-east-1a").Build(),
+					machineBuilderFor("machine-1", "us-east-1b").Build(),
+					machineBuilderFor("machine-replacement-1", "us-east-1b").WithErrorMessage("error launching instance: InvalidAMIID.NotFound").Build(),
+					machineBuilderFor("machine-2", "us-east-1c").Build(),
+				},
+				expectedMapping: map[int32]failuredomain.FailureDomain{0: usEast1a, 1: usEast1b, 2: usEast1c},
+				expectedLogs:    []testutils.LogEntry{},
+			}),
+			Entry("with a machine which reported a capacity error after its node joined the cluster", failoverTableInput{
+				failureDomains: []failuredomain.FailureDomain{usEast1a, usEast1b, usEast1c, usEast1d},
+				mapping:        map[int32]failuredomain.FailureDomain{0: usEast1a, 1: usEast1b, 2: usEast1c},
+				machines: []*machinev1beta1.Machine{
+					machineBuilderFor("machine-0", "us-east-1a").Build(),
+					machineBuilderFor("machine-1", "us-east-1b").WithErrorMessage(capacityError).WithNodeRef(corev1.ObjectReference{Name: "node-1"}).Build(),
+					machineBuilderFor("machine-2", "us-east-1c").Build(),
+				},
+				expectedMapping: map[int32]failuredomain.FailureDomain{0: usEast1a, 1: usEast1b, 2: usEast1c},
+				expectedLogs:    []testutils.LogEntry{},
+			}),
+			Entry("with a machine which failed for lack of capacity, and every failure domain in use", failoverTableInput{
+				failureDomains: []failuredomain.FailureDomain{usEast1a, usEast1b, usEast1c},
+				mapping:        map[int32]failuredomain.FailureDomain{0: usEast1a, 1: usEast1b, 2: usEast1c},
+				machines: []*machinev1beta1.Machine{
+					machineBuilderFor("machine-0", "us-east-1a").Build(),
+					machineBuilderFor("machine-1", "us-east-1b").Build(),
+					machineBuilderFor("machine-replacement-1", "us-east-1b").WithErrorMessage(capacityError).Build(),
+					machineBuilderFor("machine-2", "us-east-1c").Build(),
+				},
+				expectedMapping: map[int32]failuredomain.FailureDomain{0: usEast1a, 1: usEast1b, 2: usEast1c},
+				expectedLogs: []testutils.LogEntry{
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"index", 1,
+							"failureDomain", usEast1b.String(),
+						},
+						Message: "No alternative failure domain with capacity for index",
+					},
+				},
+			}),
+			Entry("with a machine which failed for lack of capacity, and a failure domain which may be used by a second index", failoverTableInput{
+				failureDomains: []failuredomain.FailureDomain{usEast1a, usEast1b},
+				mapping:        map[int32]failuredomain.FailureDomain{0: usEast1a, 1: usEast1b, 2: usEast1a},
+				machines: []*machinev1beta1.Machine{
+					machineBuilderFor("machine-0", "us-east-1a").Build(),
+					machineBuilderFor("machine-1", "us-east-1b").Build(),
+					machineBuilderFor("machine-2", "us-east-1a").Build(),
+					machineBuilderFor("machine-replacement-2", "us-east-1a").WithErrorMessage(capacityError).Build(),
+				},
+				expectedMapping: map[int32]failuredomain.FailureDomain{0: usEast1a, 1: usEast1b, 2: usEast1b},
+				expectedLogs: []testutils.LogEntry{
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"index", 2,
+							"oldFailureDomain", usEast1a.String(),
+							"newFailureDomain", usEast1b.String(),
+						},
+						Message: "Failed over index to another failure domain",
+					},
+				},
+			}),
+			Entry("with an index which has already failed over", failoverTableInput{
+				failureDomains: []failuredomain.FailureDomain{usEast1a, usEast1b, usEast1c, usEast1d},
+				mapping:        map[int32]failuredomain.FailureDomain{0: usEast1a, 1: usEast1d, 2: usEast1c},
+				machines: []*machinev1beta1.Machine{
+					machineBuilderFor("machine-0", "us-east-1a").Build(),
+					machineBuilderFor("machine-1", "us-east-1b").Build(),
+					machineBuilderFor("machine-replacement-1", "us-east-1b").WithErrorMessage(capacityError).Build(),
+					machineBuilderFor("machine-failover-1", "us-east-1d").Build(),
+					machineBuilderFor("machine-2", "us-east-1c").Build(),
+				},
+				expectedMapping: map[int32]failuredomain.FailureDomain{0: usEast1a, 1: usEast1d, 2: usEast1c},
+				expectedLogs:    []testutils.LogEntry{},
+			}),
+			Entry("with an index which has already failed over, to a failure domain which also lacks capacity", failoverTableInput{
+				failureDomains: []failuredomain.FailureDomain{usEast1a, usEast1b, usEast1c, usEast1d, usEast1e},
+				mapping:        map[int32]failuredomain.FailureDomain{0: usEast1a, 1: usEast1d, 2: usEast1c},
+				machines: []*machinev1beta1.Machine{
+					machineBuilderFor("machine-0", "us-east-1a").Build(),
+					machineBuilderFor("machine-1", "us-east-1b").Build(),
+					machineBuilderFor("machine-replacement-1", "us-east-1b").WithErrorMessage(capacityError).Build(),
+					machineBuilderFor("machine-failover-1", "us-east-1d").WithErrorMessage(capacityError).Build(),
+					machineBuilderFor("machine-2", "us-east-1c").Build(),
+				},
+				expectedMapping: map[int32]failuredomain.FailureDomain{0: usEast1a, 1: usEast1e, 2: usEast1c},
+				expectedLogs: []testutils.LogEntry{
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"index", 1,
+							"oldFailureDomain", usEast1d.String(),
+							"newFailureDomain", usEast1e.String(),
+						},
+						Message: "Failed over index to another failure domain",
+					},
+				},
+			}),
+		)
+	})
+
+	Context("getFailureDomainFailover", func() {
+		type getFailureDomainFailoverTableInput struct {
+			capacityFailover bool
+			mapping          map[int32]failuredomain.FailureDomain
+			machine          *machinev1beta1.Machine
+			expectedFailover *machineproviders.FailureDomainFailover
+		}
+
+		DescribeTable("should report the failover of the index of the machine", func(in getFailureDomainFailoverTableInput) {
+			logger := testutils.NewTestLogger()
+
+			provider := &openshiftMachineProvider{
+				capacityFailover:     in.capacityFailover,
+				indexToFailureDomain: in.mapping,
+			}
+
+			failover, err := provider.getFailureDomainFailover(logger.Logger(), *in.machine, 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(failover).To(Equal(in.expectedFailover))
+		},
+			Entry("with a failed machine whose index has failed over", getFailureDomainFailoverTableInput{
+				capacityFailover: true,
+				mapping:          map[int32]failuredomain.FailureDomain{0: usEast1a, 1: usEast1d, 2: usEast1c},
+				machine:          machineBuilderFor("machine-replacement-1", "us-east-1b").WithErrorMessage(capacityError).Build(),
+				expectedFailover: &machineproviders.FailureDomainFailover{
+					From: usEast1b.String(),
+					To:   usEast1d.String(),
+				},
+			}),
+			Entry("with a failed machine whose index has not failed over", getFailureDomainFailoverTableInput{
+				capacityFailover: true,
+				mapping:          map[int32]failuredomain.FailureDomain{0: usEast1a, 1: usEast1b, 2: usEast1c},
+				machine:          machineBuilderFor("machine-replacement-1", "us-east-1b").WithErrorMessage(capacityError).Build(),
+				expectedFailover: nil,
+			}),
+			Entry("with a machine which did not fail", getFailureDomainFailoverTableInput{
+				capacityFailover: true,
+				mapping:          map[int32]failuredomain.FailureDomain{0: usEast1a, 1: usEast1d, 2: usEast1c},
+				machine:          machineBuilderFor("machine-1", "us-east-1b").Build(),
+				expectedFailover: nil,
+			}),
+			Entry("with capacity failover disabled", getFailureDomainFailoverTableInput{
+				capacityFailover: false,
+				mapping:          map[int32]failuredomain.FailureDomain{0: usEast1a, 1: usEast1d, 2: usEast1c},
+				machine:          machineBuilderFor("machine-replacement-1", "us-east-1b").WithErrorMessage(capacityError).Build(),
+				expectedFailover: nil,
+			}),
+		)
+	})
+})
@@ -24,6 +24,7 @@ import (
 
 	configv1 "github.com/openshift/api/config/v1"
 	machinev1 "github.com/openshift/api/machine/v1"
+	"k8s.io/utils/pointer"
 )
 
 const (
@@ -42,6 +43,17 @@ var (
 	errMissingFailureDomain = errors.New("missing failure domain configuration")
 )
 
+// NutanixFailureDomain holds the configuration of a Nutanix failure domain.
+// The ControlPlaneMachineSet API does not provide Nutanix failure domains, instead
+// these are built from the Prism Elements (clusters) defined on the Infrastructure resource.
+type NutanixFailureDomain struct {
+	// Cluster identifies the Prism Element (cluster) in which the Machine is created.
+	Cluster machinev1.NutanixResourceIdentifier
+
+	// Subnets identifies the subnets to which the network interfaces of the Machine are attached.
+	Subnets []machinev1.NutanixResourceIdentifier
+}
+
 // FailureDomain is an interface that allows external code to interact with
 // failure domains across different platform types.
 type FailureDomain interface {
@@ -60,9 +72,15 @@ type FailureDomain interface {
 	// GCP returns the GCPFailureDomain if the platform type is GCP.
 	GCP() machinev1.GCPFailureDomain
 
+	// Nutanix returns the NutanixFailureDomain if the platform type is Nutanix.
+	Nutanix() NutanixFailureDomain
+
 	// OpenStack returns the OpenStackFailureDomain if the platform type is OpenStack.
 	OpenStack() machinev1.OpenStackFailureDomain
 
+	// VSphere returns the VSpherePlatformFailureDomainSpec if the platform type is VSphere.
+	VSphere() configv1.VSpherePlatformFailureDomainSpec
+
 	// Equal compares the underlying failure domain.
 	Equal(other FailureDomain) bool
 }
@@ -74,7 +92,9 @@ type failureDomain struct {
 	aws       machinev1.AWSFailureDomain
 	azure     machinev1.AzureFailureDomain
 	gcp       machinev1.GCPFailureDomain
+	nutanix   NutanixFailureDomain
 	openstack machinev1.OpenStackFailureDomain
+	vsphere   configv1.VSpherePlatformFailureDomainSpec
 }
 
 // String returns a string representation of the failure domain.
@@ -86,8 +106,12 @@ func (f failureDomain) String() string {
 		return azureFailureDomainToString(f.azure)
 	case configv1.GCPPlatformType:
 		return gcpFailureDomainToString(f.gcp)
+	case configv1.NutanixPlatformType:
+		return nutanixFailureDomainToString(f.nutanix)
 	case configv1.OpenStackPlatformType:
 		return openstackFailureDomainToString(f.openstack)
+	case configv1.VSpherePlatformType:
+		return vsphereFailureDomainToString(f.vsphere)
 	default:
 		return fmt.Sprintf("%sFailureDomain{}", f.platformType)
 	}
@@ -113,11 +137,21 @@ func (f failureDomain) GCP() machinev1.GCPFailureDomain {
 	return f.gcp
 }
 
+// Nutanix returns the NutanixFailureDomain if the platform type is Nutanix.
+func (f failureDomain) Nutanix() NutanixFailureDomain {
+	return f.nutanix
+}
+
 // OpenStack returns the OpenStackFailureDomain if the platform type is OpenStack.
 func (f failureDomain) OpenStack() machinev1.OpenStackFailureDomain {
 	return f.openstack
 }
 
+// VSphere returns the VSpherePlatformFailureDomainSpec if the platform type is VSphere.
+func (f failureDomain) VSphere() configv1.VSpherePlatformFailureDomainSpec {
+	return f.vsphere
+}
+
 // Equal compares the underlying failure domain.
 func (f failureDomain) Equal(other FailureDomain) bool {
 	if other == nil {
@@ -135,8 +169,12 @@ func (f failureDomain) Equal(other FailureDomain) bool {
 		return f.azure == other.Azure()
 	case configv1.GCPPlatformType:
 		return f.gcp == other.GCP()
+	case configv1.NutanixPlatformType:
+		return nutanixFailureDomainsEqual(f.nutanix, other.Nutanix())
 	case configv1.OpenStackPlatformType:
 		return reflect.DeepEqual(f.openstack, other.OpenStack())
+	case configv1.VSpherePlatformType:
+		return vsphereFailureDomainsEqual(f.vsphere, other.VSphere())
 	}
 
 	return true
@@ -162,6 +200,129 @@ func NewFailureDomains(failureDomains machinev1.FailureDomains) ([]FailureDomain
 	}
 }
 
+// NewFailureDomainsFromInfrastructure creates a set of FailureDomains representing the
+// failure domains defined within the platform spec of the Infrastructure resource.
+// This is used for platforms where the ControlPlaneMachineSet API has no failure domain
+// configuration of its own. For all other platforms, no failure domains are returned.
+// Configuration which the Infrastructure does not define, such as the Nutanix subnets,
+// is taken from the template failure domain, which may be nil.
+func NewFailureDomainsFromInfrastructure(infrastructure *configv1.Infrastructure, template FailureDomain) []FailureDomain {
+	if infrastructure == nil {
+		return nil
+	}
+
+	switch infrastructure.Spec.PlatformSpec.Type {
+	case configv1.NutanixPlatformType:
+		return newNutanixFailureDomains(infrastructure, template)
+	case configv1.VSpherePlatformType:
+		return newVSphereFailureDomains(infrastructure)
+	default:
+		return nil
+	}
+}
+
+// newNutanixFailureDomains constructs a slice of Nutanix FailureDomain from the Prism Elements
+// defined on the Infrastructure resource.
+// Failure domains are only constructed when more than one Prism Element is defined, a single
+// Prism Element is already referenced by the existing Machines, which may use its UUID rather than its name.
+// The Infrastructure only names the Prism Elements, so when the template references its cluster by UUID,
+// the Machines cannot be matched to the Prism Elements and no failure domains are constructed.
+// The Infrastructure does not define subnets for each Prism Element, so the subnets are taken from the template.
+func newNutanixFailureDomains(infrastructure *configv1.Infrastructure, template FailureDomain) []FailureDomain {
+	if infrastructure.Spec.PlatformSpec.Nutanix == nil || len(infrastructure.Spec.PlatformSpec.Nutanix.PrismElements) <= 1 {
+		return nil
+	}
+
+	if template != nil && template.Nutanix().Cluster.Type == machinev1.NutanixIdentifierUUID {
+		return nil
+	}
+
+	var subnets []machinev1.NutanixResourceIdentifier
+	if template != nil {
+		subnets = template.Nutanix().Subnets
+	}
+
+	foundFailureDomains := []FailureDomain{}
+
+	for _, prismElement := range infrastructure.Spec.PlatformSpec.Nutanix.PrismElements {
+		fd := NutanixFailureDomain{
+			Cluster: machinev1.NutanixResourceIdentifier{
+				Type: machinev1.NutanixIdentifierName,
+				Name: pointer.String(prismElement.Name),
+			},
+		}
+
+		for _, subnet := range subnets {
+			fd.Subnets = append(fd.Subnets, *subnet.DeepCopy())
+		}
+
+		foundFailureDomains = append(foundFailureDomains, NewNutanixFailureDomain(fd))
+	}
+
+	return foundFailureDomains
+}
+
+// nutanixFailureDomainsEqual compares two NutanixFailureDomains.
+// Resource identifiers are compared by the identifier matching their type, so that an identifier
+// referencing a Prism Element by name is not considered different because of an unused UUID.
+func nutanixFailureDomainsEqual(a, b NutanixFailureDomain) bool {
+	if !nutanixResourceIdentifiersEqual(a.Cluster, b.Cluster) || len(a.Subnets) != len(b.Subnets) {
+		return false
+	}
+
+	for i := range a.Subnets {
+		if !nutanixResourceIdentifiersEqual(a.Subnets[i], b.Subnets[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// nutanixResourceIdentifiersEqual compares two NutanixResourceIdentifiers by the identifier matching their type.
+func nutanixResourceIdentifiersEqual(a, b machinev1.NutanixResourceIdentifier) bool {
+	if a.Type != b.Type {
+		return false
+	}
+
+	switch a.Type {
+	case machinev1.NutanixIdentifierUUID:
+		return pointer.StringDeref(a.UUID, "") == pointer.StringDeref(b.UUID, "")
+	case machinev1.NutanixIdentifierName:
+		return pointer.StringDeref(a.Name, "") == pointer.StringDeref(b.Name, "")
+	default:
+		return reflect.DeepEqual(a, b)
+	}
+}
+
+// newVSphereFailureDomains constructs a slice of VSphere FailureDomain from the
+// Infrastructure resource.
+// Empty resource pools and folders are defaulted in the same way as the installer
+// defaults them when it creates the Machines.
+func newVSphereFailureDomains(infrastructure *configv1.Infrastructure) []FailureDomain {
+	if infrastructure.Spec.PlatformSpec.VSphere == nil || len(infrastructure.Spec.PlatformSpec.VSphere.FailureDomains) == 0 {
+		return nil
+	}
+
+	foundFailureDomains := []FailureDomain{}
+
+	for _, failureDomain := range infrastructure.Spec.PlatformSpec.VSphere.FailureDomains {
+		fd := *failureDomain.DeepCopy()
+
+		if fd.Topology.ResourcePool == "" && fd.Topology.ComputeCluster != "" {
+			fd.Topology.ResourcePool = fd.Topology.ComputeCluster + "/Resources"
+		}
+
+		if fd.Topology.Folder == "" && fd.Topology.Datacenter != "" && infrastructure.Status.InfrastructureName != "" {
+			fd.Topology.Folder = fmt.Sprintf("/%s/vm/%s", fd.Topology.Datacenter, infrastructure.Status.InfrastructureName)
+		}
+
+		foundFailureDomains = append(foundFailureDomains, NewVSphereFailureDomain(fd))
+	}
+
+	return foundFailureDomains
+}
+
 // newAWSFailureDomains constructs a slice of AWS FailureDomain from machinev1.FailureDomains.
 func newAWSFailureDomains(failureDomains machinev1.FailureDomains) ([]FailureDomain, error) {
 	foundFailureDomains := []FailureDomain{}
@@ -245,6 +406,14 @@ func NewGCPFailureDomain(fd machinev1.GCPFailureDomain) FailureDomain {
 	}
 }
 
+// NewNutanixFailureDomain creates a Nutanix failure domain from the NutanixFailureDomain.
+func NewNutanixFailureDomain(fd NutanixFailureDomain) FailureDomain {
+	return &failureDomain{
+		platformType: configv1.NutanixPlatformType,
+		nutanix:      fd,
+	}
+}
+
 // NewOpenStackFailureDomain creates an OpenStack failure domain from the machinev1.OpenStackFailureDomain.
 func NewOpenStackFailureDomain(fd machinev1.OpenStackFailureDomain) FailureDomain {
 	return &failureDomain{
@@ -253,6 +422,14 @@ func NewOpenStackFailureDomain(fd machinev1.OpenStackFailureDomain) FailureDomai
 	}
 }
 
+// NewVSphereFailureDomain creates a VSphere failure domain from the configv1.VSpherePlatformFailureDomainSpec.
+func NewVSphereFailureDomain(fd configv1.VSpherePlatformFailureDomainSpec) FailureDomain {
+	return &failureDomain{
+		platformType: configv1.VSpherePlatformType,
+		vsphere:      fd,
+	}
+}
+
 // NewGenericFailureDomain creates a dummy failure domain for generic platforms that don't support failure domains.
 func NewGenericFailureDomain() FailureDomain {
 	return failureDomain{}
@@ -316,6 +493,44 @@ func gcpFailureDomainToString(fd machinev1.GCPFailureDomain) string {
 	return unknownFailureDomain
 }
 
+// nutanixFailureDomainToString converts the NutanixFailureDomain into a string.
+func nutanixFailureDomainToString(fd NutanixFailureDomain) string {
+	if fd.Cluster.Type == "" && len(fd.Subnets) == 0 {
+		return unknownFailureDomain
+	}
+
+	var failureDomain []string
+
+	if fd.Cluster.Type != "" {
+		failureDomain = append(failureDomain, "Cluster:"+nutanixResourceIdentifierToString(fd.Cluster))
+	}
+
+	if len(fd.Subnets) > 0 {
+		var subnets []string
+
+		for _, subnet := range fd.Subnets {
+			subnets = append(subnets, nutanixResourceIdentifierToString(subnet))
+		}
+
+		failureDomain = append(failureDomain, "Subnets:["+strings.Join(subnets, ", ")+"]")
+	}
+
+	return "NutanixFailureDomain{" + strings.Join(failureDomain, ", ") + "}"
+}
+
+// nutanixResourceIdentifierToString converts the NutanixResourceIdentifier into a string.
+// Only the identifier matching the type is included.
+func nutanixResourceIdentifierToString(id machinev1.NutanixResourceIdentifier) string {
+	switch id.Type {
+	case machinev1.NutanixIdentifierUUID:
+		return fmt.Sprintf("{Type:%s, UUID:%s}", id.Type, pointer.StringDeref(id.UUID, ""))
+	case machinev1.NutanixIdentifierName:
+		return fmt.Sprintf("{Type:%s, Name:%s}", id.Type, pointer.StringDeref(id.Name, ""))
+	default:
+		return fmt.Sprintf("{Type:%s}", id.Type)
+	}
+}
+
 // openstackFailureDomainToString converts the OpenStackFailureDomain into a string.
 func openstackFailureDomainToString(fd machinev1.OpenStackFailureDomain) string {
 	if fd.AvailabilityZone == "" && fd.RootVolume == nil {
@@ -344,3 +559,48 @@ func openstackFailureDomainToString(fd machinev1.OpenStackFailureDomain) string
 
 	return "OpenStackFailureDomain{" + strings.Join(failureDomain, ", ") + "}"
 }
+
+// vsphereFailureDomainToString converts the VSpherePlatformFailureDomainSpec into a string.
+// Only the fields that are reflected within the Machine provider spec are included,
+// so that failure domains extracted from Machines match those defined on the Infrastructure.
+func vsphereFailureDomainToString(fd configv1.VSpherePlatformFailureDomainSpec) string {
+	var failureDomain []string
+
+	for _, field := range []struct {
+		name  string
+		value string
+	}{
+		{name: "Server", value: fd.Server},
+		{name: "Datacenter", value: fd.Topology.Datacenter},
+		{name: "Datastore", value: fd.Topology.Datastore},
+		{name: "ResourcePool", value: fd.Topology.ResourcePool},
+		{name: "Folder", value: fd.Topology.Folder},
+	} {
+		if field.value != "" {
+			failureDomain = append(failureDomain, field.name+":"+field.value)
+		}
+	}
+
+	if len(fd.Topology.Networks) > 0 {
+		failureDomain = append(failureDomain, "Networks:["+strings.Join(fd.Topology.Networks, ", ")+"]")
+	}
+
+	if len(failureDomain) == 0 {
+		return unknownFailureDomain
+	}
+
+	return "VSphereFailureDomain{" + strings.Join(failureDomain, ", ") + "}"
+}
+
+// vsphereFailureDomainsEqual compares the fields of the VSphere failure domains that
+// are reflected within the Machine provider spec.
+// The name, region, zone and compute cluster are not stored on the Machine and so
+// are ignored.
+func vsphereFailureDomainsEqual(a, b configv1.VSpherePlatformFailureDomainSpec) bool {
+	return a.Server == b.Server &&
+		a.Topology.Datacenter == b.Topology.Datacenter &&
+		a.Topology.Datastore == b.Topology.Datastore &&
+		a.Topology.ResourcePool == b.Topology.ResourcePool &&
+		a.Topology.Folder == b.Topology.Folder &&
+		reflect.DeepEqual(a.Topology.Networks, b.Topology.Networks)
+}
@@ -23,6 +23,7 @@ import (
 	configv1 "github.com/openshift/api/config/v1"
 	machinev1 "github.com/openshift/api/machine/v1"
 	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
+	"k8s.io/utils/pointer"
 )
 
 var _ = Describe("FailureDomains", func() {
@@ -265,6 +266,159 @@ var _ = Describe("FailureDomains", func() {
 		})
 	})
 
+	Context("NewFailureDomainsFromInfrastructure", func() {
+		var infrastructure *configv1.Infrastructure
+
+		BeforeEach(func() {
+			infrastructure = &configv1.Infrastructure{
+				Spec: configv1.InfrastructureSpec{
+					PlatformSpec: configv1.PlatformSpec{
+						Type: configv1.VSpherePlatformType,
+						VSphere: &configv1.VSpherePlatformSpec{
+							FailureDomains: []configv1.VSpherePlatformFailureDomainSpec{
+								{
+									Name:   "us-east-1",
+									Region: "us-east",
+									Zone:   "us-east-1a",
+									Server: "vcenter.example.com",
+									Topology: configv1.VSpherePlatformTopology{
+										Datacenter:     "dc1",
+										ComputeCluster: "/dc1/host/cluster1",
+										Networks:       []string{"network1"},
+										Datastore:      "/dc1/datastore/datastore1",
+										ResourcePool:   "/dc1/host/cluster1/Resources/pool1",
+										Folder:         "/dc1/vm/folder1",
+									},
+								},
+								{
+									Name:   "us-east-2",
+									Region: "us-east",
+									Zone:   "us-east-2a",
+									Server: "vcenter.example.com",
+									Topology: configv1.VSpherePlatformTopology{
+										Datacenter:     "dc2",
+										ComputeCluster: "/dc2/host/cluster2",
+										Networks:       []string{"network2"},
+										Datastore:      "/dc2/datastore/datastore2",
+									},
+								},
+							},
+						},
+					},
+				},
+				Status: configv1.InfrastructureStatus{
+					InfrastructureName: "cluster-abcde",
+				},
+			}
+		})
+
+		Context("with VSphere failure domains", func() {
+			It("returns the failure domains, defaulting the resource pool and folder", func() {
+				Expect(NewFailureDomainsFromInfrastructure(infrastructure, nil)).To(ConsistOf(
+					HaveField("String()", "VSphereFailureDomain{Server:vcenter.example.com, Datacenter:dc1, Datastore:/dc1/datastore/datastore1, ResourcePool:/dc1/host/cluster1/Resources/pool1, Folder:/dc1/vm/folder1, Networks:[network1]}"),
+					HaveField("String()", "VSphereFailureDomain{Server:vcenter.example.com, Datacenter:dc2, Datastore:/dc2/datastore/datastore2, ResourcePool:/dc2/host/cluster2/Resources, Folder:/dc2/vm/cluster-abcde, Networks:[network2]}"),
+				))
+			})
+
+			It("does not modify the Infrastructure", func() {
+				_ = NewFailureDomainsFromInfrastructure(infrastructure, nil)
+
+				Expect(infrastructure.Spec.PlatformSpec.VSphere.FailureDomains[1].Topology.ResourcePool).To(BeEmpty())
+				Expect(infrastructure.Spec.PlatformSpec.VSphere.FailureDomains[1].Topology.Folder).To(BeEmpty())
+			})
+		})
+
+		Context("with no VSphere failure domains", func() {
+			BeforeEach(func() {
+				infrastructure.Spec.PlatformSpec.VSphere.FailureDomains = nil
+			})
+
+			It("returns no failure domains", func() {
+				Expect(NewFailureDomainsFromInfrastructure(infrastructure, nil)).To(BeEmpty())
+			})
+		})
+
+		Context("with a platform that does not define failure domains on the Infrastructure", func() {
+			BeforeEach(func() {
+				infrastructure.Spec.PlatformSpec.Type = configv1.AWSPlatformType
+			})
+
+			It("returns no failure domains", func() {
+				Expect(NewFailureDomainsFromInfrastructure(infrastructure, nil)).To(BeEmpty())
+			})
+		})
+
+		Context("with a nil Infrastructure", func() {
+			It("returns no failure domains", func() {
+				Expect(NewFailureDomainsFromInfrastructure(nil, nil)).To(BeEmpty())
+			})
+		})
+
+		Context("with Nutanix Prism Elements", func() {
+			var template FailureDomain
+
+			BeforeEach(func() {
+				infrastructure = &configv1.Infrastructure{
+					Spec: configv1.InfrastructureSpec{
+						PlatformSpec: configv1.PlatformSpec{
+							Type: configv1.NutanixPlatformType,
+							Nutanix: &configv1.NutanixPlatformSpec{
+								PrismElements: []configv1.NutanixPrismElementEndpoint{
+									{Name: "pe1"},
+									{Name: "pe2"},
+									{Name: "pe3"},
+								},
+							},
+						},
+					},
+				}
+
+				template = NewNutanixFailureDomain(NutanixFailureDomain{
+					Cluster: machinev1.NutanixResourceIdentifier{Type: machinev1.NutanixIdentifierName, Name: pointer.String("pe1")},
+					Subnets: []machinev1.NutanixResourceIdentifier{{Type: machinev1.NutanixIdentifierName, Name: pointer.String("subnet1")}},
+				})
+			})
+
+			It("returns a failure domain for each Prism Element, with the subnets of the template", func() {
+				Expect(NewFailureDomainsFromInfrastructure(infrastructure, template)).To(ConsistOf(
+					HaveField("String()", "NutanixFailureDomain{Cluster:{Type:name, Name:pe1}, Subnets:[{Type:name, Name:subnet1}]}"),
+					HaveField("String()", "NutanixFailureDomain{Cluster:{Type:name, Name:pe2}, Subnets:[{Type:name, Name:subnet1}]}"),
+					HaveField("String()", "NutanixFailureDomain{Cluster:{Type:name, Name:pe3}, Subnets:[{Type:name, Name:subnet1}]}"),
+				))
+			})
+
+			It("returns failure domains without subnets when there is no template", func() {
+				Expect(NewFailureDomainsFromInfrastructure(infrastructure, nil)).To(ConsistOf(
+					HaveField("String()", "NutanixFailureDomain{Cluster:{Type:name, Name:pe1}}"),
+					HaveField("String()", "NutanixFailureDomain{Cluster:{Type:name, Name:pe2}}"),
+					HaveField("String()", "NutanixFailureDomain{Cluster:{Type:name, Name:pe3}}"),
+				))
+			})
+
+			Context("with a template referencing its cluster by UUID", func() {
+				BeforeEach(func() {
+					template = NewNutanixFailureDomain(NutanixFailureDomain{
+						Cluster: machinev1.NutanixResourceIdentifier{Type: machinev1.NutanixIdentifierUUID, UUID: pointer.String("0000-1111")},
+					})
+				})
+
+				It("returns no failure domains", func() {
+					Expect(NewFailureDomainsFromInfrastructure(infrastructure, template)).To(BeEmpty())
+				})
+			})
+
+			Context("with a single Prism Element", func() {
+				BeforeEach(func() {
+					infrastructure.Spec.PlatformSpec.Nutanix.PrismElements = infrastructure.Spec.PlatformSpec.Nutanix.PrismElements[:1]
+				})
+
+				It("returns no failure domains", func() {
+					Expect(NewFailureDomainsFromInfrastructure(infrastructure, template)).To(BeEmpty())
+				})
+			})
+		})
+	})
+
 	Context("an AWS failure domain", func() {
 		var fd failureDomain
 
@@ -418,6 +572,78 @@ var _ = Describe("FailureDomains", func() {
 		})
 	})
 
+	Context("a Nutanix failure domain", func() {
+		var fd failureDomain
+
+		BeforeEach(func() {
+			fd = failureDomain{
+				platformType: configv1.NutanixPlatformType,
+			}
+		})
+
+		Context("with a cluster and subnets", func() {
+			BeforeEach(func() {
+				fd.nutanix = NutanixFailureDomain{
+					Cluster: machinev1.NutanixResourceIdentifier{Type: machinev1.NutanixIdentifierUUID, UUID: pointer.String("0000-1111")},
+					Subnets: []machinev1.NutanixResourceIdentifier{
+						{Type: machinev1.NutanixIdentifierName, Name: pointer.String("subnet1")},
+						{Type: machinev1.NutanixIdentifierUUID, UUID: pointer.String("2222-3333")},
+					},
+				}
+			})
+
+			It("returns the cluster and subnets for String()", func() {
+				Expect(fd.String()).To(Equal("NutanixFailureDomain{Cluster:{Type:uuid, UUID:0000-1111}, Subnets:[{Type:name, Name:subnet1}, {Type:uuid, UUID:2222-3333}]}"))
+			})
+		})
+
+		Context("with no cluster or subnets", func() {
+			It("returns <unknown> for String()", func() {
+				Expect(fd.String()).To(Equal("<unknown>"))
+			})
+		})
+	})
+
+	Context("a VSphere failure domain", func() {
+		var fd failureDomain
+
+		BeforeEach(func() {
+			fd = failureDomain{
+				platformType: configv1.VSpherePlatformType,
+			}
+		})
+
+		Context("with a server and topology", func() {
+			BeforeEach(func() {
+				fd.vsphere = configv1.VSpherePlatformFailureDomainSpec{
+					Name:   "us-east-1",
+					Server: "vcenter.example.com",
+					Topology: configv1.VSpherePlatformTopology{
+						Datacenter: "dc1",
+						Datastore:  "/dc1/datastore/datastore1",
+						Networks:   []string{"network1", "network2"},
+					},
+				}
+			})
+
+			It("returns the populated fields for String()", func() {
+				Expect(fd.String()).To(Equal("VSphereFailureDomain{Server:vcenter.example.com, Datacenter:dc1, Datastore:/dc1/datastore/datastore1, Networks:[network1, network2]}"))
+			})
+		})
+
+		Context("with no server or topology", func() {
+			BeforeEach(func() {
+				fd.vsphere = configv1.VSpherePlatformFailureDomainSpec{
+					Name: "us-east-1",
+				}
+			})
+
+			It("returns <unknown> for String()", func() {
+				Expect(fd.String()).To(Equal("<unknown>"))
+			})
+		})
+	})
+
 	Context("Equal", func() {
 		var fd1 failureDomain
 		var fd2 failureDomain
@@ -557,6 +783,128 @@ var _ = Describe("FailureDomains", func() {
 			})
 		})
 
+		Context("With two identical Nutanix failure domains", func() {
+			BeforeEach(func() {
+				fd1 = failureDomain{
+					platformType: configv1.NutanixPlatformType,
+					nutanix:      NutanixFailureDomain{Cluster: machinev1.NutanixResourceIdentifier{Type: machinev1.NutanixIdentifierName, Name: pointer.String("pe1")}},
+				}
+				fd2 = failureDomain{
+					platformType: configv1.NutanixPlatformType,
+					nutanix:      NutanixFailureDomain{Cluster: machinev1.NutanixResourceIdentifier{Type: machinev1.NutanixIdentifierName, Name: pointer.String("pe1")}},
+				}
+			})
+
+			It("returns true", func() {
+				Expect(fd1.Equal(fd2)).To(BeTrue())
+			})
+		})
+
+		Context("With two different Nutanix failure domains", func() {
+			BeforeEach(func() {
+				fd1 = failureDomain{
+					platformType: configv1.NutanixPlatformType,
+					nutanix:      NutanixFailureDomain{Cluster: machinev1.NutanixResourceIdentifier{Type: machinev1.NutanixIdentifierName, Name: pointer.String("pe1")}},
+				}
+				fd2 = failureDomain{
+					platformType: configv1.NutanixPlatformType,
+					nutanix:      NutanixFailureDomain{Cluster: machinev1.NutanixResourceIdentifier{Type: machinev1.NutanixIdentifierName, Name: pointer.String("pe2")}},
+				}
+			})
+
+			It("returns false", func() {
+				Expect(fd1.Equal(fd2)).To(BeFalse())
+			})
+		})
+
+		Context("With two Nutanix failure domains differing only in the unused identifier", func() {
+			BeforeEach(func() {
+				fd1 = failureDomain{
+					platformType: configv1.NutanixPlatformType,
+					nutanix:      NutanixFailureDomain{Cluster: machinev1.NutanixResourceIdentifier{Type: machinev1.NutanixIdentifierName, Name: pointer.String("pe1")}},
+				}
+				fd2 = failureDomain{
+					platformType: configv1.NutanixPlatformType,
+					nutanix:      NutanixFailureDomain{Cluster: machinev1.NutanixResourceIdentifier{Type: machinev1.NutanixIdentifierName, Name: pointer.String("pe1"), UUID: pointer.String("0000-1111")}},
+				}
+			})
+
+			It("returns true", func() {
+				Expect(fd1.Equal(fd2)).To(BeTrue())
+			})
+		})
+
+		Context("With two Nutanix failure domains with different subnets", func() {
+			BeforeEach(func() {
+				fd1 = failureDomain{
+					platformType: configv1.NutanixPlatformType,
+					nutanix: NutanixFailureDomain{
+						Cluster: machinev1.NutanixResourceIdentifier{Type: machinev1.NutanixIdentifierName, Name: pointer.String("pe1")},
+						Subnets: []machinev1.NutanixResourceIdentifier{{Type: machinev1.NutanixIdentifierName, Name: pointer.String("subnet1")}},
+					},
+				}
+				fd2 = failureDomain{
+					platformType: configv1.NutanixPlatformType,
+					nutanix: NutanixFailureDomain{
+						Cluster: machinev1.NutanixResourceIdentifier{Type: machinev1.NutanixIdentifierName, Name: pointer.String("pe1")},
+						Subnets: []machinev1.NutanixResourceIdentifier{{Type: machinev1.NutanixIdentifierName, Name: pointer.String("subnet2")}},
+					},
+				}
+			})
+
+			It("returns false", func() {
+				Expect(fd1.Equal(fd2)).To(BeFalse())
+			})
+		})
+
+		Context("With two VSphere failure domains differing only in fields not stored on the Machine", func() {
+			BeforeEach(func() {
+				fd1 = failureDomain{
+					platformType: configv1.VSpherePlatformType,
+					vsphere: configv1.VSpherePlatformFailureDomainSpec{
+						Name:     "us-east-1",
+						Zone:     "us-east-1a",
+						Server:   "vcenter.example.com",
+						Topology: configv1.VSpherePlatformTopology{Datacenter: "dc1", ComputeCluster: "/dc1/host/cluster1", Networks: []string{"network1"}},
+					},
+				}
+				fd2 = failureDomain{
+					platformType: configv1.VSpherePlatformType,
+					vsphere: configv1.VSpherePlatformFailureDomainSpec{
+						Server:   "vcenter.example.com",
+						Topology: configv1.VSpherePlatformTopology{Datacenter: "dc1", Networks: []string{"network1"}},
+					},
+				}
+			})
+
+			It("returns true", func() {
+				Expect(fd1.Equal(fd2)).To(BeTrue())
+			})
+		})
+
+		Context("With two different VSphere failure domains", func() {
+			BeforeEach(func() {
+				fd1 = failureDomain{
+					platformType: configv1.VSpherePlatformType,
+					vsphere: configv1.VSpherePlatformFailureDomainSpec{
+						Server:   "vcenter.example.com",
+						Topology: configv1.VSpherePlatformTopology{Datacenter: "dc1"},
+					},
+				}
+				fd2 = failureDomain{
+					platformType: configv1.VSpherePlatformType,
+					vsphere: configv1.VSpherePlatformFailureDomainSpec{
+						Server:   "vcenter.example.com",
+						Topology: configv1.VSpherePlatformTopology{Datacenter: "dc2"},
+					},
+				}
+			})
+
+			It("returns false", func() {
+				Expect(fd1.Equal(fd2)).To(BeFalse())
+			})
+		})
+
 		Context("With different failure domains platform", func() {
 			BeforeEach(func() {
 				fd1 = failureDomain{
@@ -46,7 +46,8 @@ var (
 // to by external code to create new Machines in the same failure domain. It should start with a basic mapping and
 // then use existing Machine information to map failure domains, if possible, so that the Machine names match the
 // index of the failure domain in which they currently reside.
-func mapMachineIndexesToFailureDomains(logger logr.Logger, machines []machinev1beta1.Machine, replicas int32, failureDomains []failuredomain.FailureDomain) (map[int32]failuredomain.FailureDomain, error) {
+// When weights are configured, the failure domains are mapped to a share of the indexes based on their weight.
+func mapMachineIndexesToFailureDomains(logger logr.Logger, machines []machinev1beta1.Machine, replicas int32, failureDomains []failuredomain.FailureDomain, weights failureDomainWeights) (map[int32]failuredomain.FailureDomain, error) {
 	if len(failureDomains) == 0 {
 		logger.V(4).Info("No failure domains provided")
 
@@ -60,12 +61,12 @@ func mapMachineIndexesToFailureDomains(logger logr.Logger, machines []machinev1b
 
 	failureDomainsSet := failuredomain.NewSet(failureDomains...)
 
-	baseMapping, err := createBaseFailureDomainMapping(replicas, failureDomainsSet.List(), machineMapping)
+	baseMapping, err := createBaseFailureDomainMapping(replicas, failureDomainsSet.List(), machineMapping, weights)
 	if err != nil {
 		return nil, fmt.Errorf("could not construct base failure domain mapping: %w", err)
 	}
 
-	out := reconcileMappings(logger, baseMapping, machineMapping, deletingIndexes)
+	out := reconcileMappings(logger, baseMapping, machineMapping, deletingIndexes, weights)
 
 	logger.V(4).Info(
 		"Mapped provided failure domains",
@@ -81,7 +82,8 @@ func mapMachineIndexesToFailureDomains(logger logr.Logger, machines []machinev1b
 // domains.
 // Create the output based on the longer of the number of Machines or replicas so that when we reconcile the machine
 // mappings we always have enough candidates which are balanced between the available failure domains.
-func createBaseFailureDomainMapping(replicas int32, failureDomains []failuredomain.FailureDomain, machineMapping map[int32]failuredomain.FailureDomain) (map[int32]failuredomain.FailureDomain, error) {
+// When weights are configured, the candidates are instead balanced according to the weights of the failure domains.
+func createBaseFailureDomainMapping(replicas int32, failureDomains []failuredomain.FailureDomain, machineMapping map[int32]failuredomain.FailureDomain, weights failureDomainWeights) (map[int32]failuredomain.FailureDomain, error) {
 	out := make(map[int32]failuredomain.FailureDomain)
 
 	if replicas < 1 {
@@ -113,13 +115,39 @@ func createBaseFailureDomainMapping(replicas int32, failureDomains []failuredoma
 		return machineFailureDomains.Has(failureDomains[i]) && !machineFailureDomains.Has(failureDomains[j])
 	})
 
-	for i := int32(0); i < int32(machineIndexCount); i++ {
-		out[i] = failureDomains[i%int32(len(failureDomains))]
+	if !weights.isWeighted() {
+		for i := int32(0); i < int32(machineIndexCount); i++ {
+			out[i] = failureDomains[i%int32(len(failureDomains))]
+		}
+
+		return out, nil
+	}
+
+	for i, failureDomain := range spreadWeightedFailureDomains(failureDomains, machineIndexCount, weights) {
+		out[int32(i)] = failureDomain
 	}
 
 	return out, nil
 }
 
+// spreadWeightedFailureDomains returns the order in which the failure domains should be mapped to the given number of
+// indexes. The failure domains are used in turn, as they are without weights, however once a failure domain has been
+// used for its share of the indexes, it is skipped.
+func spreadWeightedFailureDomains(failureDomains []failuredomain.FailureDomain, count int, weights failureDomainWeights) []failuredomain.FailureDomain {
+	out := []failuredomain.FailureDomain{}
+	shares := weights.shares(failureDomains, count)
+
+	for round := 0; len(out) < count; round++ {
+		for i, failureDomain := range failureDomains {
+			if shares[i] > round {
+				out = append(out, failureDomain)
+			}
+		}
+	}
+
+	return out
+}
+
 // createMachineMapping inspects the state of the Machines on the cluster, selected by the ControlPlaneMachineSet, and
 // creates a mapping of their indexes (if available) to their failure domain to allow the mapping to be customised
 // to the state of the cluster.
@@ -229,7 +257,7 @@ func listDeletingIndexes(machines []machinev1beta1.Machine) sets.Set[int32] {
 // When processing the indexes, everything must be sorted to ensure the output is stable (note iterating over a map
 // is randomised by golang).
 // The base mapping should always be at least as long as the machine mapping for this to work.
-func reconcileMappings(logger logr.Logger, base, machines map[int32]failuredomain.FailureDomain, deletingIndexes sets.Set[int32]) map[int32]failuredomain.FailureDomain {
+func reconcileMappings(logger logr.Logger, base, machines map[int32]failuredomain.FailureDomain, deletingIndexes sets.Set[int32], weights failureDomainWeights) map[int32]failuredomain.FailureDomain {
 	if len(base) < len(machines) {
 		// This is a programming error since user input doesn't affect this.
 		panic("base must have at least as many indexes as machines")
@@ -254,13 +282,9 @@ func reconcileMappings(logger logr.Logger, base, machines map[int32]failuredomai
 	// Run through the mappings and match these to candidates where possible.
 	matchMachinesToCandidates(out, candidates, unmatchedIndexes, deletingIndexes)
 
-	// Get the maximum number of replicas per failure domain. This is needed
-	// to ensure we balance appropriately across the available failure domains.
-	maxPerFailureDomain := maxIndexesPerFailureDomain(base)
-
 	// Handle any remaining unmatched indexes.
 	for _, idx := range sortedIndexes(unmatchedIndexes) {
-		handleUnmatchedIndex(logger, idx, out, base, candidates, unmatchedIndexes, maxPerFailureDomain)
+		handleUnmatchedIndex(logger, idx, out, base, candidates, unmatchedIndexes, weights)
 	}
 
 	return out
@@ -312,7 +336,7 @@ func matchMachinesToCandidates(out, candidates map[int32]failuredomain.FailureDo
 // - The failure domain from the machine mapping was removed from the base.
 // - A new failure domain was added to the base mapping.
 // - The machine mapping is balanced in a different weighting to the machine mapping.
-func handleUnmatchedIndex(logger logr.Logger, idx int32, out, base, candidates map[int32]failuredomain.FailureDomain, unmatchedIndexes sets.Set[int32], maxPerFailureDomain int) {
+func handleUnmatchedIndex(logger logr.Logger, idx int32, out, base, candidates map[int32]failuredomain.FailureDomain, unmatchedIndexes sets.Set[int32], weights failureDomainWeights) {
 	switch {
 	case !indexExists(out, idx):
 		// There is no machine in this index presently,
@@ -331,7 +355,7 @@ func handleUnmatchedIndex(logger logr.Logger, idx int32, out, base, candidates m
 
 		out[idx] = candidates[idx]
 		useCandidate(candidates, unmatchedIndexes, idx)
-	case countForFailureDomain(out, out[idx]) > maxPerFailureDomain:
+	case countForFailureDomain(out, out[idx]) > maxIndexesForFailureDomain(base, out[idx], weights):
 		// This failure domain is over represented in the mapping.
 		// In this case, we must switch it to the candidate failure domain to rebalance
 		// the mapping.
@@ -346,7 +370,7 @@ func handleUnmatchedIndex(logger logr.Logger, idx int32, out, base, candidates m
 		useCandidate(candidates, unmatchedIndexes, idx)
 	default:
 		// The index exists, the failure domain is contained in the base,
-		// and is represented in the mapping fewer than maxPerFailureDomain times.
+		// and is not represented in the mapping more than the maximum number of times for the failure domain.
 		// In this case, it's ok to accept the mapping even though it doesn't match
 		// a candidate.
 		// This is likely to happen if the machine mapping is balanced using a
@@ -447,6 +471,18 @@ func maxIndexesPerFailureDomain(base map[int32]failuredomain.FailureDomain) int
 	return int(math.Ceil(d))
 }
 
+// maxIndexesForFailureDomain is used to calculate the maximum number of allowed indexes for the target failure domain.
+// Without weights, this is the same for every failure domain, to create a balanced mapping.
+// With weights, the base mapping is already spread according to the weights, so the failure domain may be used by as
+// many indexes as it is used by within the base mapping.
+func maxIndexesForFailureDomain(base map[int32]failuredomain.FailureDomain, target failuredomain.FailureDomain, weights failureDomainWeights) int {
+	if !weights.isWeighted() {
+		return maxIndexesPerFailureDomain(base)
+	}
+
+	return countForFailureDomain(base, target)
+}
+
 // countUniqueFailureDomain calculates the number of unique failure domains present within the passed mapping.
 func countUniqueFailureDomain(mapping map[int32]failuredomain.FailureDomain) int {
 	out := make(map[failuredomain.FailureDomain]struct{})
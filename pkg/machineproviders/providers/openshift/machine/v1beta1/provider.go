@@ -22,8 +22,10 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/api/config/v1"
 	machinev1 "github.com/openshift/api/machine/v1"
 	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
 	corev1 "k8s.io/api/core/v1"
@@ -38,6 +40,7 @@ import (
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers/openshift/machine/v1beta1/failuredomain"
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers/openshift/machine/v1beta1/providerconfig"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/util"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -53,6 +56,16 @@ const (
 	// openshiftMachineRoleLabel is the OpenShift Machine API machine role label.
 	// This must be present on all OpenShift Machine API Machine templates.
 	openshiftMachineRoleLabel = "machine.openshift.io/cluster-api-machine-role"
+
+	// infrastructureName is the name of the cluster wide Infrastructure resource.
+	infrastructureName = "cluster"
+
+	// replaceMachineDiff is the difference reported for a Machine which has been marked for replacement.
+	replaceMachineDiff = "Machine has been marked for replacement by the " + util.ReplaceMachineAnnotation + " annotation"
+
+	// machineAgeExceededDiff is the format of the difference reported for a Machine which is older than the
+	// maximum machine age.
+	machineAgeExceededDiff = "Machine was created at %s and has exceeded the maximum machine age of %s"
 )
 
 var (
@@ -92,7 +105,9 @@ var (
 )
 
 // NewMachineProvider creates a new OpenShift Machine v1beta1 machine provider implementation.
-func NewMachineProvider(ctx context.Context, logger logr.Logger, cl client.Client, cpms *machinev1.ControlPlaneMachineSet) (machineproviders.MachineProvider, error) {
+// The etcd client is used to read the etcd endpoints and etcd pods from the etcd namespace. When no etcd client is
+// given, these are read through the client.
+func NewMachineProvider(ctx context.Context, logger logr.Logger, cl client.Client, etcdClient client.Reader, cpms *machinev1.ControlPlaneMachineSet) (machineproviders.MachineProvider, error) {
 	if cpms.Spec.Template.MachineType != machinev1.OpenShiftMachineV1Beta1MachineType {
 		return nil, fmt.Errorf("%w: %s", errUnexpectedMachineType, cpms.Spec.Template.MachineType)
 	}
@@ -111,6 +126,18 @@ func NewMachineProvider(ctx context.Context, logger logr.Logger, cl client.Clien
 		return nil, fmt.Errorf("error constructing failure domain config: %w", err)
 	}
 
+	switch providerConfig.Type() {
+	case configv1.NutanixPlatformType, configv1.VSpherePlatformType:
+		// The ControlPlaneMachineSet has no Nutanix or vSphere failure domain configuration,
+		// the failure domains for these platforms are instead defined on the Infrastructure.
+		if len(failureDomains) == 0 {
+			failureDomains, err = getInfrastructureFailureDomains(ctx, cl, providerConfig.ExtractFailureDomain())
+			if err != nil {
+				return nil, fmt.Errorf("error constructing failure domain config from infrastructure: %w", err)
+			}
+		}
+	}
+
 	replicas := pointer.Int32Deref(cpms.Spec.Replicas, 0)
 
 	selector, err := metav1.LabelSelectorAsSelector(&cpms.Spec.Selector)
@@ -118,6 +145,38 @@ func NewMachineProvider(ctx context.Context, logger logr.Logger, cl client.Clien
 		return nil, fmt.Errorf("could not convert label selector to selector: %w", err)
 	}
 
+	revisionHash, err := util.ComputeTemplateHash(cpms.Spec.Template)
+	if err != nil {
+		return nil, fmt.Errorf("could not compute template revision hash: %w", err)
+	}
+
+	ignoreRules, err := providerconfig.NewIgnoreRulesFromAnnotations(cpms.Annotations)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse ignore rules: %w", err)
+	}
+
+	maxMachineAge, err := util.GetMaxMachineAge(cpms.Annotations)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse maximum machine age: %w", err)
+	}
+
+	capacityFailover, err := util.GetCapacityFailover(cpms.Annotations)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse capacity failover: %w", err)
+	}
+
+	weights, err := util.GetFailureDomainWeights(cpms.Annotations)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse failure domain weights: %w", err)
+	}
+
+	// The failure domains have not been sorted yet, so the weights are paired with the failure domains in the order
+	// in which they are configured, either within the template or within the Infrastructure resource.
+	failureDomainWeights, err := newFailureDomainWeights(failureDomains, weights)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing failure domain weights: %w", err)
+	}
+
 	machineAPIScheme := apimachineryruntime.NewScheme()
 	if err := machinev1.Install(machineAPIScheme); err != nil {
 		return nil, fmt.Errorf("unable to add machine.openshift.io/v1 scheme: %w", err)
@@ -127,9 +186,15 @@ func NewMachineProvider(ctx context.Context, logger logr.Logger, cl client.Clien
 		return nil, fmt.Errorf("unable to add machine.openshift.io/v1beta1 scheme: %w", err)
 	}
 
+	if etcdClient == nil {
+		etcdClient = cl
+	}
+
 	o := &openshiftMachineProvider{
 		client:           cl,
+		etcdClient:       etcdClient,
 		failureDomains:   failureDomains,
+		weights:          failureDomainWeights,
 		machineSelector:  selector,
 		machineTemplate:  *cpms.Spec.Template.OpenShiftMachineV1Beta1Machine,
 		ownerMetadata:    cpms.ObjectMeta,
@@ -137,6 +202,12 @@ func NewMachineProvider(ctx context.Context, logger logr.Logger, cl client.Clien
 		replicas:         replicas,
 		namespace:        cpms.Namespace,
 		machineAPIScheme: machineAPIScheme,
+		revisionHash:     revisionHash,
+		ignoreRules:      ignoreRules,
+		maxMachineAge:    maxMachineAge,
+		// The failover of an index relies on the failed Machine being kept until its replacement has been created,
+		// which is only the case for the RollingUpdate strategy.
+		capacityFailover: capacityFailover && cpms.Spec.Strategy.Type == machinev1.RollingUpdate,
 	}
 
 	if err := o.updateMachineCache(ctx, logger); err != nil {
@@ -146,11 +217,29 @@ func NewMachineProvider(ctx context.Context, logger logr.Logger, cl client.Clien
 	return o, nil
 }
 
+// getInfrastructureFailureDomains returns the failure domains defined on the cluster Infrastructure resource.
+// Configuration not defined on the Infrastructure is taken from the template failure domain.
+// If the Infrastructure does not exist, no failure domains are returned.
+func getInfrastructureFailureDomains(ctx context.Context, cl client.Client, template failuredomain.FailureDomain) ([]failuredomain.FailureDomain, error) {
+	infrastructure := &configv1.Infrastructure{}
+	if err := cl.Get(ctx, client.ObjectKey{Name: infrastructureName}, infrastructure); apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get infrastructure %s: %w", infrastructureName, err)
+	}
+
+	return failuredomain.NewFailureDomainsFromInfrastructure(infrastructure, template), nil
+}
+
 // openshiftMachineProvider holds the implementation of the MachineProvider interface.
 type openshiftMachineProvider struct {
 	// client is used to make API calls to fetch Machines and Nodes.
 	client client.Client
 
+	// etcdClient is used to fetch the etcd endpoints and etcd pods from the etcd namespace,
+	// which is outside of the namespace of the Machines.
+	etcdClient client.Reader
+
 	// indexToFailureDomain creates a mapping of failure domains to an arbitrary index.
 	// This index is then used in MachineInfo to allow external code to request that a
 	// new Machine be created in the same failure domain as an existing Machine.
@@ -158,9 +247,15 @@ type openshiftMachineProvider struct {
 	indexToFailureDomain map[int32]failuredomain.FailureDomain
 
 	// failureDomains is the list of failure domains collected from the CPMS spec when
-	// the machine provider was constructed.
+	// the machine provider was constructed. On Nutanix and vSphere, these are collected
+	// from the Infrastructure instead.
 	failureDomains []failuredomain.FailureDomain
 
+	// weights are the weights of the failure domains, used to determine the share of the indexes
+	// mapped to each failure domain. When no weights are configured, the failure domains are
+	// weighted equally.
+	weights failureDomainWeights
+
 	// machines is the list of machines collected from the API when the cache was built.
 	// This should be tied to the lifecycle of indexToFailureDomain since the domain mapping
 	// logic is affected by the contents of these machines.
@@ -168,6 +263,10 @@ type openshiftMachineProvider struct {
 	// must also be refreshed.
 	machines []machinev1beta1.Machine
 
+	// etcdEndpoints is the etcd endpoints ConfigMap collected from the API when the cache was built.
+	// When the etcd endpoints have not been published, this is nil.
+	etcdEndpoints *corev1.ConfigMap
+
 	// machineSelector is used to identify which Machines should be considered by
 	// the machine provider when constructing machine information.
 	machineSelector labels.Selector
@@ -189,6 +288,21 @@ type openshiftMachineProvider struct {
 
 	// machineAPIScheme contains scheme for Machine API v1 and v1beta1.
 	machineAPIScheme *apimachineryruntime.Scheme
+
+	// revisionHash is the hash of the ControlPlaneMachineSet template from which new Machines are created.
+	revisionHash string
+
+	// ignoreRules are the rules used to ignore differences in provider config fields when determining
+	// whether a Machine needs an update.
+	ignoreRules providerconfig.IgnoreRules
+
+	// maxMachineAge is the maximum duration a Machine may exist before it needs to be replaced.
+	// When zero, Machines are never replaced based on their age.
+	maxMachineAge time.Duration
+
+	// capacityFailover determines whether an index, whose failure domain did not have the capacity to create a
+	// Machine within the index, is mapped to another failure domain.
+	capacityFailover bool
 }
 
 // updateMachineCache fetches the current list of Machines and calculates from these the appropriate index
@@ -201,13 +315,25 @@ func (m *openshiftMachineProvider) updateMachineCache(ctx context.Context, logge
 	}
 
 	// Since the mapping depends on the state of the machines, we must re-map the failure domains if the machines have changed.
-	indexToFailureDomain, err := mapMachineIndexesToFailureDomains(logger, machineList.Items, m.replicas, m.failureDomains)
+	indexToFailureDomain, err := mapMachineIndexesToFailureDomains(logger, machineList.Items, m.replicas, m.failureDomains, m.weights)
 	if err != nil && !errors.Is(err, errNoFailureDomains) {
 		return fmt.Errorf("error mapping machine indexes: %w", err)
 	}
 
+	if m.capacityFailover && len(indexToFailureDomain) > 0 {
+		if err := failoverCapacityExhaustedIndexes(logger, machineList.Items, indexToFailureDomain, m.failureDomains); err != nil {
+			return fmt.Errorf("error failing over machine indexes: %w", err)
+		}
+	}
+
+	etcdEndpoints, err := m.getEtcdEndpoints(ctx)
+	if err != nil {
+		return err
+	}
+
 	m.machines = machineList.Items
 	m.indexToFailureDomain = indexToFailureDomain
+	m.etcdEndpoints = etcdEndpoints
 
 	return nil
 }
@@ -263,6 +389,7 @@ func (m *openshiftMachineProvider) GetMachineInfos(ctx context.Context, logger l
 			"ready", machineInfo.Ready,
 			"needsUpdate", machineInfo.NeedsUpdate,
 			"diff", machineInfo.Diff,
+			"ignoredDiff", machineInfo.IgnoredDiff,
 			"errorMessage", machineInfo.ErrorMessage,
 		)
 	}
@@ -307,26 +434,91 @@ func (m *openshiftMachineProvider) generateMachineInfo(ctx context.Context, logg
 		return machineproviders.MachineInfo{}, fmt.Errorf("cannot ensure that the provider config is valid: %w", err)
 	}
 
-	diff, err := validProviderConfig.Diff(providerConfig)
+	// Differences in ignored fields do not require the Machine to be updated, though they are still reported.
+	comparableProviderConfig := validProviderConfig.WithIgnoreRules(m.ignoreRules)
+
+	diff, err := comparableProviderConfig.Diff(providerConfig)
 	if err != nil {
 		return machineproviders.MachineInfo{}, fmt.Errorf("cannot compare provider configs: %w", err)
 	}
 
+	ignoredDiff, err := comparableProviderConfig.IgnoredDiff(providerConfig)
+	if err != nil {
+		return machineproviders.MachineInfo{}, fmt.Errorf("cannot compare ignored fields of provider configs: %w", err)
+	}
+
+	if util.IsMarkedForReplacement(&machine) {
+		// The Machine must be replaced, even when it is up to date.
+		diff = append(diff, replaceMachineDiff)
+	}
+
+	ageExceeded := util.MachineAgeExceeded(machine.CreationTimestamp.Time, m.maxMachineAge, time.Now())
+	if ageExceeded {
+		// The Machine must be replaced, even when it is up to date, to keep the age of the control plane bounded.
+		diff = append(diff, fmt.Sprintf(machineAgeExceededDiff, machine.CreationTimestamp.UTC().Format(time.RFC3339), m.maxMachineAge))
+	}
+
 	configsEqual := len(diff) == 0
 
-	ready, err := m.isMachineReady(ctx, machine)
+	node, err := m.getNode(ctx, machine)
 	if err != nil {
 		return machineproviders.MachineInfo{}, fmt.Errorf("error checking machine readiness: %w", err)
 	}
 
+	ready, readySince := isMachineReady(machine, node)
+	notReadySince := getNodeNotReadySince(node)
+
+	etcdMember, err := m.getEtcdMember(ctx, node)
+	if err != nil {
+		return machineproviders.MachineInfo{}, fmt.Errorf("error checking etcd membership: %w", err)
+	}
+
+	failureDomainFailover, err := m.getFailureDomainFailover(logger, machine, machineIndex)
+	if err != nil {
+		return machineproviders.MachineInfo{}, fmt.Errorf("error checking failure domain failover: %w", err)
+	}
+
 	return machineproviders.MachineInfo{
-		MachineRef:   machineRef,
-		NodeRef:      nodeRef,
-		Ready:        ready,
-		NeedsUpdate:  !configsEqual,
-		Diff:         diff,
-		Index:        machineIndex,
-		ErrorMessage: pointer.StringDeref(machine.Status.ErrorMessage, ""),
+		MachineRef:            machineRef,
+		NodeRef:               nodeRef,
+		Ready:                 ready,
+		ReadySince:            readySince,
+		NotReadySince:         notReadySince,
+		NeedsUpdate:           !configsEqual,
+		Diff:                  diff,
+		IgnoredDiff:           ignoredDiff,
+		AgeExceeded:           ageExceeded,
+		EtcdMember:            etcdMember,
+		Index:                 machineIndex,
+		ErrorMessage:          pointer.StringDeref(machine.Status.ErrorMessage, ""),
+		FailureDomainFailover: failureDomainFailover,
+	}, nil
+}
+
+// getFailureDomainFailover returns the failover of the index of the Machine, when the Machine failed because its
+// failure domain did not have the capacity to create it, and the index has since been mapped to another failure domain.
+func (m *openshiftMachineProvider) getFailureDomainFailover(logger logr.Logger, machine machinev1beta1.Machine, machineIndex int32) (*machineproviders.FailureDomainFailover, error) {
+	if !m.capacityFailover || !isCapacityExhaustedMachine(machine) {
+		return nil, nil //nolint:nilnil
+	}
+
+	mappedFailureDomain, ok := m.indexToFailureDomain[machineIndex]
+	if !ok {
+		return nil, nil //nolint:nilnil
+	}
+
+	failureDomain, err := providerconfig.ExtractFailureDomainFromMachine(logger, machine)
+	if err != nil {
+		return nil, fmt.Errorf("could not extract failure domain from machine %s: %w", machine.Name, err)
+	}
+
+	if failureDomain.Equal(mappedFailureDomain) {
+		return nil, nil //nolint:nilnil
+	}
+
+	return &machineproviders.FailureDomainFailover{
+		From: failureDomain.String(),
+		To:   mappedFailureDomain.String(),
 	}, nil
 }
 
@@ -407,36 +599,124 @@ func (m *openshiftMachineProvider) failureDomainToIndex(failureDomain failuredom
 	return 0, false
 }
 
-// isMachineReady determines whether a CPMS Machine is Ready or not.
-// A CPMS Machine is considered Ready when:
-// - the underlying Machine is Running and its Node is Ready
-// - the underlying Machine is Deleting and is still has a NodeRef.
-func (m *openshiftMachineProvider) isMachineReady(ctx context.Context, machine machinev1beta1.Machine) (bool, error) {
+// getNode fetches the Node backing the Machine.
+// When the Machine has no Node, nil is returned.
+func (m *openshiftMachineProvider) getNode(ctx context.Context, machine machinev1beta1.Machine) (*corev1.Node, error) {
 	if machine.Status.NodeRef == nil {
-		return false, nil
+		return nil, nil //nolint:nilnil
 	}
 
 	nodeName := machine.Status.NodeRef.Name
 
 	node := &corev1.Node{}
 	if err := m.client.Get(ctx, types.NamespacedName{Name: nodeName}, node); err != nil {
-		return false, fmt.Errorf("failed to get Node %q: %w", nodeName, err)
+		return nil, fmt.Errorf("failed to get Node %q: %w", nodeName, err)
+	}
+
+	return node, nil
+}
+
+// isMachineReady determines whether a CPMS Machine is Ready or not, given the Node backing the Machine.
+// A CPMS Machine is considered Ready when:
+// - the underlying Machine is Running and its Node is Ready
+// - the underlying Machine is Deleting and is still has a NodeRef.
+// When the Machine is Ready, the time at which its Node became Ready is also returned, if known.
+func isMachineReady(machine machinev1beta1.Machine, node *corev1.Node) (bool, *metav1.Time) {
+	if node == nil {
+		return false, nil
 	}
 
 	if pointer.StringDeref(machine.Status.Phase, "") == runningPhase && isNodeReady(node) {
 		// The machine is running and its node is ready, so everything is working as expected.
-		return true, nil
+		return true, getNodeReadySince(node)
 	}
 
 	if pointer.StringDeref(machine.Status.Phase, "") == deletingPhase && isNodeReady(node) {
 		// The machine was previously running but is now being deleted.
 		// The machine is still ready until the node is drained and removed from the cluster.
-		return true, nil
+		return true, getNodeReadySince(node)
 	}
 
 	return false, nil
 }
 
+// getNodeNotReadySince returns the time at which the Node backing the Machine stopped being Ready.
+// When the Machine has no Node, or the Node is Ready, nil is returned.
+func getNodeNotReadySince(node *corev1.Node) *metav1.Time {
+	if node == nil || isNodeReady(node) {
+		return nil
+	}
+
+	// The Ready condition of a Node which is not Ready last transitioned when the Node stopped being Ready.
+	return getNodeReadySince(node)
+}
+
+// getEtcdEndpoints fetches the ConfigMap in which the etcd operator publishes the etcd endpoints.
+// When the etcd endpoints have not been published, nil is returned.
+func (m *openshiftMachineProvider) getEtcdEndpoints(ctx context.Context) (*corev1.ConfigMap, error) {
+	endpoints := &corev1.ConfigMap{}
+	if err := m.etcdClient.Get(ctx, types.NamespacedName{Namespace: util.EtcdNamespace, Name: util.EtcdEndpointsConfigMapName}, endpoints); apierrors.IsNotFound(err) {
+		return nil, nil //nolint:nilnil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get etcd endpoints: %w", err)
+	}
+
+	return endpoints, nil
+}
+
+// getEtcdMember determines the membership and health of the etcd member running on the Node backing the Machine.
+// A member is voting when one of the internal addresses of the Node is published within the etcd endpoints, and
+// healthy when the etcd pod on the Node is ready.
+// When the etcd endpoints have not been published, the membership is not known and nil is returned.
+func (m *openshiftMachineProvider) getEtcdMember(ctx context.Context, node *corev1.Node) (*machineproviders.EtcdMemberInfo, error) {
+	if m.etcdEndpoints == nil {
+		return nil, nil //nolint:nilnil
+	}
+
+	if node == nil {
+		// Without a Node, there cannot be an etcd member.
+		return &machineproviders.EtcdMemberInfo{}, nil
+	}
+
+	pod := &corev1.Pod{}
+	if err := m.etcdClient.Get(ctx, types.NamespacedName{Namespace: util.EtcdNamespace, Name: util.EtcdPodNamePrefix + node.Name}, pod); err != nil && !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get etcd pod for Node %q: %w", node.Name, err)
+	}
+
+	return &machineproviders.EtcdMemberInfo{
+		Voting:  isEtcdEndpoint(m.etcdEndpoints, node),
+		Healthy: isPodReady(pod),
+	}, nil
+}
+
+// isEtcdEndpoint determines whether any of the internal addresses of the Node is published within the etcd endpoints.
+func isEtcdEndpoint(endpoints *corev1.ConfigMap, node *corev1.Node) bool {
+	for _, address := range node.Status.Addresses {
+		if address.Type != corev1.NodeInternalIP {
+			continue
+		}
+
+		for _, endpoint := range endpoints.Data {
+			if endpoint == address.Address {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// isPodReady determines whether the Pod reports that it is ready.
+func isPodReady(pod *corev1.Pod) bool {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+
+	return false
+}
+
 // getMachineNameIndex tries to fetch machine index from its name. If it's not possible,
 // it returns false as a second parameter.
 func getMachineNameIndex(machine machinev1beta1.Machine) (int32, bool) {
@@ -487,6 +767,19 @@ func isNodeReady(node *corev1.Node) bool {
 	return false
 }
 
+// getNodeReadySince returns the time at which the Ready condition of the node last transitioned.
+// When the node does not report a transition time for its Ready condition, nil is returned.
+func getNodeReadySince(node *corev1.Node) *metav1.Time {
+	for _, c := range node.Status.Conditions {
+		if c.Type == corev1.NodeReady && !c.LastTransitionTime.IsZero() {
+			readySince := c.LastTransitionTime
+			return &readySince
+		}
+	}
+
+	return nil
+}
+
 // CreateMachine creates a new Machine from the template provider config based on the
 // failure domain index provided.
 func (m *openshiftMachineProvider) CreateMachine(ctx context.Context, logger logr.Logger, index int32) error {
@@ -504,7 +797,7 @@ func (m *openshiftMachineProvider) CreateMachine(ctx context.Context, logger log
 			Name:        machineName,
 			Namespace:   m.namespace,
 			Annotations: m.machineTemplate.ObjectMeta.Annotations,
-			Labels:      m.machineTemplate.ObjectMeta.Labels,
+			Labels:      m.getMachineLabels(),
 		},
 		Spec: m.machineTemplate.Spec,
 	}
@@ -547,6 +840,23 @@ func (m *openshiftMachineProvider) CreateMachine(ctx context.Context, logger log
 	return nil
 }
 
+// getMachineLabels returns the labels for a new Machine.
+// These are the labels from the Machine template and, when known, the revision hash of the template.
+func (m *openshiftMachineProvider) getMachineLabels() map[string]string {
+	if m.revisionHash == "" {
+		return m.machineTemplate.ObjectMeta.Labels
+	}
+
+	machineLabels := make(map[string]string, len(m.machineTemplate.ObjectMeta.Labels)+1)
+	for k, v := range m.machineTemplate.ObjectMeta.Labels {
+		machineLabels[k] = v
+	}
+
+	machineLabels[util.RevisionHashLabel] = m.revisionHash
+
+	return machineLabels
+}
+
 // getMachineName generates a machine name based on the index.
 func (m *openshiftMachineProvider) getMachineName(index int32) (string, error) {
 	clusterID, ok := m.machineTemplate.ObjectMeta.Labels[machinev1beta1.MachineClusterIDLabel]
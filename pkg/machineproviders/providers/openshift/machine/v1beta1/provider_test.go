@@ -18,6 +18,7 @@ package v1beta1
 
 import (
 	"fmt"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -33,6 +34,7 @@ import (
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers/openshift/machine/v1beta1/failuredomain"
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers/openshift/machine/v1beta1/providerconfig"
 	machineprovidersresourcebuilder "github.com/openshift/cluster-control-plane-machine-set-operator/pkg/test/resourcebuilder/machineproviders"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/util"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -47,6 +49,7 @@ import (
 var _ = Describe("MachineProvider", func() {
 	const ownerUID = "uid-1234abcd"
 	const ownerName = "machineOwner"
+	const testRevisionHash = "5d4f8b9c7"
 
 	var namespaceName string
 	var logger testutils.TestLogger
@@ -210,7 +213,7 @@ var _ = Describe("MachineProvider", func() {
 
 			It("should build a provider from data in the cluster", func() {
 				var err error
-				provider, err = NewMachineProvider(ctx, logger.Logger(), k8sClient, cpms)
+				provider, err = NewMachineProvider(ctx, logger.Logger(), k8sClient, k8sClient, cpms)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(provider).ToNot(BeNil())
 
@@ -331,10 +334,19 @@ var _ = Describe("MachineProvider", func() {
 			return fmt.Sprintf("%s-master-%s", resourcebuilder.TestClusterIDValue, suffix)
 		}
 
+		withReplaceAnnotation := func(machine *machinev1beta1.Machine) *machinev1beta1.Machine {
+			machine.SetAnnotations(map[string]string{util.ReplaceMachineAnnotation: ""})
+			return machine
+		}
+
+		expiredCreationTimestamp := metav1.NewTime(time.Now().Add(-48 * time.Hour).Truncate(time.Second))
+		expiredMachineDiff := fmt.Sprintf(machineAgeExceededDiff, expiredCreationTimestamp.UTC().Format(time.RFC3339), 24*time.Hour)
+
 		type getMachineInfosTableInput struct {
 			machines             []*machinev1beta1.Machine
 			nodes                []*corev1.Node
 			failureDomains       map[int32]failuredomain.FailureDomain
+			maxMachineAge        time.Duration
 			expectedError        error
 			expectedMachineInfos []machineproviders.MachineInfo
 			expectedLogs         []testutils.LogEntry
@@ -383,6 +395,7 @@ var _ = Describe("MachineProvider", func() {
 				machineTemplate:      *template,
 				providerConfig:       providerConfig,
 				namespace:            namespaceName,
+				maxMachineAge:        in.maxMachineAge,
 			}
 
 			machineInfos, err := provider.GetMachineInfos(ctx, logger.Logger())
@@ -446,6 +459,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", false,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -459,6 +473,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", false,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -472,6 +487,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", false,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -513,6 +529,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -526,6 +543,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -539,6 +557,167 @@ var _ = Describe("MachineProvider", func() {
 							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+				},
+			}),
+			Entry("with a ready Machine whose Node reports when it became ready", getMachineInfosTableInput{
+				machines: []*machinev1beta1.Machine{
+					masterMachineBuilder.WithName(masterMachineName("0")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-0"}).Build(),
+				},
+				nodes: []*corev1.Node{
+					masterNodeBuilder.WithName("node-0").WithConditions([]corev1.NodeCondition{
+						{
+							Type:               corev1.NodeReady,
+							Status:             corev1.ConditionTrue,
+							LastTransitionTime: metav1.Date(2023, 1, 1, 0, 0, 0, 0, time.Local),
+						},
+					}).Build(),
+				},
+				failureDomains: map[int32]failuredomain.FailureDomain{
+					0: failuredomain.NewAWSFailureDomain(usEast1aFailureDomain),
+				},
+				expectedMachineInfos: []machineproviders.MachineInfo{
+					readyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).WithNodeName("node-0").
+						WithReadySince(metav1.Date(2023, 1, 1, 0, 0, 0, 0, time.Local)).Build(),
+				},
+				expectedLogs: []testutils.LogEntry{
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("0"),
+							"nodeName", "node-0",
+							"index", int32(0),
+							"ready", true,
+							"needsUpdate", false,
+							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+				},
+			}),
+			Entry("with a Machine whose Node reports when it stopped being ready", getMachineInfosTableInput{
+				machines: []*machinev1beta1.Machine{
+					masterMachineBuilder.WithName(masterMachineName("0")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-0"}).Build(),
+				},
+				nodes: []*corev1.Node{
+					masterNodeBuilder.WithName("node-0").WithConditions([]corev1.NodeCondition{
+						{
+							Type:               corev1.NodeReady,
+							Status:             corev1.ConditionUnknown,
+							LastTransitionTime: metav1.Date(2023, 1, 1, 0, 0, 0, 0, time.Local),
+						},
+					}).Build(),
+				},
+				failureDomains: map[int32]failuredomain.FailureDomain{
+					0: failuredomain.NewAWSFailureDomain(usEast1aFailureDomain),
+				},
+				expectedMachineInfos: []machineproviders.MachineInfo{
+					unreadyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).WithNodeName("node-0").
+						WithNotReadySince(metav1.Date(2023, 1, 1, 0, 0, 0, 0, time.Local)).Build(),
+				},
+				expectedLogs: []testutils.LogEntry{
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("0"),
+							"nodeName", "node-0",
+							"index", int32(0),
+							"ready", false,
+							"needsUpdate", false,
+							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+				},
+			}),
+			Entry("with a ready Machine that has been marked for replacement", getMachineInfosTableInput{
+				machines: []*machinev1beta1.Machine{
+					withReplaceAnnotation(masterMachineBuilder.WithName(masterMachineName("0")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-0"}).Build()),
+				},
+				nodes: []*corev1.Node{
+					masterNodeBuilder.WithName("node-0").Build(),
+				},
+				failureDomains: map[int32]failuredomain.FailureDomain{
+					0: failuredomain.NewAWSFailureDomain(usEast1aFailureDomain),
+				},
+				expectedMachineInfos: []machineproviders.MachineInfo{
+					readyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).WithNodeName("node-0").
+						WithMachineAnnotations(map[string]string{util.ReplaceMachineAnnotation: ""}).WithDiff([]string{replaceMachineDiff}).Build(),
+				},
+				expectedLogs: []testutils.LogEntry{
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("0"),
+							"nodeName", "node-0",
+							"index", int32(0),
+							"ready", true,
+							"needsUpdate", true,
+							"diff", []string{replaceMachineDiff},
+							"ignoredDiff", nilDiff,
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+				},
+			}),
+			Entry("with ready Machines that have and have not exceeded the maximum machine age", getMachineInfosTableInput{
+				machines: []*machinev1beta1.Machine{
+					masterMachineBuilder.WithName(masterMachineName("0")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
+						WithCreationTimestamp(expiredCreationTimestamp).WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-0"}).Build(),
+					masterMachineBuilder.WithName(masterMachineName("1")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnetbeta1)).
+						WithCreationTimestamp(metav1.Now()).WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-1"}).Build(),
+				},
+				nodes: []*corev1.Node{
+					masterNodeBuilder.WithName("node-0").Build(),
+					masterNodeBuilder.WithName("node-1").Build(),
+				},
+				failureDomains: map[int32]failuredomain.FailureDomain{
+					0: failuredomain.NewAWSFailureDomain(usEast1aFailureDomain),
+					1: failuredomain.NewAWSFailureDomain(usEast1bFailureDomain),
+				},
+				maxMachineAge: 24 * time.Hour,
+				expectedMachineInfos: []machineproviders.MachineInfo{
+					readyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).WithNodeName("node-0").
+						WithAgeExceeded(true).WithDiff([]string{expiredMachineDiff}).Build(),
+					readyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("1")).WithNodeName("node-1").Build(),
+				},
+				expectedLogs: []testutils.LogEntry{
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("0"),
+							"nodeName", "node-0",
+							"index", int32(0),
+							"ready", true,
+							"needsUpdate", true,
+							"diff", []string{expiredMachineDiff},
+							"ignoredDiff", nilDiff,
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("1"),
+							"nodeName", "node-1",
+							"index", int32(1),
+							"ready", true,
+							"needsUpdate", false,
+							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -579,6 +758,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -592,6 +772,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -605,6 +786,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -644,6 +826,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -657,6 +840,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -670,6 +854,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", false,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -710,6 +895,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -723,6 +909,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -736,6 +923,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -777,6 +965,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -790,6 +979,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", true,
 							"needsUpdate", true,
 							"diff", instanceDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -803,6 +993,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -852,6 +1043,7 @@ var _ = Describe("MachineProvider", func() {
 								"Subnet.Filters.slice[0].Values.slice[0]: subnet-us-east-1a != aws-subnet-12345678",
 								"Placement.AvailabilityZone: us-east-1a != us-east-1d",
 							},
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -865,6 +1057,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -878,6 +1071,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -923,6 +1117,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -936,6 +1131,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -952,6 +1148,7 @@ var _ = Describe("MachineProvider", func() {
 								"InstanceType: m6i.xlarge != different",
 								"Subnet.Filters.slice[0].Values.slice[0]: subnet-us-east-1c != aws-subnet-12345678",
 							},
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -965,6 +1162,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -1025,6 +1223,7 @@ var _ = Describe("MachineProvider", func() {
 								"Subnet.Filters.slice[0].Values.slice[0]: subnet-us-east-1b != subnet-us-east-1a",
 								"Placement.AvailabilityZone: us-east-1b != us-east-1a",
 							},
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -1041,6 +1240,7 @@ var _ = Describe("MachineProvider", func() {
 								"Subnet.Filters.slice[0].Values.slice[0]: subnet-us-east-1c != subnet-us-east-1b",
 								"Placement.AvailabilityZone: us-east-1c != us-east-1b",
 							},
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -1057,6 +1257,7 @@ var _ = Describe("MachineProvider", func() {
 								"Subnet.Filters.slice[0].Values.slice[0]: subnet-us-east-1a != subnet-us-east-1c",
 								"Placement.AvailabilityZone: us-east-1a != us-east-1c",
 							},
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -1097,6 +1298,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -1110,6 +1312,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -1123,6 +1326,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -1190,6 +1394,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", false,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "Node missing",
 						},
 						Message: "Gathered Machine Info",
@@ -1203,6 +1408,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", false,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "Cannot create VM",
 						},
 						Message: "Gathered Machine Info",
@@ -1216,6 +1422,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -1262,6 +1469,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -1275,6 +1483,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -1291,6 +1500,7 @@ var _ = Describe("MachineProvider", func() {
 								"Subnet.Filters.slice[0].Values.slice[0]: subnet-us-east-1c != subnet-us-east-1a",
 								"Placement.AvailabilityZone: us-east-1c != us-east-1a",
 							},
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -1327,6 +1537,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -1340,6 +1551,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -1353,6 +1565,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -1390,6 +1603,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -1403,6 +1617,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -1416,6 +1631,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", true,
 							"needsUpdate", true,
 							"diff", []string{"Placement.AvailabilityZone: us-east-1a != us-east-1b"},
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -1456,6 +1672,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -1469,6 +1686,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -1482,6 +1700,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -1522,6 +1741,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -1535,6 +1755,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -1548,6 +1769,7 @@ var _ = Describe("MachineProvider", func() {
 							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
+							"ignoredDiff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -1557,6 +1779,150 @@ var _ = Describe("MachineProvider", func() {
 		)
 	})
 
+	Context("getEtcdMember", func() {
+		const nodeName = "etcd-member-node"
+		const nodeAddress = "10.0.0.10"
+
+		var provider *openshiftMachineProvider
+		var node *corev1.Node
+
+		BeforeEach(func() {
+			provider = &openshiftMachineProvider{
+				client:     k8sClient,
+				etcdClient: k8sClient,
+				namespace:  namespaceName,
+			}
+
+			By("Creating the etcd namespace")
+			etcdNamespace := corev1resourcebuilder.Namespace().WithName(util.EtcdNamespace).Build()
+			Expect(client.IgnoreAlreadyExists(k8sClient.Create(ctx, etcdNamespace))).To(Succeed())
+
+			By("Creating the Node for the Machine")
+			node = corev1resourcebuilder.Node().AsMaster().WithName(nodeName).Build()
+			Expect(k8sClient.Create(ctx, node)).To(Succeed())
+
+			node.Status.Addresses = []corev1.NodeAddress{
+				{Type: corev1.NodeHostName, Address: nodeName},
+				{Type: corev1.NodeInternalIP, Address: nodeAddress},
+			}
+			Expect(k8sClient.Status().Update(ctx, node)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			testutils.CleanupResources(Default, ctx, cfg, k8sClient, util.EtcdNamespace,
+				&corev1.ConfigMap{},
+				&corev1.Pod{},
+			)
+		})
+
+		createEndpoints := func(addresses ...string) {
+			data := map[string]string{}
+			for i, address := range addresses {
+				data[fmt.Sprintf("member-%d", i)] = address
+			}
+
+			endpoints := corev1resourcebuilder.ConfigMap().WithNamespace(util.EtcdNamespace).WithName(util.EtcdEndpointsConfigMapName).WithData(data).Build()
+			Expect(k8sClient.Create(ctx, endpoints)).To(Succeed())
+		}
+
+		getEtcdMember := func(node *corev1.Node) (*machineproviders.EtcdMemberInfo, error) {
+			endpoints, err := provider.getEtcdEndpoints(ctx)
+			Expect(err).ToNot(HaveOccurred())
+
+			provider.etcdEndpoints = endpoints
+
+			return provider.getEtcdMember(ctx, node)
+		}
+
+		createEtcdPod := func(ready corev1.ConditionStatus) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: util.EtcdNamespace,
+					Name:      util.EtcdPodNamePrefix + nodeName,
+				},
+				Spec: corev1.PodSpec{
+					NodeName:   nodeName,
+					Containers: []corev1.Container{{Name: "etcd", Image: "etcd"}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+
+			pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: ready}}
+			Expect(k8sClient.Status().Update(ctx, pod)).To(Succeed())
+		}
+
+		Context("when the etcd endpoints do not exist", func() {
+			It("should not report etcd membership", func() {
+				member, err := getEtcdMember(node)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(member).To(BeNil())
+			})
+		})
+
+		Context("when the Machine does not have a Node", func() {
+			BeforeEach(func() {
+				createEndpoints(nodeAddress)
+			})
+
+			It("should report a non-voting, unhealthy member", func() {
+				member, err := getEtcdMember(nil)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(member).To(Equal(&machineproviders.EtcdMemberInfo{}))
+			})
+		})
+
+		Context("when the Node is not an etcd endpoint", func() {
+			BeforeEach(func() {
+				createEndpoints("10.0.0.11", "10.0.0.12")
+				createEtcdPod(corev1.ConditionTrue)
+			})
+
+			It("should report a healthy, non-voting member", func() {
+				member, err := getEtcdMember(node)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(member).To(Equal(&machineproviders.EtcdMemberInfo{Voting: false, Healthy: true}))
+			})
+		})
+
+		Context("when the Node is an etcd endpoint and the etcd pod is not ready", func() {
+			BeforeEach(func() {
+				createEndpoints("10.0.0.11", nodeAddress)
+				createEtcdPod(corev1.ConditionFalse)
+			})
+
+			It("should report an unhealthy, voting member", func() {
+				member, err := getEtcdMember(node)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(member).To(Equal(&machineproviders.EtcdMemberInfo{Voting: true, Healthy: false}))
+			})
+		})
+
+		Context("when the Node is an etcd endpoint and the etcd pod does not exist", func() {
+			BeforeEach(func() {
+				createEndpoints(nodeAddress)
+			})
+
+			It("should report an unhealthy, voting member", func() {
+				member, err := getEtcdMember(node)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(member).To(Equal(&machineproviders.EtcdMemberInfo{Voting: true, Healthy: false}))
+			})
+		})
+
+		Context("when the Node is an etcd endpoint and the etcd pod is ready", func() {
+			BeforeEach(func() {
+				createEndpoints("10.0.0.11", nodeAddress)
+				createEtcdPod(corev1.ConditionTrue)
+			})
+
+			It("should report a healthy, voting member", func() {
+				member, err := getEtcdMember(node)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(member).To(Equal(&machineproviders.EtcdMemberInfo{Voting: true, Healthy: true}))
+			})
+		})
+	})
+
 	Context("CreateMachine", func() {
 		var provider machineproviders.MachineProvider
 		var template machinev1.ControlPlaneMachineSetTemplate
@@ -1599,10 +1965,13 @@ var _ = Describe("MachineProvider", func() {
 						}
 					})
 
-					It("with the labels from the Machine template", func() {
-						Expect(machine.Labels).To(Equal(
-							template.OpenShiftMachineV1Beta1Machine.ObjectMeta.Labels,
-						))
+					It("with the labels from the Machine template and the revision hash", func() {
+						expectedLabels := map[string]string{util.RevisionHashLabel: testRevisionHash}
+						for k, v := range template.OpenShiftMachineV1Beta1Machine.ObjectMeta.Labels {
+							expectedLabels[k] = v
+						}
+
+						Expect(machine.Labels).To(Equal(expectedLabels))
 					})
 
 					It("with annotations from the Machine template", func() {
@@ -1681,6 +2050,7 @@ var _ = Describe("MachineProvider", func() {
 					providerConfig:   providerConfig,
 					namespace:        namespaceName,
 					machineAPIScheme: testScheme,
+					revisionHash:     testRevisionHash,
 				}
 			})
 
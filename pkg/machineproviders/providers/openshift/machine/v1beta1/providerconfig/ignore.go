@@ -0,0 +1,202 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providerconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/api/config/v1"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// IgnoredFieldsAnnotation is the annotation used on the ControlPlaneMachineSet to configure the fields of the
+	// provider config which are ignored when determining whether a Machine needs an update.
+	// The value is a JSON object mapping a platform type to a list of paths, for example `{"AWS": [".tags"]}`.
+	IgnoredFieldsAnnotation = "controlplanemachineset.machine.openshift.io/ignored-fields"
+)
+
+var (
+	// errInvalidIgnoreRules is used to inform users that the value of the ignored fields annotation is not valid.
+	errInvalidIgnoreRules = errors.New("ignored fields must be a JSON object mapping platform types to lists of paths")
+
+	// errEmptyIgnorePath is used to inform users that an ignored path does not contain any fields.
+	errEmptyIgnorePath = errors.New("ignored path must not be empty")
+)
+
+// IgnoreRules holds, per platform type, the paths of provider config fields which are ignored when comparing
+// provider configs. Each path is a dot separated list of JSON field names, for example `.metadata.labels`.
+// When a path traverses a list, the remainder of the path is applied to each item within the list.
+type IgnoreRules map[configv1.PlatformType][]string
+
+// NewIgnoreRulesFromAnnotations parses the ignore rules from the ignored fields annotation.
+// When the annotation is not present, no fields are ignored.
+func NewIgnoreRulesFromAnnotations(annotations map[string]string) (IgnoreRules, error) {
+	value, ok := annotations[IgnoredFieldsAnnotation]
+	if !ok {
+		return nil, nil
+	}
+
+	rules := IgnoreRules{}
+	if err := json.Unmarshal([]byte(value), &rules); err != nil {
+		return nil, fmt.Errorf("%w: %s: %q", errInvalidIgnoreRules, IgnoredFieldsAnnotation, value)
+	}
+
+	for platformType, paths := range rules {
+		for _, path := range paths {
+			if len(splitIgnorePath(path)) == 0 {
+				return nil, fmt.Errorf("%w: %s: %s: %q", errEmptyIgnorePath, IgnoredFieldsAnnotation, platformType, path)
+			}
+		}
+	}
+
+	return rules, nil
+}
+
+// WithIgnoreRules returns a copy of the ProviderConfig which ignores the fields configured by the rules
+// when comparing against other ProviderConfigs.
+func (p providerConfig) WithIgnoreRules(rules IgnoreRules) ProviderConfig {
+	newConfig := p
+	newConfig.ignoredPaths = rules[p.platformType]
+
+	return newConfig
+}
+
+// IgnoredDiff compares two ProviderConfigs and returns the list of differences which were
+// ignored based on the ignore rules, or nil if there are none.
+func (p providerConfig) IgnoredDiff(other ProviderConfig) ([]string, error) {
+	if len(p.ignoredPaths) == 0 || other == nil {
+		return nil, nil
+	}
+
+	fullDiff, err := p.withoutIgnoreRules().Diff(other)
+	if err != nil {
+		return nil, err
+	}
+
+	diff, err := p.Diff(other)
+	if err != nil {
+		return nil, err
+	}
+
+	reported := make(map[string]struct{}, len(diff))
+	for _, d := range diff {
+		reported[d] = struct{}{}
+	}
+
+	var ignoredDiff []string
+
+	for _, d := range fullDiff {
+		if _, ok := reported[d]; !ok {
+			ignoredDiff = append(ignoredDiff, d)
+		}
+	}
+
+	return ignoredDiff, nil
+}
+
+// withoutIgnoreRules returns a copy of the ProviderConfig which compares every field.
+func (p providerConfig) withoutIgnoreRules() providerConfig {
+	newConfig := p
+	newConfig.ignoredPaths = nil
+
+	return newConfig
+}
+
+// comparableConfigs returns the ProviderConfigs to compare, with the ignored fields removed from both.
+func (p providerConfig) comparableConfigs(other ProviderConfig) (ProviderConfig, ProviderConfig, error) {
+	base, err := removeIgnoredFields(p.withoutIgnoreRules(), p.ignoredPaths)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	compared, err := removeIgnoredFields(other, p.ignoredPaths)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return base, compared, nil
+}
+
+// removeIgnoredFields returns a copy of the ProviderConfig with the fields at the paths removed.
+func removeIgnoredFields(config ProviderConfig, paths []string) (ProviderConfig, error) {
+	rawConfig, err := config.RawConfig()
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch raw config from provider config: %w", err)
+	}
+
+	var object interface{}
+	if err := json.Unmarshal(rawConfig, &object); err != nil {
+		return nil, fmt.Errorf("could not unmarshal provider config: %w", err)
+	}
+
+	for _, path := range paths {
+		removePath(object, splitIgnorePath(path))
+	}
+
+	rawConfig, err = json.Marshal(object)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal provider config: %w", err)
+	}
+
+	// The provider config has already been validated, so there is no need to log about unknown fields again.
+	return newProviderConfigFromProviderSpec(logr.Discard(), machinev1beta1.ProviderSpec{Value: &runtime.RawExtension{Raw: rawConfig}}, config.Type())
+}
+
+// removePath removes the field at the path from the JSON object.
+// When a list is encountered, the remainder of the path is removed from each item within the list.
+func removePath(object interface{}, path []string) {
+	if len(path) == 0 {
+		return
+	}
+
+	switch value := object.(type) {
+	case map[string]interface{}:
+		if len(path) == 1 {
+			delete(value, path[0])
+			return
+		}
+
+		removePath(value[path[0]], path[1:])
+	case []interface{}:
+		for _, item := range value {
+			removePath(item, path)
+		}
+	}
+}
+
+// splitIgnorePath splits a path into its fields, ignoring any leading dot.
+func splitIgnorePath(path string) []string {
+	trimmed := strings.TrimPrefix(strings.TrimSpace(path), ".")
+	if trimmed == "" {
+		return nil
+	}
+
+	fields := strings.Split(trimmed, ".")
+	for _, field := range fields {
+		if field == "" {
+			return nil
+		}
+	}
+
+	return fields
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providerconfig
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	configv1 "github.com/openshift/api/config/v1"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	machinev1beta1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1beta1"
+	"k8s.io/utils/pointer"
+)
+
+var _ = Describe("Ignore rules", func() {
+	Context("NewIgnoreRulesFromAnnotations", func() {
+		type ignoreRulesTableInput struct {
+			annotations   map[string]string
+			expectedRules IgnoreRules
+			expectedError error
+		}
+
+		DescribeTable("should parse the ignored fields annotation", func(in ignoreRulesTableInput) {
+			rules, err := NewIgnoreRulesFromAnnotations(in.annotations)
+			if in.expectedError != nil {
+				Expect(err).To(MatchError(in.expectedError))
+				return
+			}
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(rules).To(Equal(in.expectedRules))
+		},
+			Entry("with no annotation", ignoreRulesTableInput{
+				annotations:   map[string]string{},
+				expectedRules: nil,
+			}),
+			Entry("with rules for multiple platforms", ignoreRulesTableInput{
+				annotations: map[string]string{
+					IgnoredFieldsAnnotation: `{"AWS": [".tags", ".blockDevices.ebs.volumeSize"], "Azure": [".tags"]}`,
+				},
+				expectedRules: IgnoreRules{
+					configv1.AWSPlatformType:   {".tags", ".blockDevices.ebs.volumeSize"},
+					configv1.AzurePlatformType: {".tags"},
+				},
+			}),
+			Entry("with an invalid value", ignoreRulesTableInput{
+				annotations: map[string]string{
+					IgnoredFieldsAnnotation: `[".tags"]`,
+				},
+				expectedError: errInvalidIgnoreRules,
+			}),
+			Entry("with an empty path", ignoreRulesTableInput{
+				annotations: map[string]string{
+					IgnoredFieldsAnnotation: `{"AWS": ["."]}`,
+				},
+				expectedError: errEmptyIgnorePath,
+			}),
+			Entry("with an empty field within a path", ignoreRulesTableInput{
+				annotations: map[string]string{
+					IgnoredFieldsAnnotation: `{"AWS": [".metadata..labels"]}`,
+				},
+				expectedError: errEmptyIgnorePath,
+			}),
+		)
+	})
+
+	Context("with ignore rules", func() {
+		var basePC ProviderConfig
+
+		awsProviderConfig := func(modify func(*machinev1beta1.AWSMachineProviderConfig)) ProviderConfig {
+			config := machinev1beta1resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a").WithInstanceType("m6i.xlarge").Build()
+			config.BlockDevices = []machinev1beta1.BlockDeviceMappingSpec{
+				{EBS: &machinev1beta1.EBSBlockDeviceSpec{VolumeSize: pointer.Int64(120)}},
+			}
+
+			if modify != nil {
+				modify(config)
+			}
+
+			return providerConfig{
+				platformType: configv1.AWSPlatformType,
+				aws: AWSProviderConfig{
+					providerConfig: *config,
+				},
+			}
+		}
+
+		withTags := func(config *machinev1beta1.AWSMachineProviderConfig) {
+			config.Tags = []machinev1beta1.TagSpecification{{Name: "owner", Value: "external-tool"}}
+		}
+
+		BeforeEach(func() {
+			basePC = awsProviderConfig(nil).WithIgnoreRules(IgnoreRules{
+				configv1.AWSPlatformType: {".tags", ".blockDevices.ebs.volumeSize"},
+			})
+		})
+
+		Context("when only ignored fields differ", func() {
+			var comparePC ProviderConfig
+
+			BeforeEach(func() {
+				comparePC = awsProviderConfig(func(config *machinev1beta1.AWSMachineProviderConfig) {
+					withTags(config)
+					config.BlockDevices[0].EBS.VolumeSize = pointer.Int64(240)
+				})
+			})
+
+			It("should be equal", func() {
+				Expect(basePC.Equal(comparePC)).To(BeTrue())
+			})
+
+			It("should not return a diff", func() {
+				Expect(basePC.Diff(comparePC)).To(BeEmpty())
+			})
+
+			It("should return the ignored diff", func() {
+				Expect(basePC.IgnoredDiff(comparePC)).To(ConsistOf(
+					ContainSubstring("Tags"),
+					ContainSubstring("VolumeSize"),
+				))
+			})
+
+			It("should not modify the compared provider config", func() {
+				Expect(comparePC.AWS().Config().Tags).To(HaveLen(1))
+			})
+		})
+
+		Context("when ignored and non-ignored fields differ", func() {
+			var comparePC ProviderConfig
+
+			BeforeEach(func() {
+				comparePC = awsProviderConfig(func(config *machinev1beta1.AWSMachineProviderConfig) {
+					withTags(config)
+					config.InstanceType = "m6i.2xlarge"
+				})
+			})
+
+			It("should not be equal", func() {
+				Expect(basePC.Equal(comparePC)).To(BeFalse())
+			})
+
+			It("should only return the non-ignored fields in the diff", func() {
+				Expect(basePC.Diff(comparePC)).To(ConsistOf(ContainSubstring("InstanceType")))
+			})
+
+			It("should only return the ignored fields in the ignored diff", func() {
+				Expect(basePC.IgnoredDiff(comparePC)).To(ConsistOf(ContainSubstring("Tags")))
+			})
+		})
+
+		Context("when the rules are for a different platform", func() {
+			var comparePC ProviderConfig
+
+			BeforeEach(func() {
+				basePC = awsProviderConfig(nil).WithIgnoreRules(IgnoreRules{
+					configv1.AzurePlatformType: {".tags"},
+				})
+
+				comparePC = awsProviderConfig(withTags)
+			})
+
+			It("should not be equal", func() {
+				Expect(basePC.Equal(comparePC)).To(BeFalse())
+			})
+
+			It("should return the diff", func() {
+				Expect(basePC.Diff(comparePC)).To(ConsistOf(ContainSubstring("Tags")))
+			})
+
+			It("should not return an ignored diff", func() {
+				Expect(basePC.IgnoredDiff(comparePC)).To(BeEmpty())
+			})
+		})
+	})
+})
@@ -22,6 +22,7 @@ import (
 	"github.com/go-logr/logr"
 	configv1 "github.com/openshift/api/config/v1"
 	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers/openshift/machine/v1beta1/failuredomain"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -30,6 +31,43 @@ type NutanixProviderConfig struct {
 	providerConfig machinev1.NutanixMachineProviderConfig
 }
 
+// InjectFailureDomain returns a new NutanixProviderConfig configured with the failure domain
+// information provided.
+// The subnets are only overwritten when they are set within the failure domain.
+func (n NutanixProviderConfig) InjectFailureDomain(fd failuredomain.NutanixFailureDomain) NutanixProviderConfig {
+	newNutanixProviderConfig := NutanixProviderConfig{
+		providerConfig: *n.providerConfig.DeepCopy(),
+	}
+
+	if fd.Cluster.Type != "" {
+		newNutanixProviderConfig.providerConfig.Cluster = *fd.Cluster.DeepCopy()
+	}
+
+	if len(fd.Subnets) > 0 {
+		newNutanixProviderConfig.providerConfig.Subnets = []machinev1.NutanixResourceIdentifier{}
+
+		for _, subnet := range fd.Subnets {
+			newNutanixProviderConfig.providerConfig.Subnets = append(newNutanixProviderConfig.providerConfig.Subnets, *subnet.DeepCopy())
+		}
+	}
+
+	return newNutanixProviderConfig
+}
+
+// ExtractFailureDomain returns a NutanixFailureDomain based on the failure domain
+// information stored within the NutanixProviderConfig.
+func (n NutanixProviderConfig) ExtractFailureDomain() failuredomain.NutanixFailureDomain {
+	fd := failuredomain.NutanixFailureDomain{
+		Cluster: *n.providerConfig.Cluster.DeepCopy(),
+	}
+
+	for _, subnet := range n.providerConfig.Subnets {
+		fd.Subnets = append(fd.Subnets, *subnet.DeepCopy())
+	}
+
+	return fd
+}
+
 // Config returns the stored NutanixMachineProviderConfig.
 func (n NutanixProviderConfig) Config() machinev1.NutanixMachineProviderConfig {
 	return n.providerConfig
@@ -0,0 +1,143 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providerconfig
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	configv1 "github.com/openshift/api/config/v1"
+	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-api-actuator-pkg/testutils"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers/openshift/machine/v1beta1/failuredomain"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+)
+
+var _ = Describe("Nutanix Provider Config", func() {
+	var logger testutils.TestLogger
+
+	var providerConfig NutanixProviderConfig
+
+	failureDomain1 := failuredomain.NutanixFailureDomain{
+		Cluster: machinev1.NutanixResourceIdentifier{Type: machinev1.NutanixIdentifierName, Name: pointer.String("pe1")},
+		Subnets: []machinev1.NutanixResourceIdentifier{{Type: machinev1.NutanixIdentifierName, Name: pointer.String("subnet1")}},
+	}
+
+	failureDomain2 := failuredomain.NutanixFailureDomain{
+		Cluster: machinev1.NutanixResourceIdentifier{Type: machinev1.NutanixIdentifierName, Name: pointer.String("pe2")},
+		Subnets: []machinev1.NutanixResourceIdentifier{{Type: machinev1.NutanixIdentifierUUID, UUID: pointer.String("0000-1111")}},
+	}
+
+	nutanixMachineProviderConfig := func() *machinev1.NutanixMachineProviderConfig {
+		return &machinev1.NutanixMachineProviderConfig{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "NutanixMachineProviderConfig",
+				APIVersion: "machine.openshift.io/v1",
+			},
+			Image:          machinev1.NutanixResourceIdentifier{Type: machinev1.NutanixIdentifierName, Name: pointer.String("rhcos")},
+			VCPUsPerSocket: 1,
+			VCPUSockets:    4,
+			Cluster:        failureDomain1.Cluster,
+			Subnets:        failureDomain1.Subnets,
+		}
+	}
+
+	BeforeEach(func() {
+		providerConfig = NutanixProviderConfig{
+			providerConfig: *nutanixMachineProviderConfig(),
+		}
+
+		logger = testutils.NewTestLogger()
+	})
+
+	Context("ExtractFailureDomain", func() {
+		It("returns the configured failure domain", func() {
+			Expect(providerConfig.ExtractFailureDomain()).To(Equal(failureDomain1))
+		})
+	})
+
+	Context("when the failuredomain is changed after initialisation", func() {
+		var changedProviderConfig NutanixProviderConfig
+
+		BeforeEach(func() {
+			changedProviderConfig = providerConfig.InjectFailureDomain(failureDomain2)
+		})
+
+		Context("ExtractFailureDomain", func() {
+			It("returns the changed failure domain from the changed config", func() {
+				Expect(changedProviderConfig.ExtractFailureDomain()).To(Equal(failureDomain2))
+			})
+
+			It("returns the original failure domain from the original config", func() {
+				Expect(providerConfig.ExtractFailureDomain()).To(Equal(failureDomain1))
+			})
+		})
+
+		It("does not change the rest of the config", func() {
+			Expect(changedProviderConfig.Config().Image).To(Equal(providerConfig.Config().Image))
+			Expect(changedProviderConfig.Config().VCPUSockets).To(Equal(providerConfig.Config().VCPUSockets))
+		})
+	})
+
+	Context("when injecting a failure domain without subnets", func() {
+		It("keeps the subnets of the config", func() {
+			changedProviderConfig := providerConfig.InjectFailureDomain(failuredomain.NutanixFailureDomain{
+				Cluster: failureDomain2.Cluster,
+			})
+
+			Expect(changedProviderConfig.ExtractFailureDomain()).To(Equal(failuredomain.NutanixFailureDomain{
+				Cluster: failureDomain2.Cluster,
+				Subnets: failureDomain1.Subnets,
+			}))
+		})
+	})
+
+	Context("newNutanixProviderConfig", func() {
+		var providerConfig ProviderConfig
+
+		BeforeEach(func() {
+			raw, err := json.Marshal(nutanixMachineProviderConfig())
+			Expect(err).ToNot(HaveOccurred())
+
+			providerConfig, err = newNutanixProviderConfig(logger.Logger(), &runtime.RawExtension{Raw: raw})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("sets the type to Nutanix", func() {
+			Expect(providerConfig.Type()).To(Equal(configv1.NutanixPlatformType))
+		})
+
+		It("returns the correct Nutanix config", func() {
+			expectedConfig := nutanixMachineProviderConfig()
+
+			Expect(providerConfig.Nutanix().Config()).To(SatisfyAll(
+				HaveField("Image", expectedConfig.Image),
+				HaveField("Cluster", expectedConfig.Cluster),
+				HaveField("Subnets", expectedConfig.Subnets),
+				HaveField("VCPUSockets", expectedConfig.VCPUSockets),
+			))
+		})
+
+		It("extracts the Nutanix failure domain", func() {
+			Expect(providerConfig.ExtractFailureDomain()).To(Equal(failuredomain.NewNutanixFailureDomain(failureDomain1)))
+		})
+	})
+})
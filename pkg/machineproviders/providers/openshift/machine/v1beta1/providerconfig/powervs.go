@@ -0,0 +1,57 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providerconfig
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/api/config/v1"
+	machinev1 "github.com/openshift/api/machine/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// PowerVSProviderConfig is a wrapper around machinev1.PowerVSMachineProviderConfig.
+// PowerVS does not support failure domains, so no failure domain information is
+// injected into, or extracted from, the provider config.
+type PowerVSProviderConfig struct {
+	providerConfig machinev1.PowerVSMachineProviderConfig
+}
+
+// Config returns the stored PowerVSMachineProviderConfig.
+func (p PowerVSProviderConfig) Config() machinev1.PowerVSMachineProviderConfig {
+	return p.providerConfig
+}
+
+func newPowerVSProviderConfig(logger logr.Logger, raw *runtime.RawExtension) (ProviderConfig, error) {
+	powerVSMachineProviderConfig := machinev1.PowerVSMachineProviderConfig{}
+
+	if err := checkForUnknownFieldsInProviderSpecAndUnmarshal(logger, raw, &powerVSMachineProviderConfig); err != nil {
+		return nil, fmt.Errorf("failed to check for unknown fields in the provider spec: %w", err)
+	}
+
+	ppc := PowerVSProviderConfig{
+		providerConfig: powerVSMachineProviderConfig,
+	}
+
+	config := providerConfig{
+		platformType: configv1.PowerVSPlatformType,
+		powervs:      ppc,
+	}
+
+	return config, nil
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providerconfig
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	configv1 "github.com/openshift/api/config/v1"
+	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-api-actuator-pkg/testutils"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers/openshift/machine/v1beta1/failuredomain"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/pointer"
+)
+
+var _ = Describe("PowerVS Provider Config", func() {
+	var logger testutils.TestLogger
+
+	powerVSMachineProviderConfig := func() *machinev1.PowerVSMachineProviderConfig {
+		return &machinev1.PowerVSMachineProviderConfig{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "PowerVSMachineProviderConfig",
+				APIVersion: "machine.openshift.io/v1",
+			},
+			UserDataSecret:    &machinev1.PowerVSSecretReference{Name: "master-user-data"},
+			CredentialsSecret: &machinev1.PowerVSSecretReference{Name: "powervs-credentials"},
+			ServiceInstance:   machinev1.PowerVSResource{Type: machinev1.PowerVSResourceTypeID, ID: pointer.String("0000-1111")},
+			Image:             machinev1.PowerVSResource{Type: machinev1.PowerVSResourceTypeName, Name: pointer.String("rhcos")},
+			Network:           machinev1.PowerVSResource{Type: machinev1.PowerVSResourceTypeRegEx, RegEx: pointer.String("^DHCPSERVER.*_Private$")},
+			KeyPairName:       "keypair",
+			SystemType:        "s922",
+			ProcessorType:     machinev1.PowerVSProcessorTypeShared,
+			Processors:        intstr.FromString("0.5"),
+			MemoryGiB:         32,
+			LoadBalancers: []machinev1.LoadBalancerReference{
+				{Name: "cluster-loadbalancer", Type: machinev1.ApplicationLoadBalancerType},
+				{Name: "cluster-loadbalancer-int", Type: machinev1.ApplicationLoadBalancerType},
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+	})
+
+	Context("newPowerVSProviderConfig", func() {
+		var providerConfig ProviderConfig
+
+		BeforeEach(func() {
+			raw, err := json.Marshal(powerVSMachineProviderConfig())
+			Expect(err).ToNot(HaveOccurred())
+
+			providerConfig, err = newPowerVSProviderConfig(logger.Logger(), &runtime.RawExtension{Raw: raw})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("sets the type to PowerVS", func() {
+			Expect(providerConfig.Type()).To(Equal(configv1.PowerVSPlatformType))
+		})
+
+		It("returns the correct PowerVS config", func() {
+			Expect(providerConfig.PowerVS().Config()).To(Equal(*powerVSMachineProviderConfig()))
+		})
+
+		It("extracts a generic failure domain", func() {
+			Expect(providerConfig.ExtractFailureDomain()).To(Equal(failuredomain.NewGenericFailureDomain()))
+		})
+
+		It("does not change the config when injecting a failure domain", func() {
+			changedProviderConfig, err := providerConfig.InjectFailureDomain(failuredomain.NewGenericFailureDomain())
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(changedProviderConfig.PowerVS().Config()).To(Equal(*powerVSMachineProviderConfig()))
+		})
+	})
+
+	Context("when comparing PowerVS configs", func() {
+		var basePC, comparePC ProviderConfig
+
+		BeforeEach(func() {
+			basePC = providerConfig{
+				platformType: configv1.PowerVSPlatformType,
+				powervs: PowerVSProviderConfig{
+					providerConfig: *powerVSMachineProviderConfig(),
+				},
+			}
+
+			changedConfig := powerVSMachineProviderConfig()
+			changedConfig.MemoryGiB = 64
+
+			comparePC = providerConfig{
+				platformType: configv1.PowerVSPlatformType,
+				powervs: PowerVSProviderConfig{
+					providerConfig: *changedConfig,
+				},
+			}
+		})
+
+		It("reports matching configs as equal", func() {
+			Expect(basePC.Equal(basePC)).To(BeTrue())
+		})
+
+		It("reports mis-matched configs as not equal", func() {
+			Expect(basePC.Equal(comparePC)).To(BeFalse())
+		})
+
+		It("does not report a diff for matching configs", func() {
+			Expect(basePC.Diff(basePC)).To(BeEmpty())
+		})
+
+		It("reports the changed field within the diff", func() {
+			Expect(basePC.Diff(comparePC)).To(ConsistOf("MemoryGiB: 32 != 64"))
+		})
+	})
+})
@@ -67,6 +67,14 @@ type ProviderConfig interface {
 	// or nil if there are none.
 	Diff(ProviderConfig) ([]string, error)
 
+	// WithIgnoreRules returns a copy of the ProviderConfig which ignores the fields configured by the rules
+	// when comparing against other ProviderConfigs.
+	WithIgnoreRules(IgnoreRules) ProviderConfig
+
+	// IgnoredDiff compares two ProviderConfigs and returns the list of differences which were
+	// ignored based on the ignore rules, or nil if there are none.
+	IgnoredDiff(ProviderConfig) ([]string, error)
+
 	// RawConfig marshalls the configuration into a JSON byte slice.
 	RawConfig() ([]byte, error)
 
@@ -88,6 +96,12 @@ type ProviderConfig interface {
 	// OpenStack returns the OpenStackProviderConfig if the platform type is OpenStack.
 	OpenStack() OpenStackProviderConfig
 
+	// PowerVS returns the PowerVSProviderConfig if the platform type is PowerVS.
+	PowerVS() PowerVSProviderConfig
+
+	// VSphere returns the VSphereProviderConfig if the platform type is VSphere.
+	VSphere() VSphereProviderConfig
+
 	// Generic returns the GenericProviderConfig if we are on a platform that is using generic provider abstraction.
 	Generic() GenericProviderConfig
 }
@@ -128,6 +142,10 @@ func newProviderConfigFromProviderSpec(logger logr.Logger, providerSpec machinev
 		return newNutanixProviderConfig(logger, providerSpec.Value)
 	case configv1.OpenStackPlatformType:
 		return newOpenStackProviderConfig(logger, providerSpec.Value)
+	case configv1.PowerVSPlatformType:
+		return newPowerVSProviderConfig(logger, providerSpec.Value)
+	case configv1.VSpherePlatformType:
+		return newVSphereProviderConfig(logger, providerSpec.Value)
 	case configv1.NonePlatformType:
 		return nil, fmt.Errorf("%w: %s", errUnsupportedPlatformType, platformType)
 	default:
@@ -144,6 +162,11 @@ type providerConfig struct {
 	nutanix      NutanixProviderConfig
 	generic      GenericProviderConfig
 	openstack    OpenStackProviderConfig
+	powervs      PowerVSProviderConfig
+	vsphere      VSphereProviderConfig
+
+	// ignoredPaths are the paths of the fields ignored when comparing against other ProviderConfigs.
+	ignoredPaths []string
 }
 
 // InjectFailureDomain is used to inject a failure domain into the ProviderConfig.
@@ -163,8 +186,12 @@ func (p providerConfig) InjectFailureDomain(fd failuredomain.FailureDomain) (Pro
 		newConfig.azure = p.Azure().InjectFailureDomain(fd.Azure())
 	case configv1.GCPPlatformType:
 		newConfig.gcp = p.GCP().InjectFailureDomain(fd.GCP())
+	case configv1.NutanixPlatformType:
+		newConfig.nutanix = p.Nutanix().InjectFailureDomain(fd.Nutanix())
 	case configv1.OpenStackPlatformType:
 		newConfig.openstack = p.OpenStack().InjectFailureDomain(fd.OpenStack())
+	case configv1.VSpherePlatformType:
+		newConfig.vsphere = p.VSphere().InjectFailureDomain(fd.VSphere())
 	case configv1.NonePlatformType:
 		return nil, fmt.Errorf("%w: %s", errUnsupportedPlatformType, p.platformType)
 	}
@@ -181,8 +208,15 @@ func (p providerConfig) ExtractFailureDomain() failuredomain.FailureDomain {
 		return failuredomain.NewAzureFailureDomain(p.Azure().ExtractFailureDomain())
 	case configv1.GCPPlatformType:
 		return failuredomain.NewGCPFailureDomain(p.GCP().ExtractFailureDomain())
+	case configv1.NutanixPlatformType:
+		return failuredomain.NewNutanixFailureDomain(p.Nutanix().ExtractFailureDomain())
 	case configv1.OpenStackPlatformType:
 		return failuredomain.NewOpenStackFailureDomain(p.OpenStack().ExtractFailureDomain())
+	case configv1.PowerVSPlatformType:
+		// PowerVS does not support failure domains.
+		return failuredomain.NewGenericFailureDomain()
+	case configv1.VSpherePlatformType:
+		return failuredomain.NewVSphereFailureDomain(p.VSphere().ExtractFailureDomain())
 	case configv1.NonePlatformType:
 		return nil
 	default:
@@ -191,7 +225,7 @@ func (p providerConfig) ExtractFailureDomain() failuredomain.FailureDomain {
 }
 
 // Diff compares two ProviderConfigs and returns a list of differences,
-// or nil if there are none.
+// or nil if there are none. Fields ignored by the ignore rules are not compared.
 //
 //nolint:dupl
 func (p providerConfig) Diff(other ProviderConfig) ([]string, error) {
@@ -203,6 +237,15 @@ func (p providerConfig) Diff(other ProviderConfig) ([]string, error) {
 		return nil, errMismatchedPlatformTypes
 	}
 
+	if len(p.ignoredPaths) > 0 {
+		base, compared, err := p.comparableConfigs(other)
+		if err != nil {
+			return nil, err
+		}
+
+		return base.Diff(compared)
+	}
+
 	switch p.platformType {
 	case configv1.AWSPlatformType:
 		return deep.Equal(p.aws.providerConfig, other.AWS().providerConfig), nil
@@ -214,6 +257,10 @@ func (p providerConfig) Diff(other ProviderConfig) ([]string, error) {
 		return deep.Equal(p.nutanix.providerConfig, other.Nutanix().providerConfig), nil
 	case configv1.OpenStackPlatformType:
 		return deep.Equal(p.openstack.providerConfig, other.OpenStack().providerConfig), nil
+	case configv1.PowerVSPlatformType:
+		return deep.Equal(p.powervs.providerConfig, other.PowerVS().providerConfig), nil
+	case configv1.VSpherePlatformType:
+		return deep.Equal(p.vsphere.providerConfig, other.VSphere().providerConfig), nil
 	case configv1.NonePlatformType:
 		return nil, errUnsupportedPlatformType
 	default:
@@ -222,6 +269,7 @@ func (p providerConfig) Diff(other ProviderConfig) ([]string, error) {
 }
 
 // Equal compares two ProviderConfigs to determine whether or not they are equal.
+// Fields ignored by the ignore rules are not compared.
 //
 //nolint:dupl
 func (p providerConfig) Equal(other ProviderConfig) (bool, error) {
@@ -233,6 +281,15 @@ func (p providerConfig) Equal(other ProviderConfig) (bool, error) {
 		return false, errMismatchedPlatformTypes
 	}
 
+	if len(p.ignoredPaths) > 0 {
+		base, compared, err := p.comparableConfigs(other)
+		if err != nil {
+			return false, err
+		}
+
+		return base.Equal(compared)
+	}
+
 	switch p.platformType {
 	case configv1.AWSPlatformType:
 		return reflect.DeepEqual(p.aws.providerConfig, other.AWS().providerConfig), nil
@@ -244,6 +301,10 @@ func (p providerConfig) Equal(other ProviderConfig) (bool, error) {
 		return reflect.DeepEqual(p.nutanix.providerConfig, other.Nutanix().providerConfig), nil
 	case configv1.OpenStackPlatformType:
 		return reflect.DeepEqual(p.openstack.providerConfig, other.OpenStack().providerConfig), nil
+	case configv1.PowerVSPlatformType:
+		return reflect.DeepEqual(p.powervs.providerConfig, other.PowerVS().providerConfig), nil
+	case configv1.VSpherePlatformType:
+		return reflect.DeepEqual(p.vsphere.providerConfig, other.VSphere().providerConfig), nil
 	case configv1.NonePlatformType:
 		return false, errUnsupportedPlatformType
 	default:
@@ -269,6 +330,10 @@ func (p providerConfig) RawConfig() ([]byte, error) {
 		rawConfig, err = json.Marshal(p.nutanix.providerConfig)
 	case configv1.OpenStackPlatformType:
 		rawConfig, err = json.Marshal(p.openstack.providerConfig)
+	case configv1.PowerVSPlatformType:
+		rawConfig, err = json.Marshal(p.powervs.providerConfig)
+	case configv1.VSpherePlatformType:
+		rawConfig, err = json.Marshal(p.vsphere.providerConfig)
 	case configv1.NonePlatformType:
 		return nil, errUnsupportedPlatformType
 	default:
@@ -312,6 +377,16 @@ func (p providerConfig) OpenStack() OpenStackProviderConfig {
 	return p.openstack
 }
 
+// PowerVS returns the PowerVSProviderConfig if the platform type is PowerVS.
+func (p providerConfig) PowerVS() PowerVSProviderConfig {
+	return p.powervs
+}
+
+// VSphere returns the VSphereProviderConfig if the platform type is VSphere.
+func (p providerConfig) VSphere() VSphereProviderConfig {
+	return p.vsphere
+}
+
 // Generic returns the GenericProviderConfig if the platform type is generic.
 func (p providerConfig) Generic() GenericProviderConfig {
 	return p.generic
@@ -326,6 +401,8 @@ func getPlatformTypeFromProviderSpecKind(kind string) configv1.PlatformType {
 		"GCPMachineProviderSpec":       configv1.GCPPlatformType,
 		"NutanixMachineProviderConfig": configv1.NutanixPlatformType,
 		"OpenstackProviderSpec":        configv1.OpenStackPlatformType,
+		"PowerVSMachineProviderConfig": configv1.PowerVSPlatformType,
+		"VSphereMachineProviderSpec":   configv1.VSpherePlatformType,
 	}
 
 	platformType, ok := providerSpecKindToPlatformType[kind]
@@ -130,6 +130,12 @@ var _ = Describe("Provider Config", func() {
 				providerSpecBuilder:   machinev1beta1resourcebuilder.OpenStackProviderSpec(),
 				providerConfigMatcher: HaveField("OpenStack().Config()", *machinev1beta1resourcebuilder.OpenStackProviderSpec().Build()),
 			}),
+			Entry("with a VSphere config without failure domains", providerConfigTableInput{
+				expectedPlatformType:  configv1.VSpherePlatformType,
+				failureDomainsBuilder: nil,
+				providerSpecBuilder:   machinev1beta1resourcebuilder.VSphereProviderSpec(),
+				providerConfigMatcher: HaveField("VSphere().Config()", *machinev1beta1resourcebuilder.VSphereProviderSpec().Build()),
+			}),
 		)
 	})
 
@@ -316,6 +322,36 @@ var _ = Describe("Provider Config", func() {
 				matchPath:        "OpenStack().Config().RootVolume.Zone",
 				matchExpectation: "cinder-az1",
 			}),
+			Entry("when changing a VSphere datacenter", injectFailureDomainTableInput{
+				providerConfig: &providerConfig{
+					platformType: configv1.VSpherePlatformType,
+					vsphere: VSphereProviderConfig{
+						providerConfig: *machinev1beta1resourcebuilder.VSphereProviderSpec().Build(),
+					},
+				},
+				failureDomain: failuredomain.NewVSphereFailureDomain(configv1.VSpherePlatformFailureDomainSpec{
+					Topology: configv1.VSpherePlatformTopology{
+						Datacenter: "dc2",
+					},
+				}),
+				matchPath:        "VSphere().Config().Workspace.Datacenter",
+				matchExpectation: "dc2",
+			}),
+			Entry("when changing a VSphere network", injectFailureDomainTableInput{
+				providerConfig: &providerConfig{
+					platformType: configv1.VSpherePlatformType,
+					vsphere: VSphereProviderConfig{
+						providerConfig: *machinev1beta1resourcebuilder.VSphereProviderSpec().Build(),
+					},
+				},
+				failureDomain: failuredomain.NewVSphereFailureDomain(configv1.VSpherePlatformFailureDomainSpec{
+					Topology: configv1.VSpherePlatformTopology{
+						Networks: []string{"test-segment-02"},
+					},
+				}),
+				matchPath:        "VSphere().Config().Network.Devices",
+				matchExpectation: []machinev1beta1.NetworkDeviceSpec{{NetworkName: "test-segment-02"}},
+			}),
 		)
 	})
 
@@ -378,6 +414,11 @@ var _ = Describe("Provider Config", func() {
 				providerSpecBuilder:   machinev1beta1resourcebuilder.OpenStackProviderSpec(),
 				providerConfigMatcher: HaveField("OpenStack().Config()", *machinev1beta1resourcebuilder.OpenStackProviderSpec().Build()),
 			}),
+			Entry("with a VSphere config", providerConfigTableInput{
+				expectedPlatformType:  configv1.VSpherePlatformType,
+				providerSpecBuilder:   machinev1beta1resourcebuilder.VSphereProviderSpec(),
+				providerConfigMatcher: HaveField("VSphere().Config()", *machinev1beta1resourcebuilder.VSphereProviderSpec().Build()),
+			}),
 		)
 	})
 
@@ -532,9 +573,22 @@ var _ = Describe("Provider Config", func() {
 					}).Build(),
 				),
 			}),
-			Entry("with a VSphere dummy failure domain", extractFailureDomainTableInput{
+			Entry("with a VSphere failure domain", extractFailureDomainTableInput{
 				providerConfig: &providerConfig{
 					platformType: configv1.VSpherePlatformType,
+					vsphere: VSphereProviderConfig{
+						providerConfig: *machinev1beta1resourcebuilder.VSphereProviderSpec().Build(),
+					},
+				},
+				expectedFailureDomain: failuredomain.NewVSphereFailureDomain(configv1.VSpherePlatformFailureDomainSpec{
+					Topology: configv1.VSpherePlatformTopology{
+						Networks: []string{"test-segment-01"},
+					},
+				}),
+			}),
+			Entry("with a BareMetal dummy failure domain", extractFailureDomainTableInput{
+				providerConfig: &providerConfig{
+					platformType: configv1.BareMetalPlatformType,
 					generic: GenericProviderConfig{
 						providerSpec: machinev1beta1resourcebuilder.VSphereProviderSpec().BuildRawExtension(),
 					},
@@ -704,15 +758,45 @@ var _ = Describe("Provider Config", func() {
 				},
 				expectedEqual: false,
 			}),
-			Entry("with matching Generic configs", equalTableInput{
+			Entry("with matching VSphere configs", equalTableInput{
 				basePC: &providerConfig{
 					platformType: configv1.VSpherePlatformType,
+					vsphere: VSphereProviderConfig{
+						providerConfig: *machinev1beta1resourcebuilder.VSphereProviderSpec().Build(),
+					},
+				},
+				comparePC: &providerConfig{
+					platformType: configv1.VSpherePlatformType,
+					vsphere: VSphereProviderConfig{
+						providerConfig: *machinev1beta1resourcebuilder.VSphereProviderSpec().Build(),
+					},
+				},
+				expectedEqual: true,
+			}),
+			Entry("with mis-matched VSphere configs", equalTableInput{
+				basePC: &providerConfig{
+					platformType: configv1.VSpherePlatformType,
+					vsphere: VSphereProviderConfig{
+						providerConfig: *machinev1beta1resourcebuilder.VSphereProviderSpec().Build(),
+					},
+				},
+				comparePC: &providerConfig{
+					platformType: configv1.VSpherePlatformType,
+					vsphere: VSphereProviderConfig{
+						providerConfig: *machinev1beta1resourcebuilder.VSphereProviderSpec().WithTemplate("different-template").Build(),
+					},
+				},
+				expectedEqual: false,
+			}),
+			Entry("with matching Generic configs", equalTableInput{
+				basePC: &providerConfig{
+					platformType: configv1.BareMetalPlatformType,
 					generic: GenericProviderConfig{
 						providerSpec: machinev1beta1resourcebuilder.VSphereProviderSpec().BuildRawExtension(),
 					},
 				},
 				comparePC: &providerConfig{
-					platformType: configv1.VSpherePlatformType,
+					platformType: configv1.BareMetalPlatformType,
 					generic: GenericProviderConfig{
 						providerSpec: machinev1beta1resourcebuilder.VSphereProviderSpec().BuildRawExtension(),
 					},
@@ -721,13 +805,13 @@ var _ = Describe("Provider Config", func() {
 			}),
 			Entry("with mis-matched spec using Generic configs", equalTableInput{
 				basePC: &providerConfig{
-					platformType: configv1.VSpherePlatformType,
+					platformType: configv1.BareMetalPlatformType,
 					generic: GenericProviderConfig{
 						providerSpec: machinev1beta1resourcebuilder.VSphereProviderSpec().BuildRawExtension(),
 					},
 				},
 				comparePC: &providerConfig{
-					platformType: configv1.VSpherePlatformType,
+					platformType: configv1.BareMetalPlatformType,
 					generic: GenericProviderConfig{
 						providerSpec: machinev1beta1resourcebuilder.VSphereProviderSpec().WithTemplate("different-template").BuildRawExtension(),
 					},
@@ -810,6 +894,15 @@ var _ = Describe("Provider Config", func() {
 			Entry("with a VSphere config", rawConfigTableInput{
 				providerConfig: providerConfig{
 					platformType: configv1.VSpherePlatformType,
+					vsphere: VSphereProviderConfig{
+						providerConfig: *machinev1beta1resourcebuilder.VSphereProviderSpec().Build(),
+					},
+				},
+				expectedOut: machinev1beta1resourcebuilder.VSphereProviderSpec().BuildRawExtension().Raw,
+			}),
+			Entry("with a Generic config", rawConfigTableInput{
+				providerConfig: providerConfig{
+					platformType: configv1.BareMetalPlatformType,
 					generic: GenericProviderConfig{
 						providerSpec: machinev1beta1resourcebuilder.VSphereProviderSpec().BuildRawExtension(),
 					},
@@ -0,0 +1,135 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providerconfig
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/api/config/v1"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// VSphereProviderConfig holds the provider spec of a VSphere Machine.
+// It allows external code to extract and inject failure domain information,
+// as well as gathering the stored config.
+type VSphereProviderConfig struct {
+	providerConfig machinev1beta1.VSphereMachineProviderSpec
+}
+
+// InjectFailureDomain returns a new VSphereProviderConfig configured with the failure domain
+// information provided.
+// The workspace and network device fields are only overwritten when they are set within
+// the failure domain.
+func (v VSphereProviderConfig) InjectFailureDomain(fd configv1.VSpherePlatformFailureDomainSpec) VSphereProviderConfig {
+	newVSphereProviderConfig := VSphereProviderConfig{
+		providerConfig: *v.providerConfig.DeepCopy(),
+	}
+
+	workspace := newVSphereProviderConfig.providerConfig.Workspace
+	if workspace == nil {
+		workspace = &machinev1beta1.Workspace{}
+	}
+
+	if fd.Server != "" {
+		workspace.Server = fd.Server
+	}
+
+	if fd.Topology.Datacenter != "" {
+		workspace.Datacenter = fd.Topology.Datacenter
+	}
+
+	if fd.Topology.Datastore != "" {
+		workspace.Datastore = fd.Topology.Datastore
+	}
+
+	if fd.Topology.ResourcePool != "" {
+		workspace.ResourcePool = fd.Topology.ResourcePool
+	}
+
+	if fd.Topology.Folder != "" {
+		workspace.Folder = fd.Topology.Folder
+	}
+
+	if *workspace != (machinev1beta1.Workspace{}) {
+		newVSphereProviderConfig.providerConfig.Workspace = workspace
+	}
+
+	for i, network := range fd.Topology.Networks {
+		if i < len(newVSphereProviderConfig.providerConfig.Network.Devices) {
+			newVSphereProviderConfig.providerConfig.Network.Devices[i].NetworkName = network
+			continue
+		}
+
+		newVSphereProviderConfig.providerConfig.Network.Devices = append(newVSphereProviderConfig.providerConfig.Network.Devices, machinev1beta1.NetworkDeviceSpec{
+			NetworkName: network,
+		})
+	}
+
+	return newVSphereProviderConfig
+}
+
+// ExtractFailureDomain returns a VSpherePlatformFailureDomainSpec based on the failure domain
+// information stored within the VSphereProviderConfig.
+// Only the server, topology workspace fields and networks are stored on the Machine,
+// so the name, region, zone and compute cluster of the failure domain are left empty.
+func (v VSphereProviderConfig) ExtractFailureDomain() configv1.VSpherePlatformFailureDomainSpec {
+	fd := configv1.VSpherePlatformFailureDomainSpec{}
+
+	if workspace := v.providerConfig.Workspace; workspace != nil {
+		fd.Server = workspace.Server
+		fd.Topology.Datacenter = workspace.Datacenter
+		fd.Topology.Datastore = workspace.Datastore
+		fd.Topology.ResourcePool = workspace.ResourcePool
+		fd.Topology.Folder = workspace.Folder
+	}
+
+	for _, device := range v.providerConfig.Network.Devices {
+		if device.NetworkName != "" {
+			fd.Topology.Networks = append(fd.Topology.Networks, device.NetworkName)
+		}
+	}
+
+	return fd
+}
+
+// Config returns the stored VSphereMachineProviderSpec.
+func (v VSphereProviderConfig) Config() machinev1beta1.VSphereMachineProviderSpec {
+	return v.providerConfig
+}
+
+// newVSphereProviderConfig creates a VSphere type ProviderConfig from the raw extension.
+// It should return an error if the provided RawExtension does not represent
+// a VSphereMachineProviderSpec.
+func newVSphereProviderConfig(logger logr.Logger, raw *runtime.RawExtension) (ProviderConfig, error) {
+	vsphereProviderSpec := machinev1beta1.VSphereMachineProviderSpec{}
+	if err := checkForUnknownFieldsInProviderSpecAndUnmarshal(logger, raw, &vsphereProviderSpec); err != nil {
+		return nil, fmt.Errorf("failed to check for unknown fields in the provider spec: %w", err)
+	}
+
+	vsphereProviderConfig := VSphereProviderConfig{
+		providerConfig: vsphereProviderSpec,
+	}
+
+	config := providerConfig{
+		platformType: configv1.VSpherePlatformType,
+		vsphere:      vsphereProviderConfig,
+	}
+
+	return config, nil
+}
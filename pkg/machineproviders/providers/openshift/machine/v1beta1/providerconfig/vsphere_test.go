@@ -0,0 +1,200 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providerconfig
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	configv1 "github.com/openshift/api/config/v1"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/cluster-api-actuator-pkg/testutils"
+	machinev1beta1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1beta1"
+)
+
+var _ = Describe("VSphere Provider Config", func() {
+	var logger testutils.TestLogger
+
+	var providerConfig VSphereProviderConfig
+
+	failureDomain1 := configv1.VSpherePlatformFailureDomainSpec{
+		Name:   "us-east-1",
+		Region: "us-east",
+		Zone:   "us-east-1a",
+		Server: "vcenter.example.com",
+		Topology: configv1.VSpherePlatformTopology{
+			Datacenter:     "dc1",
+			ComputeCluster: "/dc1/host/cluster1",
+			Networks:       []string{"network1"},
+			Datastore:      "/dc1/datastore/datastore1",
+			ResourcePool:   "/dc1/host/cluster1/Resources",
+			Folder:         "/dc1/vm/folder1",
+		},
+	}
+
+	failureDomain2 := configv1.VSpherePlatformFailureDomainSpec{
+		Name:   "us-east-2",
+		Region: "us-east",
+		Zone:   "us-east-2a",
+		Server: "vcenter.example.com",
+		Topology: configv1.VSpherePlatformTopology{
+			Datacenter:     "dc2",
+			ComputeCluster: "/dc2/host/cluster2",
+			Networks:       []string{"network2"},
+			Datastore:      "/dc2/datastore/datastore2",
+			ResourcePool:   "/dc2/host/cluster2/Resources",
+			Folder:         "/dc2/vm/folder2",
+		},
+	}
+
+	// extractedFailureDomain returns the failure domain as it is expected to be
+	// extracted from a provider spec, without the fields that are not stored on the Machine.
+	extractedFailureDomain := func(fd configv1.VSpherePlatformFailureDomainSpec) configv1.VSpherePlatformFailureDomainSpec {
+		return configv1.VSpherePlatformFailureDomainSpec{
+			Server: fd.Server,
+			Topology: configv1.VSpherePlatformTopology{
+				Datacenter:   fd.Topology.Datacenter,
+				Networks:     fd.Topology.Networks,
+				Datastore:    fd.Topology.Datastore,
+				ResourcePool: fd.Topology.ResourcePool,
+				Folder:       fd.Topology.Folder,
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		machineProviderConfig := machinev1beta1resourcebuilder.VSphereProviderSpec().Build()
+		machineProviderConfig.Workspace = &machinev1beta1.Workspace{
+			Server:       failureDomain1.Server,
+			Datacenter:   failureDomain1.Topology.Datacenter,
+			Datastore:    failureDomain1.Topology.Datastore,
+			ResourcePool: failureDomain1.Topology.ResourcePool,
+			Folder:       failureDomain1.Topology.Folder,
+		}
+		machineProviderConfig.Network.Devices[0].NetworkName = failureDomain1.Topology.Networks[0]
+
+		providerConfig = VSphereProviderConfig{
+			providerConfig: *machineProviderConfig,
+		}
+
+		logger = testutils.NewTestLogger()
+	})
+
+	Context("ExtractFailureDomain", func() {
+		It("returns the configured failure domain", func() {
+			Expect(providerConfig.ExtractFailureDomain()).To(Equal(extractedFailureDomain(failureDomain1)))
+		})
+
+		It("returns an empty failure domain when no workspace or network is configured", func() {
+			emptyProviderConfig := VSphereProviderConfig{
+				providerConfig: machinev1beta1.VSphereMachineProviderSpec{},
+			}
+
+			Expect(emptyProviderConfig.ExtractFailureDomain()).To(Equal(configv1.VSpherePlatformFailureDomainSpec{}))
+		})
+	})
+
+	Context("when the failuredomain is changed after initialisation", func() {
+		var changedProviderConfig VSphereProviderConfig
+
+		BeforeEach(func() {
+			changedProviderConfig = providerConfig.InjectFailureDomain(failureDomain2)
+		})
+
+		Context("ExtractFailureDomain", func() {
+			It("returns the changed failure domain from the changed config", func() {
+				Expect(changedProviderConfig.ExtractFailureDomain()).To(Equal(extractedFailureDomain(failureDomain2)))
+			})
+
+			It("returns the original failure domain from the original config", func() {
+				Expect(providerConfig.ExtractFailureDomain()).To(Equal(extractedFailureDomain(failureDomain1)))
+			})
+		})
+
+		It("does not change the rest of the config", func() {
+			Expect(changedProviderConfig.Config().Template).To(Equal(providerConfig.Config().Template))
+			Expect(changedProviderConfig.Config().CredentialsSecret).To(Equal(providerConfig.Config().CredentialsSecret))
+		})
+	})
+
+	Context("when injecting a failure domain into a config without a workspace", func() {
+		var changedProviderConfig VSphereProviderConfig
+
+		BeforeEach(func() {
+			changedProviderConfig = VSphereProviderConfig{
+				providerConfig: *machinev1beta1resourcebuilder.VSphereProviderSpec().Build(),
+			}.InjectFailureDomain(failureDomain2)
+		})
+
+		It("creates the workspace from the failure domain", func() {
+			Expect(changedProviderConfig.Config().Workspace).To(Equal(&machinev1beta1.Workspace{
+				Server:       failureDomain2.Server,
+				Datacenter:   failureDomain2.Topology.Datacenter,
+				Datastore:    failureDomain2.Topology.Datastore,
+				ResourcePool: failureDomain2.Topology.ResourcePool,
+				Folder:       failureDomain2.Topology.Folder,
+			}))
+		})
+	})
+
+	Context("when injecting a failure domain with more networks than network devices", func() {
+		var changedProviderConfig VSphereProviderConfig
+
+		BeforeEach(func() {
+			fd := failureDomain2
+			fd.Topology.Networks = []string{"network2", "network3"}
+
+			changedProviderConfig = providerConfig.InjectFailureDomain(fd)
+		})
+
+		It("adds a network device for each additional network", func() {
+			Expect(changedProviderConfig.Config().Network.Devices).To(Equal([]machinev1beta1.NetworkDeviceSpec{
+				{NetworkName: "network2"},
+				{NetworkName: "network3"},
+			}))
+		})
+	})
+
+	Context("when injecting an empty failure domain", func() {
+		It("does not change the config", func() {
+			Expect(providerConfig.InjectFailureDomain(configv1.VSpherePlatformFailureDomainSpec{})).To(Equal(providerConfig))
+		})
+	})
+
+	Context("newVSphereProviderConfig", func() {
+		var providerConfig ProviderConfig
+		var expectedVSphereConfig machinev1beta1.VSphereMachineProviderSpec
+
+		BeforeEach(func() {
+			configBuilder := machinev1beta1resourcebuilder.VSphereProviderSpec()
+			expectedVSphereConfig = *configBuilder.Build()
+			rawConfig := configBuilder.BuildRawExtension()
+
+			var err error
+			providerConfig, err = newVSphereProviderConfig(logger.Logger(), rawConfig)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("sets the type to VSphere", func() {
+			Expect(providerConfig.Type()).To(Equal(configv1.VSpherePlatformType))
+		})
+
+		It("returns the correct VSphere config", func() {
+			Expect(providerConfig.VSphere().Config()).To(Equal(expectedVSphereConfig))
+		})
+	})
+})
@@ -0,0 +1,118 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers/openshift/machine/v1beta1/failuredomain"
+)
+
+// errMismatchedFailureDomainWeights is used to inform users that the failure domain weights annotation does not
+// configure a weight for each of the failure domains.
+var errMismatchedFailureDomainWeights = errors.New("the number of failure domain weights does not match the number of failure domains")
+
+// failureDomainWeights holds the weight of each failure domain, as configured by the failure domain weights annotation.
+// The weight of a failure domain determines its share of the indexes within the failure domain mapping.
+// The weights are matched to the failure domains by position when constructed, and by failure domain thereafter, so
+// that sorting the failure domains does not change their weights.
+// When no weights are configured, the failure domains are weighted equally.
+type failureDomainWeights struct {
+	failureDomains []failuredomain.FailureDomain
+	weights        []int
+}
+
+// newFailureDomainWeights pairs each weight with the failure domain in the same position.
+// The failure domains must be given in the order in which they are configured, that is the order of the failure
+// domains within the ControlPlaneMachineSet template or, when these are read from the Infrastructure resource as on
+// vSphere and Nutanix, the order of the failure domains within the Infrastructure resource. This is the order
+// documented for the failure domain weights annotation, rather than the alphabetical order used by the mapping.
+// When weights are configured, there must be a weight for each failure domain.
+func newFailureDomainWeights(failureDomains []failuredomain.FailureDomain, weights []int) (failureDomainWeights, error) {
+	if len(weights) == 0 {
+		return failureDomainWeights{}, nil
+	}
+
+	if len(weights) != len(failureDomains) {
+		names := []string{}
+		for _, failureDomain := range failureDomains {
+			names = append(names, failureDomain.String())
+		}
+
+		return failureDomainWeights{}, fmt.Errorf("%w: %d weights for %d failure domains, in the order %s", errMismatchedFailureDomainWeights, len(weights), len(failureDomains), strings.Join(names, ", "))
+	}
+
+	// Copy the failure domains as the order of the given slice may be changed later on,
+	// for example when creating the base mapping.
+	return failureDomainWeights{
+		failureDomains: append([]failuredomain.FailureDomain{}, failureDomains...),
+		weights:        weights,
+	}, nil
+}
+
+// isWeighted determines whether any weights have been configured.
+func (w failureDomainWeights) isWeighted() bool {
+	return len(w.weights) > 0
+}
+
+// weight returns the weight of the failure domain.
+// Failure domains without a configured weight have a weight of 1.
+func (w failureDomainWeights) weight(failureDomain failuredomain.FailureDomain) int {
+	for i, fd := range w.failureDomains {
+		if fd.Equal(failureDomain) {
+			return w.weights[i]
+		}
+	}
+
+	return 1
+}
+
+// shares calculates the number of indexes, out of the count, that each failure domain should be mapped to based on
+// its weight. Each failure domain is given the whole part of its share first, the remaining indexes are then given
+// to the failure domains with the largest remainders. Failure domains with equal remainders are considered in the
+// order given.
+func (w failureDomainWeights) shares(failureDomains []failuredomain.FailureDomain, count int) []int {
+	totalWeight := 0
+	for _, failureDomain := range failureDomains {
+		totalWeight += w.weight(failureDomain)
+	}
+
+	shares := make([]int, len(failureDomains))
+	remainders := make([]int, len(failureDomains))
+	order := make([]int, len(failureDomains))
+	unassigned := count
+
+	for i, failureDomain := range failureDomains {
+		shares[i] = count * w.weight(failureDomain) / totalWeight
+		remainders[i] = count * w.weight(failureDomain) % totalWeight
+		order[i] = i
+		unassigned -= shares[i]
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return remainders[order[i]] > remainders[order[j]]
+	})
+
+	for _, i := range order[:unassigned] {
+		shares[i]++
+	}
+
+	return shares
+}
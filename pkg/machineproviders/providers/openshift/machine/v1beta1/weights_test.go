@@ -0,0 +1,117 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
+
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers/openshift/machine/v1beta1/failuredomain"
+)
+
+var _ = Describe("Failure Domain Weights", func() {
+	failureDomainFor := func(zone string) failuredomain.FailureDomain {
+		return failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone(zone).Build())
+	}
+
+	usEast1a := failureDomainFor("us-east-1a")
+	usEast1b := failureDomainFor("us-east-1b")
+	usEast1c := failureDomainFor("us-east-1c")
+
+	Context("newFailureDomainWeights", func() {
+		It("weights the failure domains equally without weights", func() {
+			weights, err := newFailureDomainWeights([]failuredomain.FailureDomain{usEast1a, usEast1b}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(weights.isWeighted()).To(BeFalse())
+			Expect(weights.weight(usEast1a)).To(Equal(1))
+			Expect(weights.weight(usEast1b)).To(Equal(1))
+		})
+
+		It("pairs each weight with the failure domain in the same position", func() {
+			weights, err := newFailureDomainWeights([]failuredomain.FailureDomain{usEast1b, usEast1a}, []int{3, 1})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(weights.isWeighted()).To(BeTrue())
+			Expect(weights.weight(usEast1a)).To(Equal(1))
+			Expect(weights.weight(usEast1b)).To(Equal(3))
+			Expect(weights.weight(usEast1c)).To(Equal(1))
+		})
+
+		It("is not affected by changes to the order of the failure domains", func() {
+			failureDomains := []failuredomain.FailureDomain{usEast1b, usEast1a}
+
+			weights, err := newFailureDomainWeights(failureDomains, []int{3, 1})
+			Expect(err).ToNot(HaveOccurred())
+
+			failureDomains[0], failureDomains[1] = failureDomains[1], failureDomains[0]
+
+			Expect(weights.weight(usEast1b)).To(Equal(3))
+		})
+
+		It("returns an error when there is not a weight for each failure domain", func() {
+			_, err := newFailureDomainWeights([]failuredomain.FailureDomain{usEast1a, usEast1b, usEast1c}, []int{2, 1})
+			Expect(err).To(MatchError(errMismatchedFailureDomainWeights))
+			Expect(err).To(MatchError("the number of failure domain weights does not match the number of failure domains: 2 weights for 3 failure domains, in the order AWSFailureDomain{AvailabilityZone:us-east-1a}, AWSFailureDomain{AvailabilityZone:us-east-1b}, AWSFailureDomain{AvailabilityZone:us-east-1c}"))
+		})
+	})
+
+	Context("shares", func() {
+		type sharesTableInput struct {
+			weights        []int
+			count          int
+			expectedShares []int
+		}
+
+		DescribeTable("should share the indexes between the failure domains based on their weights", func(in sharesTableInput) {
+			failureDomains := []failuredomain.FailureDomain{usEast1a, usEast1b, usEast1c}
+
+			weights, err := newFailureDomainWeights(failureDomains, in.weights)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(weights.shares(failureDomains, in.count)).To(Equal(in.expectedShares))
+		},
+			Entry("with equal weights and three indexes", sharesTableInput{
+				weights:        []int{1, 1, 1},
+				count:          3,
+				expectedShares: []int{1, 1, 1},
+			}),
+			Entry("with equal weights and five indexes, gives the remainder to the first failure domains", sharesTableInput{
+				weights:        []int{1, 1, 1},
+				count:          5,
+				expectedShares: []int{2, 2, 1},
+			}),
+			Entry("with weights dividing the indexes exactly", sharesTableInput{
+				weights:        []int{1, 2, 2},
+				count:          5,
+				expectedShares: []int{1, 2, 2},
+			}),
+			Entry("with weights not dividing the indexes exactly, gives the remainder to the largest remainders", sharesTableInput{
+				weights:        []int{1, 1, 2},
+				count:          3,
+				expectedShares: []int{1, 1, 1},
+			}),
+			Entry("with a heavily weighted failure domain", sharesTableInput{
+				weights:        []int{3, 1, 1},
+				count:          3,
+				expectedShares: []int{2, 1, 0},
+			}),
+		)
+	})
+})
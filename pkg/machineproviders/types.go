@@ -39,6 +39,15 @@ type MachineInfo struct {
 	// Node has joined the cluster and is operating as expected.
 	Ready bool
 
+	// ReadySince is the time at which the Node backing the Machine last became Ready.
+	// This is only populated when Ready is true and the Node reports when its Ready condition last transitioned.
+	ReadySince *metav1.Time
+
+	// NotReadySince is the time at which the Node backing the Machine stopped being Ready.
+	// This is only populated when the Machine has a Node which is not Ready and the Node reports when its Ready
+	// condition last transitioned.
+	NotReadySince *metav1.Time
+
 	// NeedsUpdate is set true when the existing spec of the Machine does not match the desired spec of the Machine.
 	// This is used to inform the controller about decisions related to rolling out new machines.
 	NeedsUpdate bool
@@ -47,6 +56,20 @@ type MachineInfo struct {
 	// This is only ever populated when NeedsUpdate is true.
 	Diff []string
 
+	// IgnoredDiff is the computed difference between the existing spec of the Machine and the desired spec of the
+	// Machine, for the fields which are ignored when determining whether the Machine needs an update.
+	// Differences in these fields do not cause NeedsUpdate to be set.
+	IgnoredDiff []string
+
+	// AgeExceeded is set true when the Machine is older than the maximum machine age configured on the
+	// ControlPlaneMachineSet. When set, NeedsUpdate is also set and Diff contains a description of the exceeded age.
+	AgeExceeded bool
+
+	// EtcdMember describes the etcd member running on the Node backing the Machine.
+	// This is nil when the etcd membership of the control plane is not known, for example when the etcd operator
+	// has not published the etcd endpoints.
+	EtcdMember *EtcdMemberInfo
+
 	// Index denotes the Control Plane Machine index. Each Control Plane Machine replica is index (typically 0-2 in a
 	// three node cluster) and the Index will be needed to generate a replacement of this replica,  if a replacement is
 	// required.
@@ -55,6 +78,30 @@ type MachineInfo struct {
 	// ErrorMessage is used to provide information about any errors that have occurred with the Machine. For example, if
 	// the Machine has an error state within its status, it should be propagated up via this error message.
 	ErrorMessage string
+
+	// FailureDomainFailover describes the failover of the index of the Machine to another failure domain.
+	// This is only populated for a Machine which failed because its failure domain did not have the capacity to create
+	// it, once its index has been mapped to another failure domain.
+	FailureDomainFailover *FailureDomainFailover
+}
+
+// FailureDomainFailover describes the failover of an index from a failure domain without capacity to another.
+type FailureDomainFailover struct {
+	// From is the failure domain which did not have the capacity to create the Machine.
+	From string
+
+	// To is the failure domain in which the replacement for the Machine is created instead.
+	To string
+}
+
+// EtcdMemberInfo describes the etcd member running on a control plane Node.
+type EtcdMemberInfo struct {
+	// Voting is set true when the etcd member on the Node is a voting member of the etcd cluster.
+	// This is false when the member is still a learner, or when no member has been added for the Node.
+	Voting bool
+
+	// Healthy is set true when the etcd pod on the Node reports that it is ready.
+	Healthy bool
 }
 
 // ObjectRef allows you to uniquely identify a resource within a cluster.
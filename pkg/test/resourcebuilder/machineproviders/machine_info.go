@@ -38,26 +38,39 @@ type MachineInfoBuilder struct {
 	machineName              string
 	machineNamespace         string
 	machineLabels            map[string]string
+	machineAnnotations       map[string]string
 	machineOwnerRefs         []metav1.OwnerReference
 
 	nodeGVR  schema.GroupVersionResource
 	nodeName string
 
-	errorMessage string
-	index        int32
-	needsUpdate  bool
-	ready        bool
-	diff         []string
+	errorMessage          string
+	index                 int32
+	needsUpdate           bool
+	ready                 bool
+	readySince            *metav1.Time
+	notReadySince         *metav1.Time
+	diff                  []string
+	ignoredDiff           []string
+	ageExceeded           bool
+	etcdMember            *machineproviders.EtcdMemberInfo
+	failureDomainFailover *machineproviders.FailureDomainFailover
 }
 
 // Build builds a new machineinfo based on the configuration provided.
 func (m MachineInfoBuilder) Build() machineproviders.MachineInfo {
 	info := machineproviders.MachineInfo{
-		ErrorMessage: m.errorMessage,
-		Index:        m.index,
-		Ready:        m.ready,
-		NeedsUpdate:  m.needsUpdate,
-		Diff:         m.diff,
+		ErrorMessage:          m.errorMessage,
+		Index:                 m.index,
+		Ready:                 m.ready,
+		ReadySince:            m.readySince,
+		NotReadySince:         m.notReadySince,
+		NeedsUpdate:           m.needsUpdate,
+		Diff:                  m.diff,
+		IgnoredDiff:           m.ignoredDiff,
+		AgeExceeded:           m.ageExceeded,
+		EtcdMember:            m.etcdMember,
+		FailureDomainFailover: m.failureDomainFailover,
 	}
 
 	if m.machineName != "" {
@@ -67,6 +80,7 @@ func (m MachineInfoBuilder) Build() machineproviders.MachineInfo {
 				DeletionTimestamp: m.machineDeletiontimestamp,
 				CreationTimestamp: m.machineCreationtimestamp,
 				Labels:            m.machineLabels,
+				Annotations:       m.machineAnnotations,
 				Name:              m.machineName,
 				Namespace:         m.machineNamespace,
 				OwnerReferences:   m.machineOwnerRefs,
@@ -90,6 +104,12 @@ func (m MachineInfoBuilder) Build() machineproviders.MachineInfo {
 	return info
 }
 
+// WithAgeExceeded sets the age exceeded for the machineinfo builder.
+func (m MachineInfoBuilder) WithAgeExceeded(ageExceeded bool) MachineInfoBuilder {
+	m.ageExceeded = ageExceeded
+	return m
+}
+
 // WithDiff sets the needsupdate for the machineinfo builder.
 func (m MachineInfoBuilder) WithDiff(diff []string) MachineInfoBuilder {
 	if diff != nil {
@@ -101,6 +121,30 @@ func (m MachineInfoBuilder) WithDiff(diff []string) MachineInfoBuilder {
 	return m
 }
 
+// WithEtcdMember sets the etcd member for the machineinfo builder.
+func (m MachineInfoBuilder) WithEtcdMember(voting, healthy bool) MachineInfoBuilder {
+	m.etcdMember = &machineproviders.EtcdMemberInfo{Voting: voting, Healthy: healthy}
+	return m
+}
+
+// WithFailureDomainFailover sets the failure domain failover for the machineinfo builder.
+func (m MachineInfoBuilder) WithFailureDomainFailover(from, to string) MachineInfoBuilder {
+	m.failureDomainFailover = &machineproviders.FailureDomainFailover{From: from, To: to}
+	return m
+}
+
+// WithIgnoredDiff sets the ignored diff for the machineinfo builder.
+func (m MachineInfoBuilder) WithIgnoredDiff(ignoredDiff []string) MachineInfoBuilder {
+	m.ignoredDiff = ignoredDiff
+	return m
+}
+
+// WithMachineAnnotations sets the machine annotations for the machineinfo builder.
+func (m MachineInfoBuilder) WithMachineAnnotations(annotations map[string]string) MachineInfoBuilder {
+	m.machineAnnotations = annotations
+	return m
+}
+
 // WithMachineCreationTimestamp sets the machine creation timestamp for the machineinfo builder.
 func (m MachineInfoBuilder) WithMachineCreationTimestamp(creation metav1.Time) MachineInfoBuilder {
 	m.machineCreationtimestamp = creation
@@ -184,3 +228,15 @@ func (m MachineInfoBuilder) WithReady(ready bool) MachineInfoBuilder {
 	m.ready = ready
 	return m
 }
+
+// WithNotReadySince sets the time since which the machine has not been ready for the machineinfo builder.
+func (m MachineInfoBuilder) WithNotReadySince(notReadySince metav1.Time) MachineInfoBuilder {
+	m.notReadySince = &notReadySince
+	return m
+}
+
+// WithReadySince sets the time since which the machine has been ready for the machineinfo builder.
+func (m MachineInfoBuilder) WithReadySince(readySince metav1.Time) MachineInfoBuilder {
+	m.readySince = &readySince
+	return m
+}
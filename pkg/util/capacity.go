@@ -0,0 +1,78 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CapacityFailoverAnnotation is the annotation used on the ControlPlaneMachineSet to enable the failover of an index
+// to another failure domain, when a replacement Machine in the index failed because its failure domain had no
+// capacity for it.
+// The value must be a boolean.
+const CapacityFailoverAnnotation = "controlplanemachineset.machine.openshift.io/capacity-failover"
+
+// ErrInvalidCapacityFailover is used to inform users that the value of the capacity failover annotation is not valid.
+var ErrInvalidCapacityFailover = errors.New("capacity failover must be a boolean")
+
+// capacityErrorReasons are the error reasons reported by the infrastructure providers when a failure domain does not
+// have the capacity to create an instance.
+var capacityErrorReasons = []string{
+	// AWS.
+	"InsufficientInstanceCapacity",
+	"InsufficientHostCapacity",
+	// Azure, this also matches ZonalAllocationFailed.
+	"AllocationFailed",
+	"OverconstrainedZonalAllocationRequest",
+	// GCP.
+	"ZONE_RESOURCE_POOL_EXHAUSTED",
+	"ZonalResourcesExhausted",
+	// OpenStack.
+	"No valid host was found",
+}
+
+// GetCapacityFailover returns whether the failover of indexes for lack of capacity has been enabled by the capacity
+// failover annotation.
+// When the annotation is not present, indexes are never failed over and false is returned.
+func GetCapacityFailover(annotations map[string]string) (bool, error) {
+	value, ok := annotations[CapacityFailoverAnnotation]
+	if !ok {
+		return false, nil
+	}
+
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%w: %s: %q", ErrInvalidCapacityFailover, CapacityFailoverAnnotation, value)
+	}
+
+	return enabled, nil
+}
+
+// IsCapacityError determines whether the error message reported on a Machine indicates that the failure domain of the
+// Machine does not have the capacity to create it.
+func IsCapacityError(errorMessage string) bool {
+	for _, reason := range capacityErrorReasons {
+		if strings.Contains(errorMessage, reason) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Capacity failover", func() {
+	type getCapacityFailoverTableInput struct {
+		annotations     map[string]string
+		expectedEnabled bool
+		expectedError   string
+	}
+
+	DescribeTable("GetCapacityFailover", func(in getCapacityFailoverTableInput) {
+		enabled, err := GetCapacityFailover(in.annotations)
+
+		if in.expectedError != "" {
+			Expect(err).To(MatchError(in.expectedError))
+			Expect(err).To(MatchError(ErrInvalidCapacityFailover))
+		} else {
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		Expect(enabled).To(Equal(in.expectedEnabled))
+	},
+		Entry("with no annotations", getCapacityFailoverTableInput{
+			annotations:     nil,
+			expectedEnabled: false,
+		}),
+		Entry("with capacity failover enabled", getCapacityFailoverTableInput{
+			annotations: map[string]string{
+				CapacityFailoverAnnotation: "true",
+			},
+			expectedEnabled: true,
+		}),
+		Entry("with capacity failover disabled", getCapacityFailoverTableInput{
+			annotations: map[string]string{
+				CapacityFailoverAnnotation: "false",
+			},
+			expectedEnabled: false,
+		}),
+		Entry("with an invalid value", getCapacityFailoverTableInput{
+			annotations: map[string]string{
+				CapacityFailoverAnnotation: "sometimes",
+			},
+			expectedError: "capacity failover must be a boolean: controlplanemachineset.machine.openshift.io/capacity-failover: \"sometimes\"",
+		}),
+	)
+
+	DescribeTable("IsCapacityError", func(errorMessage string, expected bool) {
+		Expect(IsCapacityError(errorMessage)).To(Equal(expected))
+	},
+		Entry("with no error message", "", false),
+		Entry("with an AWS capacity error", "error launching instance: InsufficientInstanceCapacity: We currently do not have sufficient m6i.xlarge capacity in the Availability Zone you requested (us-east-1a).", true),
+		Entry("with an Azure capacity error", "failed to create vm: Code=\"ZonalAllocationFailed\" Message=\"Allocation failed.\"", true),
+		Entry("with a GCP capacity error", "googleapi: Error 503: The zone 'projects/openshift/zones/us-central1-a' does not have enough resources available to fulfill the request, ZONE_RESOURCE_POOL_EXHAUSTED", true),
+		Entry("with an OpenStack capacity error", "No valid host was found. There are not enough hosts available.", true),
+		Entry("with an unrelated error", "error launching instance: InvalidParameterValue: Invalid availability zone: [us-east-1z]", false),
+	)
+})
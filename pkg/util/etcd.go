@@ -0,0 +1,30 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+const (
+	// EtcdNamespace is the namespace in which the etcd operator runs the etcd members of the control plane.
+	EtcdNamespace = "openshift-etcd"
+
+	// EtcdEndpointsConfigMapName is the name of the ConfigMap, within the etcd namespace, in which the etcd operator
+	// publishes the addresses of the voting members of the etcd cluster. Learner members are not published.
+	EtcdEndpointsConfigMapName = "etcd-endpoints"
+
+	// EtcdPodNamePrefix is the prefix of the name of the etcd pod running on each control plane Node.
+	// The name of the pod is the prefix followed by the name of the Node.
+	EtcdPodNamePrefix = "etcd-"
+)
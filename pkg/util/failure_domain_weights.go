@@ -0,0 +1,58 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FailureDomainWeightsAnnotation is the annotation used on the ControlPlaneMachineSet to configure the share of the
+// indexes mapped to each failure domain. Failure domains with a higher weight are mapped to more indexes.
+// The value must be a comma separated list of positive integers, with one weight for each failure domain, in the
+// order in which the failure domains are configured within the ControlPlaneMachineSet template. When the failure
+// domains are read from the Infrastructure resource, as on vSphere and Nutanix, the weights follow the order of the
+// failure domains within the Infrastructure resource.
+const FailureDomainWeightsAnnotation = "controlplanemachineset.machine.openshift.io/failure-domain-weights"
+
+// ErrInvalidFailureDomainWeights is used to inform users that the value of the failure domain weights annotation is
+// not valid.
+var ErrInvalidFailureDomainWeights = errors.New("failure domain weights must be a comma separated list of positive integers")
+
+// GetFailureDomainWeights returns the failure domain weights configured by the failure domain weights annotation.
+// When the annotation is not present, the failure domains are weighted equally and nil is returned.
+func GetFailureDomainWeights(annotations map[string]string) ([]int, error) {
+	value, ok := annotations[FailureDomainWeightsAnnotation]
+	if !ok {
+		return nil, nil
+	}
+
+	weights := []int{}
+
+	for _, field := range strings.Split(value, ",") {
+		weight, err := strconv.ParseInt(strings.TrimSpace(field), 10, 32)
+		if err != nil || weight < 1 {
+			return nil, fmt.Errorf("%w: %s: %q", ErrInvalidFailureDomainWeights, FailureDomainWeightsAnnotation, value)
+		}
+
+		weights = append(weights, int(weight))
+	}
+
+	return weights, nil
+}
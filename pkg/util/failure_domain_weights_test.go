@@ -0,0 +1,84 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Failure domain weights", func() {
+	type getFailureDomainWeightsTableInput struct {
+		annotations     map[string]string
+		expectedWeights []int
+		expectedError   string
+	}
+
+	DescribeTable("GetFailureDomainWeights", func(in getFailureDomainWeightsTableInput) {
+		weights, err := GetFailureDomainWeights(in.annotations)
+
+		if in.expectedError != "" {
+			Expect(err).To(MatchError(in.expectedError))
+			Expect(err).To(MatchError(ErrInvalidFailureDomainWeights))
+		} else {
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		Expect(weights).To(Equal(in.expectedWeights))
+	},
+		Entry("with no annotations", getFailureDomainWeightsTableInput{
+			annotations:     nil,
+			expectedWeights: nil,
+		}),
+		Entry("with valid weights", getFailureDomainWeightsTableInput{
+			annotations: map[string]string{
+				FailureDomainWeightsAnnotation: "2,2,1",
+			},
+			expectedWeights: []int{2, 2, 1},
+		}),
+		Entry("with valid weights separated by spaces", getFailureDomainWeightsTableInput{
+			annotations: map[string]string{
+				FailureDomainWeightsAnnotation: "3, 1",
+			},
+			expectedWeights: []int{3, 1},
+		}),
+		Entry("with an empty value", getFailureDomainWeightsTableInput{
+			annotations: map[string]string{
+				FailureDomainWeightsAnnotation: "",
+			},
+			expectedError: "failure domain weights must be a comma separated list of positive integers: controlplanemachineset.machine.openshift.io/failure-domain-weights: \"\"",
+		}),
+		Entry("with an empty weight", getFailureDomainWeightsTableInput{
+			annotations: map[string]string{
+				FailureDomainWeightsAnnotation: "2,,1",
+			},
+			expectedError: "failure domain weights must be a comma separated list of positive integers: controlplanemachineset.machine.openshift.io/failure-domain-weights: \"2,,1\"",
+		}),
+		Entry("with a zero weight", getFailureDomainWeightsTableInput{
+			annotations: map[string]string{
+				FailureDomainWeightsAnnotation: "2,0,1",
+			},
+			expectedError: "failure domain weights must be a comma separated list of positive integers: controlplanemachineset.machine.openshift.io/failure-domain-weights: \"2,0,1\"",
+		}),
+		Entry("with a non-numeric weight", getFailureDomainWeightsTableInput{
+			annotations: map[string]string{
+				FailureDomainWeightsAnnotation: "high,low",
+			},
+			expectedError: "failure domain weights must be a comma separated list of positive integers: controlplanemachineset.machine.openshift.io/failure-domain-weights: \"high,low\"",
+		}),
+	)
+})
@@ -0,0 +1,59 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// MaxMachineAgeAnnotation is the annotation used on the ControlPlaneMachineSet to configure the maximum number of
+// seconds a Machine may exist before it is replaced. Machines older than the maximum age are reported as in need of
+// update so that the update strategy of the ControlPlaneMachineSet replaces them.
+// The value must be a positive integer.
+const MaxMachineAgeAnnotation = "controlplanemachineset.machine.openshift.io/max-machine-age-seconds"
+
+// ErrInvalidMaxMachineAge is used to inform users that the value of the maximum machine age annotation is not valid.
+var ErrInvalidMaxMachineAge = errors.New("maximum machine age seconds must be a positive integer")
+
+// GetMaxMachineAge returns the maximum machine age configured by the maximum machine age annotation.
+// When the annotation is not present, Machines are never replaced based on their age and 0 is returned.
+func GetMaxMachineAge(annotations map[string]string) (time.Duration, error) {
+	value, ok := annotations[MaxMachineAgeAnnotation]
+	if !ok {
+		return 0, nil
+	}
+
+	maxAgeSeconds, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || maxAgeSeconds < 1 {
+		return 0, fmt.Errorf("%w: %s: %q", ErrInvalidMaxMachineAge, MaxMachineAgeAnnotation, value)
+	}
+
+	return time.Duration(maxAgeSeconds) * time.Second, nil
+}
+
+// MachineAgeExceeded determines whether a Machine, created at the given time, is older than the maximum machine age.
+// When no maximum machine age is configured, Machines never exceed it.
+func MachineAgeExceeded(creationTimestamp time.Time, maxAge time.Duration, now time.Time) bool {
+	if maxAge <= 0 || creationTimestamp.IsZero() {
+		return false
+	}
+
+	return now.Sub(creationTimestamp) > maxAge
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Machine age", func() {
+	type getMaxMachineAgeTableInput struct {
+		annotations    map[string]string
+		expectedMaxAge time.Duration
+		expectedError  string
+	}
+
+	DescribeTable("GetMaxMachineAge", func(in getMaxMachineAgeTableInput) {
+		maxAge, err := GetMaxMachineAge(in.annotations)
+
+		if in.expectedError != "" {
+			Expect(err).To(MatchError(in.expectedError))
+			Expect(err).To(MatchError(ErrInvalidMaxMachineAge))
+		} else {
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		Expect(maxAge).To(Equal(in.expectedMaxAge))
+	},
+		Entry("with no annotations", getMaxMachineAgeTableInput{
+			annotations:    nil,
+			expectedMaxAge: 0,
+		}),
+		Entry("with a valid maximum machine age", getMaxMachineAgeTableInput{
+			annotations: map[string]string{
+				MaxMachineAgeAnnotation: "7776000",
+			},
+			expectedMaxAge: 90 * 24 * time.Hour,
+		}),
+		Entry("with a zero maximum machine age", getMaxMachineAgeTableInput{
+			annotations: map[string]string{
+				MaxMachineAgeAnnotation: "0",
+			},
+			expectedError: "maximum machine age seconds must be a positive integer: controlplanemachineset.machine.openshift.io/max-machine-age-seconds: \"0\"",
+		}),
+		Entry("with a negative maximum machine age", getMaxMachineAgeTableInput{
+			annotations: map[string]string{
+				MaxMachineAgeAnnotation: "-1",
+			},
+			expectedError: "maximum machine age seconds must be a positive integer: controlplanemachineset.machine.openshift.io/max-machine-age-seconds: \"-1\"",
+		}),
+		Entry("with a duration string", getMaxMachineAgeTableInput{
+			annotations: map[string]string{
+				MaxMachineAgeAnnotation: "90d",
+			},
+			expectedError: "maximum machine age seconds must be a positive integer: controlplanemachineset.machine.openshift.io/max-machine-age-seconds: \"90d\"",
+		}),
+	)
+
+	type machineAgeExceededTableInput struct {
+		creationTimestamp time.Time
+		maxAge            time.Duration
+		expected          bool
+	}
+
+	now := time.Date(2023, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	DescribeTable("MachineAgeExceeded", func(in machineAgeExceededTableInput) {
+		Expect(MachineAgeExceeded(in.creationTimestamp, in.maxAge, now)).To(Equal(in.expected))
+	},
+		Entry("with no maximum machine age", machineAgeExceededTableInput{
+			creationTimestamp: now.Add(-365 * 24 * time.Hour),
+			maxAge:            0,
+			expected:          false,
+		}),
+		Entry("with a Machine younger than the maximum machine age", machineAgeExceededTableInput{
+			creationTimestamp: now.Add(-time.Hour),
+			maxAge:            2 * time.Hour,
+			expected:          false,
+		}),
+		Entry("with a Machine older than the maximum machine age", machineAgeExceededTableInput{
+			creationTimestamp: now.Add(-3 * time.Hour),
+			maxAge:            2 * time.Hour,
+			expected:          true,
+		}),
+		Entry("with a Machine with no creation timestamp", machineAgeExceededTableInput{
+			creationTimestamp: time.Time{},
+			maxAge:            2 * time.Hour,
+			expected:          false,
+		}),
+	)
+})
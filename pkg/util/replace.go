@@ -0,0 +1,32 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ReplaceMachineAnnotation is the annotation used on a Machine to request that it is replaced, even when it is
+// up to date. Machines with this annotation are reported as in need of update so that the update strategy of the
+// ControlPlaneMachineSet replaces them.
+const ReplaceMachineAnnotation = "controlplanemachineset.machine.openshift.io/replace"
+
+// IsMarkedForReplacement determines whether the object has been marked for replacement by the replace machine annotation.
+func IsMarkedForReplacement(obj metav1.Object) bool {
+	_, ok := obj.GetAnnotations()[ReplaceMachineAnnotation]
+	return ok
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+
+	machinev1 "github.com/openshift/api/machine/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
+)
+
+// RevisionHashLabel is the label used to identify the revision of the ControlPlaneMachineSet template
+// from which a Machine, or a stored revision, was created.
+const RevisionHashLabel = "controlplanemachineset.machine.openshift.io/revision-hash"
+
+// ComputeTemplateHash returns a stable hash of the ControlPlaneMachineSet template.
+// The hash is safe to use within label values and resource names.
+func ComputeTemplateHash(template machinev1.ControlPlaneMachineSetTemplate) (string, error) {
+	data, err := json.Marshal(template)
+	if err != nil {
+		return "", fmt.Errorf("could not marshal control plane machine set template: %w", err)
+	}
+
+	hasher := fnv.New32a()
+	// Writes to the hash never return an error.
+	_, _ = hasher.Write(data)
+
+	return rand.SafeEncodeString(fmt.Sprint(hasher.Sum32())), nil
+}
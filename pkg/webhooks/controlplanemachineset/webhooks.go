@@ -27,6 +27,7 @@ import (
 	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers/openshift/machine/v1beta1/failuredomain"
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers/openshift/machine/v1beta1/providerconfig"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -160,7 +161,12 @@ func (r *ControlPlaneMachineSetWebhook) validateSpecOnCreate(ctx context.Context
 			fmt.Sprintf("control plane machine set replicas (%d) does not match the current number of control plane machines (%d)", *cpms.Spec.Replicas, len(controlPlaneMachines))))
 	}
 
-	errs = append(errs, validateTemplateOnCreate(r.logger, parentPath.Child("template"), cpms.Spec.Template, controlPlaneMachines)...)
+	infrastructure, err := r.fetchInfrastructure(ctx)
+	if err != nil {
+		return append(errs, fmt.Errorf("could not fetch infrastructure: %w", err))
+	}
+
+	errs = append(errs, validateTemplateOnCreate(r.logger, parentPath.Child("template"), cpms.Spec.Template, controlPlaneMachines, infrastructure)...)
 
 	return errs
 }
@@ -203,9 +209,9 @@ func validateTemplate(logger logr.Logger, parentPath *field.Path, template machi
 	}
 }
 
-// validateTemplateOnCreate validates the failure domains defined in the template match up with the Machines
-// that already exist within the cluster. This check is only performed on create.
-func validateTemplateOnCreate(logger logr.Logger, parentPath *field.Path, template machinev1.ControlPlaneMachineSetTemplate, machines []machinev1beta1.Machine) []error {
+// validateTemplateOnCreate validates the failure domains defined in the template, or on the Infrastructure,
+// match up with the Machines that already exist within the cluster. This check is only performed on create.
+func validateTemplateOnCreate(logger logr.Logger, parentPath *field.Path, template machinev1.ControlPlaneMachineSetTemplate, machines []machinev1beta1.Machine, infrastructure *configv1.Infrastructure) []error {
 	switch template.MachineType {
 	case machinev1.OpenShiftMachineV1Beta1MachineType:
 		openshiftMachineTemplatePath := parentPath.Child(string(machinev1.OpenShiftMachineV1Beta1MachineType))
@@ -216,7 +222,7 @@ func validateTemplateOnCreate(logger logr.Logger, parentPath *field.Path, templa
 			return []error{field.Required(openshiftMachineTemplatePath, fmt.Sprintf("%s is required when machine type is %s", machinev1.OpenShiftMachineV1Beta1MachineType, machinev1.OpenShiftMachineV1Beta1MachineType))}
 		}
 
-		return validateOpenShiftMachineV1BetaTemplateOnCreate(logger, openshiftMachineTemplatePath, *template.OpenShiftMachineV1Beta1Machine, machines)
+		return validateOpenShiftMachineV1BetaTemplateOnCreate(logger, openshiftMachineTemplatePath, *template.OpenShiftMachineV1Beta1Machine, machines, infrastructure)
 	default:
 		return []error{field.NotSupported(parentPath.Child("machineType"), template.MachineType, []string{string(machinev1.OpenShiftMachineV1Beta1MachineType)})}
 	}
@@ -234,16 +240,25 @@ func validateOpenShiftMachineV1BetaTemplate(logger logr.Logger, parentPath *fiel
 
 // validateOpenShiftMachineV1BetaTemplateOnCreate validates the failure domains in the provided template match up with those
 // present in the Machines provided.
-func validateOpenShiftMachineV1BetaTemplateOnCreate(logger logr.Logger, parentPath *field.Path, template machinev1.OpenShiftMachineV1Beta1MachineTemplate, machines []machinev1beta1.Machine) []error {
+// When the template has no failure domains, but the Infrastructure defines failure domains, as is the case on Nutanix
+// and vSphere, the Machines are validated against the failure domains of the Infrastructure instead.
+func validateOpenShiftMachineV1BetaTemplateOnCreate(logger logr.Logger, parentPath *field.Path, template machinev1.OpenShiftMachineV1Beta1MachineTemplate, machines []machinev1beta1.Machine, infrastructure *configv1.Infrastructure) []error {
 	errs := []error{}
 
-	if template.FailureDomains.Platform == "" {
-		errs = append(errs, checkOpenShiftProviderSpecFailureDomainMatchesMachines(logger, parentPath.Child("spec", "providerSpec"), template, machines)...)
-	} else {
-		errs = append(errs, checkOpenShiftFailureDomainsMatchMachines(logger, parentPath.Child("failureDomains"), template.FailureDomains, machines)...)
+	if template.FailureDomains.Platform != "" {
+		return append(errs, checkOpenShiftFailureDomainsMatchMachines(logger, parentPath.Child("failureDomains"), template.FailureDomains, machines)...)
 	}
 
-	return errs
+	templateProviderConfig, err := providerconfig.NewProviderConfigFromMachineTemplate(logger, template)
+	if err != nil {
+		return append(errs, field.Invalid(parentPath.Child("spec", "providerSpec"), template, fmt.Sprintf("error parsing provider config from machine template: %v", err)))
+	}
+
+	if infrastructureFailureDomains := failuredomain.NewFailureDomainsFromInfrastructure(infrastructure, templateProviderConfig.ExtractFailureDomain()); len(infrastructureFailureDomains) > 0 {
+		return append(errs, checkInfrastructureFailureDomainsMatchMachines(logger, parentPath.Child("spec", "providerSpec"), infrastructureFailureDomains, machines)...)
+	}
+
+	return append(errs, checkOpenShiftProviderSpecFailureDomainMatchesMachines(logger, parentPath.Child("spec", "providerSpec"), template, machines)...)
 }
 
 // validateTemplateLabels validates that the labels passed from the template match the expectations required.
@@ -281,6 +296,8 @@ func validateOpenShiftProviderConfig(logger logr.Logger, parentPath *field.Path,
 		return validateOpenShiftGCPProviderConfig(providerSpecPath.Child("value"), providerConfig.GCP())
 	case configv1.OpenStackPlatformType:
 		return validateOpenShiftOpenStackProviderConfig(providerSpecPath.Child("value"), providerConfig.OpenStack())
+	case configv1.PowerVSPlatformType:
+		return validateOpenShiftPowerVSProviderConfig(providerSpecPath.Child("value"), providerConfig.PowerVS())
 	}
 
 	return []error{}
@@ -312,6 +329,20 @@ func validateOpenShiftOpenStackProviderConfig(parentPath *field.Path, providerCo
 	return []error{}
 }
 
+// validateOpenShiftPowerVSProviderConfig runs PowerVS specific checks on the provider config on the ControlPlaneMachineSet.
+// This ensure that the ControlPlaneMachineSet can safely replace PowerVS control plane machines.
+func validateOpenShiftPowerVSProviderConfig(parentPath *field.Path, providerConfig providerconfig.PowerVSProviderConfig) []error {
+	errs := []error{}
+
+	config := providerConfig.Config()
+
+	if len(config.LoadBalancers) == 0 {
+		errs = append(errs, field.Required(parentPath.Child("loadBalancers"), "loadBalancers are required for control plane machines"))
+	}
+
+	return errs
+}
+
 // fetchControlPlaneMachines returns all control plane machines in the cluster.
 func (r *ControlPlaneMachineSetWebhook) fetchControlPlaneMachines(ctx context.Context) ([]machinev1beta1.Machine, error) {
 	machineList := machinev1beta1.MachineList{}
@@ -330,6 +361,19 @@ func (r *ControlPlaneMachineSetWebhook) fetchControlPlaneMachines(ctx context.Co
 	return controlPlaneMachines, nil
 }
 
+// fetchInfrastructure fetches the cluster Infrastructure.
+// If the Infrastructure does not exist, nil is returned.
+func (r *ControlPlaneMachineSetWebhook) fetchInfrastructure(ctx context.Context) (*configv1.Infrastructure, error) {
+	infrastructure := &configv1.Infrastructure{}
+	if err := r.client.Get(ctx, client.ObjectKey{Name: clusterSingletonName}, infrastructure); apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error querying api for infrastructure: %w", err)
+	}
+
+	return infrastructure, nil
+}
+
 // checkOpenShiftProviderSpecFailureDomainMatchesMachines ensures that failure domains of the Control Plane Machines match the
 // failure domain extracted from the OpenShift Machine template MachineSpec on the ControlPlaneMachineSet.
 // This check is performed when no failure domains are defined on the OpenShift Machine template on the ControlPlaneMachineSet.
@@ -358,6 +402,25 @@ func checkOpenShiftProviderSpecFailureDomainMatchesMachines(logger logr.Logger,
 	return errs
 }
 
+// checkInfrastructureFailureDomainsMatchMachines ensures that the Control Plane Machines only use failure domains
+// which are defined on the Infrastructure.
+// This check is performed when no failure domains are defined on the OpenShift Machine template on the ControlPlaneMachineSet,
+// but the failure domains are defined on the Infrastructure instead. For example on multi zone Nutanix and vSphere deployments.
+func checkInfrastructureFailureDomainsMatchMachines(logger logr.Logger, parentPath *field.Path, infrastructureFailureDomains []failuredomain.FailureDomain, machines []machinev1beta1.Machine) []error {
+	errs := []error{}
+
+	machineFailureDomains, err := getMachineFailureDomains(logger, machines)
+	if err != nil {
+		return append(errs, field.InternalError(parentPath, fmt.Errorf("could not get failure domains from cluster machines: %w", err)))
+	}
+
+	if missingFailureDomains := missingFailureDomains(machineFailureDomains, infrastructureFailureDomains); len(missingFailureDomains) > 0 {
+		errs = append(errs, field.Forbidden(parentPath, fmt.Sprintf("control plane machines are using failure domain(s) %s which are not defined on the infrastructure", missingFailureDomains)))
+	}
+
+	return errs
+}
+
 // checkOpenShiftFailureDomainsMatchMachines ensures that failure domains of the Control Plane Machines match the
 // failure domains defined on the OpenShift Machine template on the ControlPlaneMachineSet.
 func checkOpenShiftFailureDomainsMatchMachines(logger logr.Logger, parentPath *field.Path, failureDomains machinev1.FailureDomains, machines []machinev1beta1.Machine) []error {
@@ -18,6 +18,7 @@ package controlplanemachineset
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -32,7 +33,10 @@ import (
 	corev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/core/v1"
 	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
 	machinev1beta1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1beta1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers/openshift/machine/v1beta1/failuredomain"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/utils/pointer"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/envtest/komega"
@@ -1107,3 +1111,179 @@ var _ = Describe("Webhooks", func() {
 		})
 	})
 })
+
+var _ = Describe("checkInfrastructureFailureDomainsMatchMachines", func() {
+	providerSpecPath := field.NewPath("spec", "template", "machines_v1beta1_machine_openshift_io", "spec", "providerSpec")
+
+	var logger testutils.TestLogger
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+	})
+
+	vsphereFailureDomain := func(datacenter string) configv1.VSpherePlatformFailureDomainSpec {
+		return configv1.VSpherePlatformFailureDomainSpec{
+			Server: "vcenter.example.com",
+			Topology: configv1.VSpherePlatformTopology{
+				Datacenter: datacenter,
+				Networks:   []string{"test-segment-01"},
+			},
+		}
+	}
+
+	vsphereMachine := func(datacenter string) machinev1beta1.Machine {
+		providerSpec := machinev1beta1resourcebuilder.VSphereProviderSpec().Build()
+		providerSpec.Workspace = &machinev1beta1.Workspace{
+			Server:     "vcenter.example.com",
+			Datacenter: datacenter,
+		}
+
+		raw, err := json.Marshal(providerSpec)
+		Expect(err).ToNot(HaveOccurred())
+
+		machine := machinev1beta1resourcebuilder.Machine().AsMaster().Build()
+		machine.Spec.ProviderSpec.Value = &runtime.RawExtension{Raw: raw}
+
+		return *machine
+	}
+
+	infrastructureFailureDomains := []failuredomain.FailureDomain{
+		failuredomain.NewVSphereFailureDomain(vsphereFailureDomain("dc1")),
+		failuredomain.NewVSphereFailureDomain(vsphereFailureDomain("dc2")),
+		failuredomain.NewVSphereFailureDomain(vsphereFailureDomain("dc3")),
+	}
+
+	It("allows machines spread across the infrastructure failure domains", func() {
+		machines := []machinev1beta1.Machine{vsphereMachine("dc1"), vsphereMachine("dc2"), vsphereMachine("dc3")}
+
+		Expect(checkInfrastructureFailureDomainsMatchMachines(logger.Logger(), providerSpecPath, infrastructureFailureDomains, machines)).To(BeEmpty())
+	})
+
+	It("allows machines using only some of the infrastructure failure domains", func() {
+		machines := []machinev1beta1.Machine{vsphereMachine("dc1"), vsphereMachine("dc1"), vsphereMachine("dc2")}
+
+		Expect(checkInfrastructureFailureDomainsMatchMachines(logger.Logger(), providerSpecPath, infrastructureFailureDomains, machines)).To(BeEmpty())
+	})
+
+	It("rejects machines using a failure domain not defined on the infrastructure", func() {
+		machines := []machinev1beta1.Machine{vsphereMachine("dc1"), vsphereMachine("dc2"), vsphereMachine("dc4")}
+
+		Expect(checkInfrastructureFailureDomainsMatchMachines(logger.Logger(), providerSpecPath, infrastructureFailureDomains, machines)).To(ConsistOf(
+			field.Forbidden(providerSpecPath, "control plane machines are using failure domain(s) [VSphereFailureDomain{Server:vcenter.example.com, Datacenter:dc4, Networks:[test-segment-01]}] which are not defined on the infrastructure"),
+		))
+	})
+})
+
+var _ = Describe("validateOpenShiftMachineV1BetaTemplateOnCreate with Nutanix Prism Elements", func() {
+	templatePath := field.NewPath("spec", "template", "machines_v1beta1_machine_openshift_io")
+
+	var logger testutils.TestLogger
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+	})
+
+	nutanixProviderSpec := func(cluster string) *runtime.RawExtension {
+		raw, err := json.Marshal(&machinev1.NutanixMachineProviderConfig{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "NutanixMachineProviderConfig",
+				APIVersion: "machine.openshift.io/v1",
+			},
+			Cluster: machinev1.NutanixResourceIdentifier{Type: machinev1.NutanixIdentifierName, Name: pointer.String(cluster)},
+			Subnets: []machinev1.NutanixResourceIdentifier{{Type: machinev1.NutanixIdentifierName, Name: pointer.String("subnet1")}},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		return &runtime.RawExtension{Raw: raw}
+	}
+
+	nutanixMachine := func(cluster string) machinev1beta1.Machine {
+		machine := machinev1beta1resourcebuilder.Machine().AsMaster().Build()
+		machine.Spec.ProviderSpec.Value = nutanixProviderSpec(cluster)
+
+		return *machine
+	}
+
+	infrastructure := &configv1.Infrastructure{
+		Spec: configv1.InfrastructureSpec{
+			PlatformSpec: configv1.PlatformSpec{
+				Type: configv1.NutanixPlatformType,
+				Nutanix: &configv1.NutanixPlatformSpec{
+					PrismElements: []configv1.NutanixPrismElementEndpoint{{Name: "pe1"}, {Name: "pe2"}, {Name: "pe3"}},
+				},
+			},
+		},
+	}
+
+	template := machinev1.OpenShiftMachineV1Beta1MachineTemplate{
+		Spec: machinev1beta1.MachineSpec{
+			ProviderSpec: machinev1beta1.ProviderSpec{Value: nutanixProviderSpec("pe1")},
+		},
+	}
+
+	It("allows machines spread across the Prism Elements", func() {
+		machines := []machinev1beta1.Machine{nutanixMachine("pe1"), nutanixMachine("pe2"), nutanixMachine("pe3")}
+
+		Expect(validateOpenShiftMachineV1BetaTemplateOnCreate(logger.Logger(), templatePath, template, machines, infrastructure)).To(BeEmpty())
+	})
+
+	It("rejects machines using a Prism Element not defined on the infrastructure", func() {
+		machines := []machinev1beta1.Machine{nutanixMachine("pe1"), nutanixMachine("pe2"), nutanixMachine("pe4")}
+
+		Expect(validateOpenShiftMachineV1BetaTemplateOnCreate(logger.Logger(), templatePath, template, machines, infrastructure)).To(ConsistOf(
+			field.Forbidden(templatePath.Child("spec", "providerSpec"), "control plane machines are using failure domain(s) [NutanixFailureDomain{Cluster:{Type:name, Name:pe4}, Subnets:[{Type:name, Name:subnet1}]}] which are not defined on the infrastructure"),
+		))
+	})
+})
+
+var _ = Describe("validateOpenShiftProviderConfig on PowerVS", func() {
+	templatePath := field.NewPath("spec", "template", "machines_v1beta1_machine_openshift_io")
+	loadBalancersPath := templatePath.Child("spec", "providerSpec", "value", "loadBalancers")
+
+	var logger testutils.TestLogger
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+	})
+
+	powerVSTemplate := func(loadBalancers []machinev1.LoadBalancerReference) machinev1.OpenShiftMachineV1Beta1MachineTemplate {
+		providerConfig := &machinev1.PowerVSMachineProviderConfig{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "PowerVSMachineProviderConfig",
+				APIVersion: machinev1.GroupVersion.String(),
+			},
+			ServiceInstance: machinev1.PowerVSResource{Type: machinev1.PowerVSResourceTypeID, ID: pointer.String("0000-1111")},
+			Image:           machinev1.PowerVSResource{Type: machinev1.PowerVSResourceTypeName, Name: pointer.String("rhcos")},
+			Network:         machinev1.PowerVSResource{Type: machinev1.PowerVSResourceTypeName, Name: pointer.String("private")},
+			KeyPairName:     "keypair",
+			LoadBalancers:   loadBalancers,
+		}
+
+		raw, err := json.Marshal(providerConfig)
+		Expect(err).ToNot(HaveOccurred())
+
+		return machinev1.OpenShiftMachineV1Beta1MachineTemplate{
+			Spec: machinev1beta1.MachineSpec{
+				ProviderSpec: machinev1beta1.ProviderSpec{
+					Value: &runtime.RawExtension{Raw: raw},
+				},
+			},
+		}
+	}
+
+	It("with load balancers", func() {
+		template := powerVSTemplate([]machinev1.LoadBalancerReference{
+			{Name: "cluster-loadbalancer-int", Type: machinev1.ApplicationLoadBalancerType},
+		})
+
+		Expect(validateOpenShiftProviderConfig(logger.Logger(), templatePath, template)).To(BeEmpty())
+	})
+
+	It("without load balancers", func() {
+		template := powerVSTemplate(nil)
+
+		Expect(validateOpenShiftProviderConfig(logger.Logger(), templatePath, template)).To(ConsistOf(
+			field.Required(loadBalancersPath, "loadBalancers are required for control plane machines"),
+		))
+	})
+})